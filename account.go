@@ -0,0 +1,47 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrPasswordNotSet = Error{EConflict, errors.New("set a password to confirm this with first")}
+
+// Account deletion policies, deciding what becomes of the articles and
+// comments of users who delete their account. Favorites, bookmarks,
+// reactions and follows go with the account either way.
+const (
+	// DeletionAnonymize keeps the content, attributed to a banned placeholder
+	// that replaces the user.
+	DeletionAnonymize = "anonymize"
+	// DeletionRemove removes the content along with the user. Comments that
+	// have replies are kept as placeholders.
+	DeletionRemove = "remove"
+)
+
+func ValidDeletionPolicy(policy string) bool {
+	return policy == DeletionAnonymize || policy == DeletionRemove
+}
+
+// AccountExport is everything a user has given us, handed back to them on
+// request. Following and Followers hold usernames.
+type AccountExport struct {
+	User       User
+	Articles   []*Article
+	Comments   []*Comment
+	Favorites  []*Article
+	Bookmarks  []*Article
+	Following  []string
+	Followers  []string
+	Identities []*Identity
+	APIKeys    []*APIKey
+	ExportedAt time.Time
+}
+
+type AccountService interface {
+	// DeleteAccount deletes the user, who has to confirm it with their
+	// password, ending their sessions and removing their API keys,
+	// identities and second factor.
+	DeleteAccount(u User, password string) error
+	ExportAccount(u User) (*AccountExport, error)
+}
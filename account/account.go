@@ -0,0 +1,292 @@
+package account
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	realworld "github.com/xesina/gokit-realworld"
+	"math"
+	"time"
+)
+
+// everything is the page size that lists every item at once.
+const everything = math.MaxInt32
+
+type Service struct {
+	UserRepo    realworld.UserRepo
+	ArticleRepo realworld.ArticleRepo
+	// Articles deletes comments the way their authors would, keeping
+	// placeholders for the ones that have replies.
+	Articles realworld.ArticleService
+	// Policy is one of the deletion policies, DeletionAnonymize when it's empty.
+	Policy string
+
+	// Sessions, APIKeys, Identities and TwoFactor, when set, are cleared of
+	// what belongs to deleted users.
+	Sessions   realworld.SessionService
+	APIKeys    realworld.APIKeyRepo
+	Identities realworld.IdentityRepo
+	TwoFactor  realworld.TwoFactorRepo
+	// Restrictions, when set, is cleared of the blocks and mutes deleted
+	// users put on others and others put on them.
+	Restrictions realworld.RestrictionRepo
+	// Avatars, when set, has the avatars of deleted users removed.
+	Avatars realworld.AvatarService
+	// Attachments, when set, has the attachments of removed articles
+	// removed too.
+	Attachments realworld.AttachmentService
+	// Usernames, when set, forgets the usernames deleted users gave up, so
+	// they no longer lead to them.
+	Usernames realworld.UsernameHistoryRepo
+}
+
+// DeleteAccount takes the account apart one step at a time, as what it
+// touches is kept in more than one place. Every step can be repeated, and
+// the user and the ways of logging in as them go last, so after a failure
+// the user can log in and delete the account again to finish.
+func (s Service) DeleteAccount(u realworld.User, password string) error {
+	found, err := s.UserRepo.GetByID(u.ID)
+	if err != nil {
+		return err
+	}
+
+	// Users who only log in with an identity provider have no password to
+	// confirm with.
+	if found.Password == "" {
+		return realworld.ErrPasswordNotSet
+	}
+	if !found.CheckPassword(password) {
+		return realworld.ErrIncorrectPasswordError
+	}
+
+	// Logging out first keeps anything from being done with the account
+	// while it's taken apart.
+	if s.Sessions != nil {
+		if err := s.Sessions.RevokeSessions(*found); err != nil {
+			return err
+		}
+	}
+
+	if s.Avatars != nil {
+		if _, err := s.Avatars.RemoveAvatar(*found); err != nil {
+			return err
+		}
+	}
+
+	if err := s.ArticleRepo.ForgetUser(found.ID); err != nil {
+		return err
+	}
+
+	if err := s.removeRestrictions(found.ID); err != nil {
+		return err
+	}
+
+	if s.Usernames != nil {
+		if err := s.Usernames.DeleteByUserID(found.ID); err != nil {
+			return err
+		}
+	}
+
+	if s.Policy == realworld.DeletionRemove {
+		if err := s.removeContent(found.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.removeCredentials(found.ID); err != nil {
+		return err
+	}
+
+	if s.Policy == realworld.DeletionRemove {
+		return s.UserRepo.Delete(found.ID)
+	}
+
+	username, err := placeholderUsername()
+	if err != nil {
+		return realworld.InternalError(err)
+	}
+	return s.UserRepo.Anonymize(found.ID, username, username+"@deleted.invalid")
+}
+
+// removeCredentials removes every other way of logging in as the user. The
+// second factor goes last, so a failure before it leaves the password as
+// well protected as it was.
+func (s Service) removeCredentials(id int64) error {
+	if s.APIKeys != nil {
+		kk, err := s.APIKeys.ListByUserID(id)
+		if err != nil {
+			return err
+		}
+		for _, k := range kk {
+			if err := s.APIKeys.Delete(id, k.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.Identities != nil {
+		ii, err := s.Identities.ListByUserID(id)
+		if err != nil {
+			return err
+		}
+		for _, i := range ii {
+			if err := s.Identities.Delete(id, i.Provider); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.TwoFactor != nil {
+		return s.TwoFactor.Delete(id)
+	}
+
+	return nil
+}
+
+// removeRestrictions removes the restrictions the user put on others and
+// others put on them.
+func (s Service) removeRestrictions(id int64) error {
+	if s.Restrictions == nil {
+		return nil
+	}
+
+	for _, kind := range []string{realworld.RestrictionBlock, realworld.RestrictionMute} {
+		targets, err := s.Restrictions.TargetIDs(id, kind)
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			if err := s.Restrictions.Remove(realworld.Restriction{UserID: id, TargetID: target, Kind: kind}); err != nil {
+				return err
+			}
+		}
+
+		users, err := s.Restrictions.UserIDs(id, kind)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := s.Restrictions.Remove(realworld.Restriction{UserID: user, TargetID: id, Kind: kind}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeContent removes the user's articles and comments, along with their
+// edit history.
+func (s Service) removeContent(id int64) error {
+	if err := s.ArticleRepo.DeleteByAuthorID(id); err != nil {
+		return err
+	}
+
+	// Only authors attach to articles, so the user's attachments were all on
+	// the articles that are gone.
+	if s.Attachments != nil {
+		if err := s.Attachments.DeleteByUserID(id); err != nil {
+			return err
+		}
+	}
+
+	cc, err := s.ArticleRepo.CommentsByUserID(id)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cc {
+		if err := s.ArticleRepo.DeleteCommentEdits(*c); err != nil {
+			return err
+		}
+		// Placeholders are already stripped of what the user wrote.
+		if c.Deleted {
+			continue
+		}
+		err := s.Articles.DeleteComment(realworld.Comment{ID: c.ID, Article: c.Article, UserID: id})
+		if err != nil && !errors.Is(err, realworld.ErrCommentNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// placeholderUsername makes up a username for an anonymized user that
+// nobody will have taken.
+func placeholderUsername() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "deleted-" + hex.EncodeToString(b), nil
+}
+
+func (s Service) ExportAccount(u realworld.User) (*realworld.AccountExport, error) {
+	found, err := s.UserRepo.GetByID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	e := realworld.AccountExport{
+		User:       *found,
+		ExportedAt: time.Now(),
+	}
+
+	if e.Articles, _, err = s.ArticleRepo.ListByAuthorID(found.ID, 0, everything); err != nil {
+		return nil, err
+	}
+	if e.Favorites, _, err = s.ArticleRepo.ListByFavoriterID(found.ID, 0, everything); err != nil {
+		return nil, err
+	}
+	if e.Bookmarks, _, err = s.ArticleRepo.ListByBookmarkerID(found.ID, 0, everything); err != nil {
+		return nil, err
+	}
+
+	cc, err := s.ArticleRepo.CommentsByUserID(found.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cc {
+		if !c.Deleted {
+			e.Comments = append(e.Comments, c)
+		}
+	}
+
+	if e.Following, err = s.usernames(found.Followings); err != nil {
+		return nil, err
+	}
+	if e.Followers, err = s.usernames(found.Followers); err != nil {
+		return nil, err
+	}
+
+	if s.Identities != nil {
+		if e.Identities, err = s.Identities.ListByUserID(found.ID); err != nil {
+			return nil, err
+		}
+	}
+	if s.APIKeys != nil {
+		if e.APIKeys, err = s.APIKeys.ListByUserID(found.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &e, nil
+}
+
+// usernames looks up the usernames of the users in ff, skipping those who
+// are gone.
+func (s Service) usernames(ff realworld.Follows) ([]string, error) {
+	names := make([]string, 0, len(ff))
+	for _, id := range ff.List() {
+		u, err := s.UserRepo.GetByID(id)
+		if err != nil {
+			if errors.Is(err, realworld.ErrUserNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		names = append(names, u.Username)
+	}
+	return names, nil
+}
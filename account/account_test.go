@@ -0,0 +1,203 @@
+package account
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/article"
+	"github.com/xesina/gokit-realworld/inmem"
+	"strings"
+	"testing"
+)
+
+// newService sets up alice, who wrote an article and a comment with a reply,
+// favorited bob's article and follows him.
+func newService(t *testing.T, policy string) (Service, *realworld.User, *realworld.User) {
+	users := inmem.NewMemUserSaver()
+	articles := inmem.NewMemArticleRepo()
+	s := Service{
+		UserRepo:    users,
+		ArticleRepo: articles,
+		Articles:    article.Service{Repo: articles},
+		Policy:      policy,
+		APIKeys:     inmem.NewMemAPIKeyRepo(),
+	}
+
+	alice := &realworld.User{Username: "alice", Email: "alice@example.com"}
+	hashed, err := alice.HashPassword("secret")
+	assert.NoError(t, err)
+	alice.Password = hashed
+	alice, err = users.Create(*alice)
+	assert.NoError(t, err)
+	bob, err := users.Create(realworld.User{Username: "bobby", Email: "bobby@example.com"})
+	assert.NoError(t, err)
+
+	_, err = articles.Create(realworld.Article{Slug: "by-alice", Title: "By alice", Author: *alice})
+	assert.NoError(t, err)
+	byBob, err := articles.Create(realworld.Article{Slug: "by-bob", Title: "By bob", Author: *bob})
+	assert.NoError(t, err)
+	_, err = articles.AddFavorite(*byBob, *alice)
+	assert.NoError(t, err)
+	_, err = articles.AddReaction(realworld.Reaction{Kind: "like", UserID: alice.ID}, *byBob)
+	assert.NoError(t, err)
+
+	c, err := articles.AddComment(realworld.Comment{Article: *byBob, UserID: alice.ID, Body: "first"})
+	assert.NoError(t, err)
+	_, err = articles.AddCommentEdit(realworld.CommentEdit{CommentID: c.ID, Body: "frist"})
+	assert.NoError(t, err)
+	_, err = articles.AddComment(realworld.Comment{Article: *byBob, ParentID: c.ID, UserID: bob.ID, Body: "reply"})
+	assert.NoError(t, err)
+
+	_, err = users.AddFollower(alice.ID, bob.ID)
+	assert.NoError(t, err)
+	_, err = s.APIKeys.Create(realworld.APIKey{UserID: alice.ID, Name: "ci", Hash: "h"})
+	assert.NoError(t, err)
+
+	return s, alice, bob
+}
+
+func TestService_ExportAccount(t *testing.T) {
+	s, alice, _ := newService(t, "")
+
+	e, err := s.ExportAccount(*alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", e.User.Username)
+	assert.Len(t, e.Articles, 1)
+	assert.Equal(t, "by-alice", e.Articles[0].Slug)
+	assert.Len(t, e.Comments, 1)
+	assert.Equal(t, "by-bob", e.Comments[0].Article.Slug)
+	assert.Len(t, e.Favorites, 1)
+	assert.Equal(t, "by-bob", e.Favorites[0].Slug)
+	assert.Equal(t, []string{"bobby"}, e.Following)
+	assert.Empty(t, e.Followers)
+	assert.Len(t, e.APIKeys, 1)
+}
+
+func TestService_DeleteAccount_Anonymize(t *testing.T) {
+	s, alice, bob := newService(t, realworld.DeletionAnonymize)
+
+	assert.Equal(t, realworld.ErrIncorrectPasswordError, s.DeleteAccount(*alice, "wrong"))
+	assert.NoError(t, s.DeleteAccount(*alice, "secret"))
+
+	_, err := s.UserRepo.Get("alice@example.com")
+	assert.Equal(t, realworld.ErrUserNotFound, err)
+
+	placeholder, err := s.UserRepo.GetByID(alice.ID)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(placeholder.Username, "deleted-"))
+	assert.True(t, placeholder.Banned)
+	assert.Empty(t, placeholder.Password)
+
+	// what alice wrote stays, what she did with bob's article goes
+	a, err := s.ArticleRepo.Get("by-alice")
+	assert.NoError(t, err)
+	assert.Equal(t, alice.ID, a.Author.ID)
+	cc, err := s.ArticleRepo.CommentsByUserID(alice.ID)
+	assert.NoError(t, err)
+	assert.Len(t, cc, 1)
+
+	byBob, err := s.ArticleRepo.Get("by-bob")
+	assert.NoError(t, err)
+	assert.False(t, byBob.Favorited(alice.ID))
+	assert.Empty(t, byBob.Reactions.By(alice.ID))
+
+	b, err := s.UserRepo.GetByID(bob.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, b.Followers)
+
+	kk, err := s.APIKeys.ListByUserID(alice.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, kk)
+
+	// the placeholder has no password to confirm another deletion with
+	assert.Equal(t, realworld.ErrPasswordNotSet, s.DeleteAccount(*placeholder, ""))
+}
+
+func TestService_DeleteAccount_Remove(t *testing.T) {
+	s, alice, _ := newService(t, realworld.DeletionRemove)
+
+	assert.NoError(t, s.DeleteAccount(*alice, "secret"))
+
+	_, err := s.UserRepo.GetByID(alice.ID)
+	assert.Equal(t, realworld.ErrUserNotFound, err)
+
+	_, err = s.ArticleRepo.Get("by-alice")
+	assert.Equal(t, realworld.ErrArticleNotFound, err)
+
+	// the comment bob replied to is kept as a placeholder, without its history
+	cc, err := s.ArticleRepo.CommentsByUserID(alice.ID)
+	assert.NoError(t, err)
+	assert.Len(t, cc, 1)
+	assert.True(t, cc[0].Deleted)
+	assert.Empty(t, cc[0].Body)
+	edits, err := s.ArticleRepo.CommentEdits(*cc[0])
+	assert.NoError(t, err)
+	assert.Empty(t, edits)
+}
+
+// failOnce fails the next call made through fail.
+type failOnce struct {
+	failed bool
+}
+
+func (f *failOnce) fail() error {
+	if f.failed {
+		return nil
+	}
+	f.failed = true
+	return errors.New("storage unavailable")
+}
+
+type flakyUsernames struct {
+	realworld.UsernameHistoryRepo
+	failOnce
+}
+
+func (r *flakyUsernames) DeleteByUserID(id int64) error {
+	if err := r.fail(); err != nil {
+		return err
+	}
+	return r.UsernameHistoryRepo.DeleteByUserID(id)
+}
+
+type flakyUsers struct {
+	realworld.UserRepo
+	failOnce
+}
+
+func (r *flakyUsers) Delete(id int64) error {
+	if err := r.fail(); err != nil {
+		return err
+	}
+	return r.UserRepo.Delete(id)
+}
+
+func TestService_DeleteAccount_Retry(t *testing.T) {
+	s, alice, _ := newService(t, realworld.DeletionRemove)
+	s.Usernames = &flakyUsernames{UsernameHistoryRepo: inmem.NewMemUsernameHistoryRepo()}
+	users := &flakyUsers{UserRepo: s.UserRepo}
+	s.UserRepo = users
+
+	// Failing halfway leaves the user able to log in with their password,
+	// and their other credentials, to try again.
+	assert.Error(t, s.DeleteAccount(*alice, "secret"))
+	_, err := s.UserRepo.GetByID(alice.ID)
+	assert.NoError(t, err)
+	kk, err := s.APIKeys.ListByUserID(alice.ID)
+	assert.NoError(t, err)
+	assert.Len(t, kk, 1)
+
+	// Failing at the last step, after the content is gone, too.
+	assert.Error(t, s.DeleteAccount(*alice, "secret"))
+	_, err = s.ArticleRepo.Get("by-alice")
+	assert.Equal(t, realworld.ErrArticleNotFound, err)
+
+	assert.NoError(t, s.DeleteAccount(*alice, "secret"))
+	_, err = s.UserRepo.GetByID(alice.ID)
+	assert.Equal(t, realworld.ErrUserNotFound, err)
+	cc, err := s.ArticleRepo.CommentsByUserID(alice.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, cc, 1) {
+		assert.True(t, cc[0].Deleted)
+	}
+}
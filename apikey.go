@@ -0,0 +1,76 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound = Error{ENotFound, errors.New("api key not found")}
+	ErrInvalidAPIKey  = Error{EUnauthorized, errors.New("invalid api key")}
+	ErrInvalidScope   = Error{EConflict, errors.New("unknown scope")}
+)
+
+// APIKeyPrefix starts every API key, which tells them apart from access tokens.
+const APIKeyPrefix = "rwk_"
+
+// Scopes an API key can be limited to.
+const (
+	ScopeArticlesRead  = "articles:read"
+	ScopeArticlesWrite = "articles:write"
+	ScopeCommentsRead  = "comments:read"
+	ScopeCommentsWrite = "comments:write"
+	ScopeProfileRead   = "profile:read"
+	ScopeProfileWrite  = "profile:write"
+)
+
+var Scopes = []string{
+	ScopeArticlesRead,
+	ScopeArticlesWrite,
+	ScopeCommentsRead,
+	ScopeCommentsWrite,
+	ScopeProfileRead,
+	ScopeProfileWrite,
+}
+
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey lets scripts act for a user within its Scopes without a password.
+// Only a hash of the key is stored; Prefix keeps enough of it for the user
+// to tell their keys apart.
+type APIKey struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+type APIKeyService interface {
+	// CreateAPIKey returns the new key along with the plain key, which can't be recovered later.
+	CreateAPIKey(u User, name string, scopes []string) (*APIKey, string, error)
+	APIKeys(u User) ([]*APIKey, error)
+	RevokeAPIKey(u User, id int64) error
+	// Authenticate returns the key a plain key belongs to and records its use.
+	Authenticate(key string) (*APIKey, error)
+}
+
+type APIKeyRepo interface {
+	Create(k APIKey) (*APIKey, error)
+	// GetByHash returns ErrInvalidAPIKey when no key has the hash.
+	GetByHash(hash string) (*APIKey, error)
+	ListByUserID(userID int64) ([]*APIKey, error)
+	// Delete returns ErrAPIKeyNotFound unless the user has a key with the ID.
+	Delete(userID, id int64) error
+	Touch(id int64, at time.Time) error
+}
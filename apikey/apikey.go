@@ -0,0 +1,82 @@
+package apikey
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/token"
+	"time"
+)
+
+const (
+	// prefixLength is how much of a key is kept in plain to identify it.
+	prefixLength = len(realworld.APIKeyPrefix) + 6
+	// touchEvery limits how often the last use of a key is written.
+	touchEvery = time.Minute
+)
+
+type Service struct {
+	Repo realworld.APIKeyRepo
+	// Users, when set, is checked so keys of banned users stop working.
+	Users realworld.UserRepo
+}
+
+func (s Service) CreateAPIKey(u realworld.User, name string, scopes []string) (*realworld.APIKey, string, error) {
+	for _, scope := range scopes {
+		if !realworld.ValidScope(scope) {
+			return nil, "", realworld.ErrInvalidScope
+		}
+	}
+
+	plain, _, err := token.Generate()
+	if err != nil {
+		return nil, "", realworld.InternalError(err)
+	}
+	plain = realworld.APIKeyPrefix + plain
+
+	k, err := s.Repo.Create(realworld.APIKey{
+		UserID: u.ID,
+		Name:   name,
+		Prefix: plain[:prefixLength],
+		Hash:   token.Hash(plain),
+		Scopes: scopes,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return k, plain, nil
+}
+
+func (s Service) APIKeys(u realworld.User) ([]*realworld.APIKey, error) {
+	return s.Repo.ListByUserID(u.ID)
+}
+
+func (s Service) RevokeAPIKey(u realworld.User, id int64) error {
+	return s.Repo.Delete(u.ID, id)
+}
+
+func (s Service) Authenticate(plain string) (*realworld.APIKey, error) {
+	k, err := s.Repo.GetByHash(token.Hash(plain))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Users != nil {
+		u, err := s.Users.GetByID(k.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if u.Banned {
+			return nil, realworld.ErrInvalidAPIKey
+		}
+	}
+
+	now := time.Now()
+	if now.Sub(k.LastUsedAt) >= touchEvery {
+		if err := s.Repo.Touch(k.ID, now); err != nil {
+			return nil, err
+		}
+		k.LastUsedAt = now
+	}
+
+	return k, nil
+}
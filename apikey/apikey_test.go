@@ -0,0 +1,48 @@
+package apikey
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"strings"
+	"testing"
+)
+
+func TestService_APIKeys(t *testing.T) {
+	users := inmem.NewMemUserSaver()
+	alice, err := users.Create(realworld.User{Username: "alice", Email: "alice@example.com"})
+	assert.NoError(t, err)
+	bob, err := users.Create(realworld.User{Username: "bobby", Email: "bobby@example.com"})
+	assert.NoError(t, err)
+
+	s := Service{Repo: inmem.NewMemAPIKeyRepo(), Users: users}
+
+	_, _, err = s.CreateAPIKey(*alice, "ci", []string{"everything"})
+	assert.Equal(t, realworld.ErrInvalidScope, err)
+
+	k, plain, err := s.CreateAPIKey(*alice, "ci", []string{realworld.ScopeArticlesWrite})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plain, k.Prefix))
+	assert.NotContains(t, k.Hash, plain)
+	assert.True(t, k.LastUsedAt.IsZero())
+
+	found, err := s.Authenticate(plain)
+	assert.NoError(t, err)
+	assert.Equal(t, alice.ID, found.UserID)
+	assert.Equal(t, []string{realworld.ScopeArticlesWrite}, found.Scopes)
+
+	kk, err := s.APIKeys(*alice)
+	assert.NoError(t, err)
+	assert.Len(t, kk, 1)
+	assert.False(t, kk[0].LastUsedAt.IsZero())
+
+	assert.NoError(t, users.SetBanned(alice.ID, true))
+	_, err = s.Authenticate(plain)
+	assert.Equal(t, realworld.ErrInvalidAPIKey, err)
+	assert.NoError(t, users.SetBanned(alice.ID, false))
+
+	assert.Equal(t, realworld.ErrAPIKeyNotFound, s.RevokeAPIKey(*bob, k.ID))
+	assert.NoError(t, s.RevokeAPIKey(*alice, k.ID))
+	_, err = s.Authenticate(plain)
+	assert.Equal(t, realworld.ErrInvalidAPIKey, err)
+}
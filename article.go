@@ -9,6 +9,10 @@ import (
 var (
 	ErrArticleNotFound      = Error{ENotFound, errors.New("article not found")}
 	ErrArticleAlreadyExists = Error{EConflict, errors.New("article already exists")}
+	ErrCommentNotFound      = Error{ENotFound, errors.New("comment not found")}
+	ErrCommentTooDeep       = Error{EInvalidComment, errors.New("comment is nested too deep")}
+	ErrCommentNotAuthor     = Error{EForbidden, errors.New("only the comment author can do this")}
+	ErrNotArticleAuthor     = Error{EForbidden, errors.New("only the article author can do this")}
 )
 
 type Favorites map[int64]struct{}
@@ -18,6 +22,13 @@ func (ff Favorites) FavoritedBy(id int64) bool {
 	return ok
 }
 
+type Bookmarks map[int64]struct{}
+
+func (bb Bookmarks) BookmarkedBy(id int64) bool {
+	_, ok := bb[id]
+	return ok
+}
+
 type Tags map[string]Tag
 
 func (tt Tags) HasTag(t string) bool {
@@ -35,6 +46,10 @@ func (tt Tags) TagsList() (tagList []string) {
 
 type Comments map[int64]Comment
 
+// DeletedCommentBody replaces the body of a deleted comment that is kept as a
+// placeholder because it still has replies.
+const DeletedCommentBody = "[deleted]"
+
 type Article struct {
 	ID          int64
 	Slug        string
@@ -44,6 +59,8 @@ type Article struct {
 	Author      User
 	Comments    Comments
 	Favorites   Favorites
+	Bookmarks   Bookmarks
+	Reactions   Reactions
 	Tags        Tags
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -65,12 +82,46 @@ func (a Article) Favorited(id int64) bool {
 	return false
 }
 
+// Bookmarked reports whether the article is in the given user's private
+// reading list. Unlike favorites, bookmarks are never exposed to other users.
+func (a Article) Bookmarked(id int64) bool {
+	if a.Bookmarks == nil {
+		return false
+	}
+
+	return a.Bookmarks.BookmarkedBy(id)
+}
+
 type ListRequest struct {
-	Tag         string
-	AuthorID    int64
-	FavoriterID int64
-	Offset      int
-	Limit       int
+	Tag          string
+	AuthorID     int64
+	FavoriterID  int64
+	BookmarkerID int64
+	Offset       int
+	Limit        int
+}
+
+// Comment orderings accepted by CommentListRequest.
+const (
+	CommentsOldest      = "oldest"
+	CommentsNewest      = "newest"
+	CommentsMostReacted = "most_reacted"
+)
+
+// CommentListRequest selects a page of an article's comments. When Threaded is
+// set the page is made of top-level comments and every reply below them is
+// included, and the total that comes with the page counts top-level comments
+// only, so it can be paged through with Offset and Limit. A Limit of zero
+// returns every comment.
+type CommentListRequest struct {
+	Article Article
+	// ViewerID, when set, hides the comments of users the viewer blocks,
+	// mutes or is blocked by.
+	ViewerID int64
+	Sort     string
+	Threaded bool
+	Offset   int
+	Limit    int
 }
 
 type FeedRequest struct {
@@ -89,9 +140,21 @@ type ArticleService interface {
 	Delete(a Article) error
 	Favorite(a Article, u User) (*Article, error)
 	Unfavorite(a Article, u User) (*Article, error)
+	Bookmark(a Article, u User) (*Article, error)
+	Unbookmark(a Article, u User) (*Article, error)
+	React(r Reaction, a Article) (*Article, error)
+	Unreact(r Reaction, a Article) (*Article, error)
+	ReactToComment(r Reaction, c Comment) (*Comment, error)
+	UnreactToComment(r Reaction, c Comment) (*Comment, error)
 	AddComment(c Comment) (*Comment, error)
+	UpdateComment(c Comment) (*Comment, error)
+	CommentHistory(c Comment, u User) ([]*CommentEdit, error)
 	DeleteComment(c Comment) error
-	Comments(a Article) ([]*Comment, error)
+	// RemoveArticle and RemoveComment delete content of any author. Only
+	// moderators can do this.
+	RemoveArticle(moderator User, a Article) error
+	RemoveComment(moderator User, c Comment) error
+	Comments(r CommentListRequest) ([]*Comment, int, error)
 	Tags() ([]*Tag, error)
 }
 
@@ -100,29 +163,70 @@ type ArticleRepo interface {
 	List(offset, limit int) ([]*Article, int, error)
 	ListByTag(tag string, offset, limit int) ([]*Article, int, error)
 	ListByAuthorID(id int64, offset, limit int) ([]*Article, int, error)
+	CountByAuthorID(id int64) (int, error)
 	ListByFavoriterID(id int64, offset, limit int) ([]*Article, int, error)
+	// ListByBookmarkerID returns a page of the articles the user bookmarked,
+	// newest first, along with how many there are in all.
+	ListByBookmarkerID(id int64, offset, limit int) ([]*Article, int, error)
 	Feed(req FeedRequest) ([]*Article, int, error)
 	Create(u Article) (*Article, error)
 	Update(slug string, u Article) (*Article, error)
 	Delete(u Article) error
 	AddFavorite(a Article, u User) (*Article, error)
 	RemoveFavorite(a Article, u User) (*Article, error)
+	AddBookmark(a Article, u User) (*Article, error)
+	RemoveBookmark(a Article, u User) (*Article, error)
+	AddReaction(r Reaction, a Article) (*Article, error)
+	RemoveReaction(r Reaction, a Article) (*Article, error)
+	AddCommentReaction(r Reaction, c Comment) (*Comment, error)
+	RemoveCommentReaction(r Reaction, c Comment) (*Comment, error)
 	AddComment(c Comment) (*Comment, error)
+	Comment(c Comment) (*Comment, error)
+	UpdateComment(c Comment) (*Comment, error)
 	DeleteComment(c Comment) error
+	// Comments returns every comment of the article, oldest first.
 	Comments(a Article) ([]*Comment, error)
+	AddCommentEdit(e CommentEdit) (*CommentEdit, error)
+	CommentEdits(c Comment) ([]*CommentEdit, error)
+	// DeleteCommentEdits removes the edit history of the comment.
+	DeleteCommentEdits(c Comment) error
+	// CommentsByUserID returns every comment the user wrote, oldest first,
+	// with the slug of the article it's on.
+	CommentsByUserID(id int64) ([]*Comment, error)
+	// DeleteByAuthorID removes every article of the author for good, along
+	// with everything on them.
+	DeleteByAuthorID(id int64) error
+	// ForgetUser takes back the user's favorites, bookmarks and reactions.
+	ForgetUser(id int64) error
 	Tags() ([]*Tag, error)
 }
 
+// Comment is either a top-level comment on an article or, when ParentID is set,
+// a reply to another comment. Deleted marks a placeholder kept in place of a
+// deleted comment that still has replies, Edited a comment whose body has been
+// changed by its author.
 type Comment struct {
 	ID        int64
 	Article   Article
 	ArticleID int64
+	ParentID  int64
 	UserID    int64
 	Body      string
+	Deleted   bool
+	Edited    bool
+	Reactions Reactions
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// CommentEdit keeps the body a comment had before it was edited.
+type CommentEdit struct {
+	ID        int64
+	CommentID int64
+	Body      string
+	EditedAt  time.Time
+}
+
 type Tag struct {
 	ID       int64
 	Tag      string
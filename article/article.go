@@ -2,18 +2,62 @@ package article
 
 import (
 	realworld "github.com/xesina/gokit-realworld"
+	"sort"
 )
 
+const defaultMaxCommentDepth = 5
+
 type Service struct {
 	Repo realworld.ArticleRepo
+	// Reactions is the set of reactions users may add. DefaultReactions is used when it's nil.
+	Reactions realworld.ReactionSet
+	// MaxCommentDepth limits how deep replies can be nested, top-level comments
+	// being at depth zero. defaultMaxCommentDepth is used when it's zero.
+	MaxCommentDepth int
+	// RequireVerifiedEmail keeps users who haven't verified their email from
+	// creating articles or comments. Users must be set when it's enabled.
+	RequireVerifiedEmail bool
+	// Users is also where the roles of moderators are checked.
+	Users realworld.UserRepo
+	// Restrictions, when set, keeps users who block each other from
+	// commenting on each other's articles and comments, and hides the content
+	// of blocked and muted users in feeds and comments.
+	Restrictions realworld.RestrictionRepo
+	// Attachments, when set, has the attachments of deleted articles removed.
+	Attachments realworld.AttachmentService
 }
 
 func (s Service) Create(a realworld.Article) (*realworld.Article, error) {
+	if err := s.checkVerified(a.Author.ID); err != nil {
+		return nil, err
+	}
 	return s.Repo.Create(a)
 }
 
+// Delete removes an article. Only its author may do this.
 func (s Service) Delete(a realworld.Article) error {
-	return s.Repo.Delete(a)
+	found, err := s.Repo.Get(a.Slug)
+	if err != nil {
+		return err
+	}
+
+	if found.Author.ID != a.Author.ID {
+		return realworld.ErrNotArticleAuthor
+	}
+
+	return s.delete(*found)
+}
+
+// delete removes an article along with its attachments.
+func (s Service) delete(a realworld.Article) error {
+	if err := s.Repo.Delete(a); err != nil {
+		return err
+	}
+
+	if s.Attachments != nil {
+		return s.Attachments.DeleteByArticleID(a.ID)
+	}
+	return nil
 }
 
 func (s Service) Get(a realworld.Article) (*realworld.Article, error) {
@@ -26,6 +70,8 @@ func (s Service) List(req realworld.ListRequest) ([]*realworld.Article, int, err
 		return s.Repo.ListByTag(req.Tag, req.Offset, req.Limit)
 	case req.FavoriterID != 0:
 		return s.Repo.ListByFavoriterID(req.FavoriterID, req.Offset, req.Limit)
+	case req.BookmarkerID != 0:
+		return s.Repo.ListByBookmarkerID(req.BookmarkerID, req.Offset, req.Limit)
 	case req.AuthorID != 0:
 		return s.Repo.ListByAuthorID(req.AuthorID, req.Offset, req.Limit)
 	default:
@@ -34,6 +80,19 @@ func (s Service) List(req realworld.ListRequest) ([]*realworld.Article, int, err
 }
 
 func (s Service) Feed(req realworld.FeedRequest) ([]*realworld.Article, int, error) {
+	hidden, err := s.hiddenFrom(req.UserID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	following := make([]int64, 0, len(req.FollowingIDs))
+	for _, id := range req.FollowingIDs {
+		if _, ok := hidden[id]; !ok {
+			following = append(following, id)
+		}
+	}
+	req.FollowingIDs = following
+
 	return s.Repo.Feed(req)
 }
 
@@ -45,16 +104,333 @@ func (s Service) Unfavorite(a realworld.Article, u realworld.User) (*realworld.A
 	return s.Repo.RemoveFavorite(a, u)
 }
 
+func (s Service) Bookmark(a realworld.Article, u realworld.User) (*realworld.Article, error) {
+	return s.Repo.AddBookmark(a, u)
+}
+
+func (s Service) Unbookmark(a realworld.Article, u realworld.User) (*realworld.Article, error) {
+	return s.Repo.RemoveBookmark(a, u)
+}
+
+func (s Service) React(r realworld.Reaction, a realworld.Article) (*realworld.Article, error) {
+	if !s.reactions().Allowed(r.Kind) {
+		return nil, realworld.ErrReactionNotAllowed
+	}
+	return s.Repo.AddReaction(r, a)
+}
+
+func (s Service) Unreact(r realworld.Reaction, a realworld.Article) (*realworld.Article, error) {
+	return s.Repo.RemoveReaction(r, a)
+}
+
+// ReactToComment adds a reaction to a comment, unless it was deleted and
+// only a placeholder is left.
+func (s Service) ReactToComment(r realworld.Reaction, c realworld.Comment) (*realworld.Comment, error) {
+	if !s.reactions().Allowed(r.Kind) {
+		return nil, realworld.ErrReactionNotAllowed
+	}
+
+	found, err := s.Repo.Comment(c)
+	if err != nil {
+		return nil, err
+	}
+	if found.Deleted {
+		return nil, realworld.ErrCommentNotFound
+	}
+
+	return s.Repo.AddCommentReaction(r, c)
+}
+
+func (s Service) UnreactToComment(r realworld.Reaction, c realworld.Comment) (*realworld.Comment, error) {
+	return s.Repo.RemoveCommentReaction(r, c)
+}
+
+func (s Service) reactions() realworld.ReactionSet {
+	if s.Reactions == nil {
+		return realworld.NewReactionSet(realworld.DefaultReactions...)
+	}
+	return s.Reactions
+}
+
 func (s Service) AddComment(c realworld.Comment) (*realworld.Comment, error) {
+	if err := s.checkVerified(c.UserID); err != nil {
+		return nil, err
+	}
+	if err := s.checkCommentBlocked(c); err != nil {
+		return nil, err
+	}
+	if c.ParentID != 0 {
+		depth, err := s.commentDepth(c.Article, c.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if depth+1 > s.maxCommentDepth() {
+			return nil, realworld.ErrCommentTooDeep
+		}
+	}
 	return s.Repo.AddComment(c)
 }
 
+// checkVerified enforces RequireVerifiedEmail for the user with the given ID.
+func (s Service) checkVerified(userID int64) error {
+	if !s.RequireVerifiedEmail {
+		return nil
+	}
+
+	u, err := s.Users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !u.EmailVerified {
+		return realworld.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// checkCommentBlocked keeps the comment from being added when its author and
+// the author of the article, or of the comment it replies to, block each other.
+func (s Service) checkCommentBlocked(c realworld.Comment) error {
+	if s.Restrictions == nil {
+		return nil
+	}
+
+	a, err := s.Repo.Get(c.Article.Slug)
+	if err != nil {
+		return err
+	}
+	authors := []int64{a.Author.ID}
+
+	if c.ParentID != 0 {
+		parent, err := s.Repo.Comment(realworld.Comment{ID: c.ParentID, Article: c.Article})
+		if err != nil {
+			return err
+		}
+		if !parent.Deleted {
+			authors = append(authors, parent.UserID)
+		}
+	}
+
+	return s.checkBlocked(c.UserID, authors...)
+}
+
+// commentDepth returns the depth of the comment with the given ID by walking up its parents.
+func (s Service) commentDepth(a realworld.Article, id int64) (depth int, err error) {
+	for {
+		c, err := s.Repo.Comment(realworld.Comment{ID: id, Article: a})
+		if err != nil {
+			return 0, err
+		}
+		if c.Deleted && depth == 0 {
+			return 0, realworld.ErrCommentNotFound
+		}
+		if c.ParentID == 0 {
+			return depth, nil
+		}
+		depth++
+		id = c.ParentID
+	}
+}
+
+func (s Service) maxCommentDepth() int {
+	if s.MaxCommentDepth == 0 {
+		return defaultMaxCommentDepth
+	}
+	return s.MaxCommentDepth
+}
+
+// UpdateComment changes the body of a comment, keeping the previous body in its edit history.
+func (s Service) UpdateComment(c realworld.Comment) (*realworld.Comment, error) {
+	old, err := s.Repo.Comment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if old.Deleted {
+		return nil, realworld.ErrCommentNotFound
+	}
+
+	if old.UserID != c.UserID {
+		return nil, realworld.ErrCommentNotAuthor
+	}
+
+	if old.Body == c.Body {
+		return old, nil
+	}
+
+	_, err = s.Repo.AddCommentEdit(realworld.CommentEdit{CommentID: old.ID, Body: old.Body})
+	if err != nil {
+		return nil, err
+	}
+
+	old.Article = c.Article
+	old.Body = c.Body
+	old.Edited = true
+
+	return s.Repo.UpdateComment(*old)
+}
+
+// CommentHistory returns the previous bodies of a comment. Only the author of
+// the article the comment belongs to may see them.
+func (s Service) CommentHistory(c realworld.Comment, u realworld.User) ([]*realworld.CommentEdit, error) {
+	a, err := s.Repo.Get(c.Article.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Author.ID != u.ID {
+		return nil, realworld.ErrNotArticleAuthor
+	}
+
+	if _, err := s.Repo.Comment(c); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.CommentEdits(c)
+}
+
+// DeleteComment removes a comment. A comment that still has replies is replaced
+// by a placeholder instead, and placeholders left without replies are removed.
+// Only the author of the comment may do this.
 func (s Service) DeleteComment(c realworld.Comment) error {
-	return s.Repo.DeleteComment(c)
+	found, err := s.Repo.Comment(c)
+	if err != nil {
+		return err
+	}
+
+	if !found.Deleted && found.UserID != c.UserID {
+		return realworld.ErrCommentNotAuthor
+	}
+
+	return s.deleteComment(c)
 }
 
-func (s Service) Comments(a realworld.Article) ([]*realworld.Comment, error) {
-	return s.Repo.Comments(a)
+func (s Service) deleteComment(c realworld.Comment) error {
+	cc, err := s.Repo.Comments(c.Article)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int64]*realworld.Comment)
+	replies := make(map[int64]int)
+	for _, comment := range cc {
+		byID[comment.ID] = comment
+		replies[comment.ParentID]++
+	}
+
+	found, ok := byID[c.ID]
+	if !ok || found.Deleted {
+		return realworld.ErrCommentNotFound
+	}
+
+	if replies[found.ID] > 0 {
+		found.Article = c.Article
+		found.Body = ""
+		found.Deleted = true
+		_, err := s.Repo.UpdateComment(*found)
+		return err
+	}
+
+	if err := s.Repo.DeleteComment(c); err != nil {
+		return err
+	}
+
+	for parent := byID[found.ParentID]; parent != nil; parent = byID[parent.ParentID] {
+		replies[parent.ID]--
+		if !parent.Deleted || replies[parent.ID] > 0 {
+			break
+		}
+		if err := s.Repo.DeleteComment(realworld.Comment{ID: parent.ID, Article: c.Article}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Comments returns a sorted page of the article's comments along with the
+// total number of comments the article has, or of its top-level comments
+// when the page is threaded.
+func (s Service) Comments(r realworld.CommentListRequest) ([]*realworld.Comment, int, error) {
+	cc, err := s.Repo.Comments(r.Article)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hidden, err := s.hiddenFrom(r.ViewerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	cc = hideComments(cc, hidden)
+
+	sortComments(cc, r.Sort)
+
+	if !r.Threaded {
+		return paginateComments(cc, r.Offset, r.Limit), len(cc), nil
+	}
+
+	present := make(map[int64]bool, len(cc))
+	for _, c := range cc {
+		present[c.ID] = true
+	}
+
+	// Replies whose parent is missing are listed as top-level comments.
+	roots := make([]*realworld.Comment, 0)
+	replies := make(map[int64][]*realworld.Comment)
+	for _, c := range cc {
+		if c.ParentID == 0 || !present[c.ParentID] {
+			roots = append(roots, c)
+			continue
+		}
+		replies[c.ParentID] = append(replies[c.ParentID], c)
+	}
+
+	page := make([]*realworld.Comment, 0)
+	var collect func(c *realworld.Comment)
+	collect = func(c *realworld.Comment) {
+		page = append(page, c)
+		for _, reply := range replies[c.ID] {
+			collect(reply)
+		}
+	}
+	for _, root := range paginateComments(roots, r.Offset, r.Limit) {
+		collect(root)
+	}
+
+	return page, len(roots), nil
+}
+
+// sortComments orders comments in place. Ties are broken by ID so the order
+// is stable across storages.
+func sortComments(cc []*realworld.Comment, by string) {
+	sort.SliceStable(cc, func(i, j int) bool {
+		switch by {
+		case realworld.CommentsNewest:
+			if !cc[i].CreatedAt.Equal(cc[j].CreatedAt) {
+				return cc[i].CreatedAt.After(cc[j].CreatedAt)
+			}
+			return cc[i].ID > cc[j].ID
+		case realworld.CommentsMostReacted:
+			if ti, tj := cc[i].Reactions.Total(), cc[j].Reactions.Total(); ti != tj {
+				return ti > tj
+			}
+		}
+		if !cc[i].CreatedAt.Equal(cc[j].CreatedAt) {
+			return cc[i].CreatedAt.Before(cc[j].CreatedAt)
+		}
+		return cc[i].ID < cc[j].ID
+	})
+}
+
+func paginateComments(cc []*realworld.Comment, offset, limit int) []*realworld.Comment {
+	if offset >= len(cc) {
+		return []*realworld.Comment{}
+	}
+	cc = cc[offset:]
+	if limit > 0 && limit < len(cc) {
+		cc = cc[:limit]
+	}
+	return cc
 }
 
 func (s Service) Update(slug string, a realworld.Article) (*realworld.Article, error) {
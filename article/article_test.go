@@ -0,0 +1,302 @@
+package article
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/attachment"
+	"github.com/xesina/gokit-realworld/blob/blobtest"
+	"github.com/xesina/gokit-realworld/inmem"
+	"sync"
+	"testing"
+)
+
+// gifImage is a transparent GIF of a single pixel.
+var gifImage = []byte("GIF89a\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\xff\xff\xff!\xf9\x04\x01\x00\x00\x00\x00,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;")
+
+func TestService_Comments(t *testing.T) {
+	s := Service{Repo: inmem.NewMemArticleRepo()}
+	a := realworld.Article{Slug: "hello", Title: "hello"}
+	_, err := s.Create(a)
+	assert.NoError(t, err)
+
+	add := func(parentID int64) int64 {
+		c, err := s.AddComment(realworld.Comment{Article: a, ParentID: parentID, UserID: 1, Body: "body"})
+		assert.NoError(t, err)
+		return c.ID
+	}
+	first := add(0)
+	reply := add(first)
+	second := add(0)
+
+	ids := func(cc []*realworld.Comment) (ids []int64) {
+		for _, c := range cc {
+			ids = append(ids, c.ID)
+		}
+		return
+	}
+
+	cc, count, err := s.Comments(realworld.CommentListRequest{Article: a, Sort: realworld.CommentsNewest})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []int64{second, reply, first}, ids(cc))
+
+	cc, count, err = s.Comments(realworld.CommentListRequest{Article: a, Threaded: true, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []int64{first, reply}, ids(cc))
+
+	cc, count, err = s.Comments(realworld.CommentListRequest{Article: a, Threaded: true, Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []int64{second}, ids(cc))
+
+	cc, count, err = s.Comments(realworld.CommentListRequest{Article: a, Threaded: true, Offset: 2, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Empty(t, cc)
+}
+
+// TestService_CommentsWhileReacting pages through comments sorted by their
+// reactions while those change, for go test -race.
+func TestService_CommentsWhileReacting(t *testing.T) {
+	s := Service{Repo: inmem.NewMemArticleRepo()}
+	a := realworld.Article{Slug: "hello", Title: "hello"}
+	_, err := s.Create(a)
+	assert.NoError(t, err)
+
+	var roots []int64
+	for i := 0; i < 3; i++ {
+		c, err := s.AddComment(realworld.Comment{Article: a, UserID: 1, Body: "body"})
+		assert.NoError(t, err)
+		roots = append(roots, c.ID)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func(userID int64) {
+			defer wg.Done()
+			_, err := s.ReactToComment(realworld.Reaction{Kind: "like", UserID: userID}, realworld.Comment{ID: roots[2], Article: a})
+			assert.NoError(t, err)
+		}(int64(i + 10))
+		go func() {
+			defer wg.Done()
+			_, err := s.AddComment(realworld.Comment{Article: a, ParentID: roots[0], UserID: 2, Body: "reply"})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, count, err := s.Comments(realworld.CommentListRequest{
+				Article: a, Sort: realworld.CommentsMostReacted, Threaded: true, Limit: 2,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, count)
+		}()
+	}
+	wg.Wait()
+
+	cc, count, err := s.Comments(realworld.CommentListRequest{Article: a, Sort: realworld.CommentsMostReacted, Threaded: true, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	if assert.Len(t, cc, 1) {
+		assert.Equal(t, roots[2], cc[0].ID)
+	}
+}
+
+func TestService_CommentDepth(t *testing.T) {
+	s := Service{Repo: inmem.NewMemArticleRepo(), MaxCommentDepth: 2}
+	a := realworld.Article{Slug: "hello", Title: "hello"}
+	_, err := s.Create(a)
+	assert.NoError(t, err)
+
+	var parentID int64
+	for depth := 0; depth <= 2; depth++ {
+		c, err := s.AddComment(realworld.Comment{Article: a, ParentID: parentID, UserID: 1, Body: "body"})
+		assert.NoError(t, err)
+		parentID = c.ID
+	}
+
+	_, err = s.AddComment(realworld.Comment{Article: a, ParentID: parentID, UserID: 1, Body: "body"})
+	assert.Equal(t, realworld.ErrCommentTooDeep, err)
+}
+
+func TestService_DeleteCommentWithReplies(t *testing.T) {
+	s := Service{Repo: inmem.NewMemArticleRepo()}
+	a := realworld.Article{Slug: "hello", Title: "hello"}
+	_, err := s.Create(a)
+	assert.NoError(t, err)
+
+	parent, err := s.AddComment(realworld.Comment{Article: a, UserID: 1, Body: "parent"})
+	assert.NoError(t, err)
+	reply, err := s.AddComment(realworld.Comment{Article: a, ParentID: parent.ID, UserID: 2, Body: "reply"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.DeleteComment(realworld.Comment{ID: parent.ID, Article: a, UserID: 1}))
+	cc, count, err := s.Comments(realworld.CommentListRequest{Article: a, Threaded: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	if assert.Len(t, cc, 2) {
+		assert.Equal(t, parent.ID, cc[0].ID)
+		assert.True(t, cc[0].Deleted)
+		assert.Empty(t, cc[0].Body)
+		assert.Equal(t, reply.ID, cc[1].ID)
+	}
+
+	// the placeholder can't be replied to, and goes away with its last reply
+	_, err = s.AddComment(realworld.Comment{Article: a, ParentID: parent.ID, UserID: 2, Body: "reply"})
+	assert.Equal(t, realworld.ErrCommentNotFound, err)
+	assert.NoError(t, s.DeleteComment(realworld.Comment{ID: reply.ID, Article: a, UserID: 2}))
+	cc, count, err = s.Comments(realworld.CommentListRequest{Article: a, Threaded: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Empty(t, cc)
+}
+
+func TestService_ReactToDeletedComment(t *testing.T) {
+	s := Service{Repo: inmem.NewMemArticleRepo()}
+	a := realworld.Article{Slug: "hello", Title: "hello"}
+	_, err := s.Create(a)
+	assert.NoError(t, err)
+
+	parent, err := s.AddComment(realworld.Comment{Article: a, UserID: 1, Body: "parent"})
+	assert.NoError(t, err)
+	_, err = s.AddComment(realworld.Comment{Article: a, ParentID: parent.ID, UserID: 2, Body: "reply"})
+	assert.NoError(t, err)
+
+	c, err := s.ReactToComment(realworld.Reaction{Kind: "like", UserID: 2}, realworld.Comment{ID: parent.ID, Article: a})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.Reactions.Counts()["like"])
+
+	// the placeholder left behind takes no reactions
+	assert.NoError(t, s.DeleteComment(realworld.Comment{ID: parent.ID, Article: a, UserID: 1}))
+	_, err = s.ReactToComment(realworld.Reaction{Kind: "like", UserID: 3}, realworld.Comment{ID: parent.ID, Article: a})
+	assert.Equal(t, realworld.ErrCommentNotFound, err)
+}
+
+func TestService_OrphanedComments(t *testing.T) {
+	repo := inmem.NewMemArticleRepo()
+	s := Service{Repo: repo}
+	a := realworld.Article{Slug: "hello", Title: "hello"}
+	_, err := s.Create(a)
+	assert.NoError(t, err)
+
+	parent, err := s.AddComment(realworld.Comment{Article: a, UserID: 1, Body: "parent"})
+	assert.NoError(t, err)
+	reply, err := s.AddComment(realworld.Comment{Article: a, ParentID: parent.ID, UserID: 2, Body: "reply"})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.DeleteComment(realworld.Comment{ID: parent.ID, Article: a}))
+
+	cc, _, err := s.Comments(realworld.CommentListRequest{Article: a, Threaded: true})
+	assert.NoError(t, err)
+	if assert.Len(t, cc, 1) {
+		assert.Equal(t, reply.ID, cc[0].ID)
+	}
+}
+
+func TestNestComments(t *testing.T) {
+	nested := nestComments([]Comment{
+		{ID: 1},
+		{ID: 2, ParentID: 1},
+		{ID: 3, ParentID: 2},
+		{ID: 4, ParentID: 1},
+		{ID: 5},
+		{ID: 6, ParentID: 99},
+	})
+
+	ids := func(cc []Comment) (ids []int64) {
+		for _, c := range cc {
+			ids = append(ids, c.ID)
+		}
+		return
+	}
+	if assert.Equal(t, []int64{1, 5, 6}, ids(nested)) {
+		assert.Equal(t, []int64{2, 4}, ids(nested[0].Replies))
+		assert.Equal(t, []int64{3}, ids(nested[0].Replies[0].Replies))
+		assert.Empty(t, nested[1].Replies)
+		assert.Empty(t, nested[2].Replies)
+	}
+}
+
+func TestService_UpdateComment(t *testing.T) {
+	s := Service{Repo: inmem.NewMemArticleRepo()}
+	author := realworld.User{ID: 1, Username: "author"}
+	commenter := realworld.User{ID: 2, Username: "commenter"}
+	other := realworld.User{ID: 3, Username: "other"}
+	a := realworld.Article{Slug: "hello", Title: "hello", Author: author}
+	_, err := s.Create(a)
+	assert.NoError(t, err)
+	c, err := s.AddComment(realworld.Comment{Article: a, UserID: commenter.ID, Body: "first"})
+	assert.NoError(t, err)
+
+	for _, u := range []realworld.User{author, other} {
+		_, err = s.UpdateComment(realworld.Comment{ID: c.ID, Article: a, UserID: u.ID, Body: "changed"})
+		assert.Equal(t, realworld.ErrCommentNotAuthor, err)
+	}
+
+	updated, err := s.UpdateComment(realworld.Comment{ID: c.ID, Article: a, UserID: commenter.ID, Body: "second"})
+	assert.NoError(t, err)
+	assert.Equal(t, "second", updated.Body)
+	assert.True(t, updated.Edited)
+	_, err = s.UpdateComment(realworld.Comment{ID: c.ID, Article: a, UserID: commenter.ID, Body: "third"})
+	assert.NoError(t, err)
+
+	// only the article's author can see the edit history, not even the commenter
+	for _, u := range []realworld.User{commenter, other} {
+		_, err = s.CommentHistory(realworld.Comment{ID: c.ID, Article: a}, u)
+		assert.Equal(t, realworld.ErrNotArticleAuthor, err)
+	}
+	ee, err := s.CommentHistory(realworld.Comment{ID: c.ID, Article: a}, author)
+	assert.NoError(t, err)
+	if assert.Len(t, ee, 2) {
+		assert.Equal(t, "first", ee[0].Body)
+		assert.Equal(t, "second", ee[1].Body)
+	}
+
+	_, err = s.CommentHistory(realworld.Comment{ID: c.ID + 100, Article: a}, author)
+	assert.Equal(t, realworld.ErrCommentNotFound, err)
+}
+
+func TestService_Moderation(t *testing.T) {
+	users := inmem.NewMemUserSaver()
+	author, err := users.Create(realworld.User{Username: "author", Email: "author@example.com"})
+	assert.NoError(t, err)
+	other, err := users.Create(realworld.User{Username: "other", Email: "other@example.com"})
+	assert.NoError(t, err)
+	mod, err := users.Create(realworld.User{Username: "moderator", Email: "moderator@example.com"})
+	assert.NoError(t, err)
+	assert.NoError(t, users.SetRole(mod.ID, realworld.RoleModerator))
+
+	s := Service{Repo: inmem.NewMemArticleRepo(), Users: users}
+	a := realworld.Article{Slug: "hello", Title: "hello", Author: *author}
+	_, err = s.Create(a)
+	assert.NoError(t, err)
+	c, err := s.AddComment(realworld.Comment{Article: a, UserID: author.ID, Body: "body"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, realworld.ErrCommentNotAuthor, s.DeleteComment(realworld.Comment{ID: c.ID, Article: a, UserID: other.ID}))
+	assert.Equal(t, realworld.ErrNotModerator, s.RemoveComment(*other, realworld.Comment{ID: c.ID, Article: a}))
+	assert.NoError(t, s.RemoveComment(*mod, realworld.Comment{ID: c.ID, Article: a}))
+
+	assert.Equal(t, realworld.ErrNotArticleAuthor, s.Delete(realworld.Article{Slug: "hello", Author: *other}))
+	assert.Equal(t, realworld.ErrNotModerator, s.RemoveArticle(*other, a))
+	assert.NoError(t, s.RemoveArticle(*mod, a))
+	assert.Equal(t, realworld.ErrArticleNotFound, s.RemoveArticle(*mod, a))
+}
+
+func TestService_DeleteAttachments(t *testing.T) {
+	repo := inmem.NewMemArticleRepo()
+	attachments := inmem.NewMemAttachmentRepo()
+	s := Service{Repo: repo, Attachments: attachment.Service{Repo: attachments, Articles: repo, Storage: blobtest.NewStorage(t)}}
+
+	author := realworld.User{ID: 1, Username: "author"}
+	a, err := s.Create(realworld.Article{Slug: "hello", Title: "hello", Author: author})
+	assert.NoError(t, err)
+	_, err = s.Attachments.Attach(*a, author, bytes.NewReader(gifImage))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Delete(realworld.Article{Slug: "hello", Author: author}))
+	aa, err := attachments.ListByArticleID(a.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, aa)
+}
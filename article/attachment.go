@@ -0,0 +1,71 @@
+package article
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	realworld "github.com/xesina/gokit-realworld"
+	"io"
+)
+
+type AttachRequest struct {
+	UserID int64
+	Slug   string
+	Image  io.Reader
+}
+
+type AttachmentResponse struct {
+	Attachment *realworld.Attachment
+	Err        error
+}
+
+func (r AttachmentResponse) Failed() error { return r.Err }
+
+func AttachEndpoint(a realworld.AttachmentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(AttachRequest)
+		at, err := a.Attach(realworld.Article{Slug: req.Slug}, realworld.User{ID: req.UserID}, req.Image)
+		if err != nil {
+			return nil, err
+		}
+		return AttachmentResponse{Attachment: at}, nil
+	}
+}
+
+type AttachmentsRequest struct {
+	Slug string
+}
+
+type AttachmentsResponse struct {
+	Attachments []*realworld.Attachment
+	Err         error
+}
+
+func (r AttachmentsResponse) Failed() error { return r.Err }
+
+func AttachmentsEndpoint(a realworld.AttachmentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(AttachmentsRequest)
+		aa, err := a.Attachments(realworld.Article{Slug: req.Slug})
+		if err != nil {
+			return nil, err
+		}
+		return AttachmentsResponse{Attachments: aa}, nil
+	}
+}
+
+type DetachRequest struct {
+	ID     int64
+	UserID int64
+	Slug   string
+}
+
+func DetachEndpoint(a realworld.AttachmentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(DetachRequest)
+		err = a.Detach(realworld.Article{Slug: req.Slug}, realworld.User{ID: req.UserID}, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		return DeleteResponse{}, nil
+	}
+}
@@ -8,25 +8,33 @@ import (
 )
 
 type AddCommentRequest struct {
-	Slug   string
-	UserID int64
-	Body   string
+	Slug     string
+	UserID   int64
+	ParentID int64
+	Body     string
 }
 
 func (req AddCommentRequest) toComment() realworld.Comment {
 	return realworld.Comment{
-		Article: realworld.Article{Slug: req.Slug},
-		UserID:  req.UserID,
-		Body:    req.Body,
+		Article:  realworld.Article{Slug: req.Slug},
+		UserID:   req.UserID,
+		ParentID: req.ParentID,
+		Body:     req.Body,
 	}
 }
 
 type Comment struct {
-	ID        int64
-	Body      string
-	Author    Author
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID              int64
+	ParentID        int64
+	Body            string
+	Deleted         bool
+	Edited          bool
+	Replies         []Comment
+	Author          Author
+	Reactions       map[string]int
+	ViewerReactions []string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 type CommentResponse struct {
@@ -44,16 +52,20 @@ func NewCommentResponse(c *realworld.Comment, u *realworld.User, userSrv realwor
 
 	return CommentResponse{
 		Comment: Comment{
-			ID:   c.ID,
-			Body: c.Body,
+			ID:       c.ID,
+			ParentID: c.ParentID,
+			Body:     c.Body,
+			Edited:   c.Edited,
 			Author: Author{
 				Username:  author.Username,
 				Bio:       author.Bio,
 				Image:     author.Image,
 				Following: author.IsFollower(u),
 			},
-			CreatedAt: c.CreatedAt,
-			UpdatedAt: c.UpdatedAt,
+			Reactions:       c.Reactions.Counts(),
+			ViewerReactions: c.Reactions.By(u.ID),
+			CreatedAt:       c.CreatedAt,
+			UpdatedAt:       c.UpdatedAt,
 		},
 		Err: err,
 	}
@@ -74,6 +86,79 @@ func AddCommentEndpoint(a realworld.ArticleService, u realworld.UserService) end
 	}
 }
 
+type UpdateCommentRequest struct {
+	ID     int64
+	Slug   string
+	UserID int64
+	Body   string
+}
+
+func (req UpdateCommentRequest) toComment() realworld.Comment {
+	return realworld.Comment{
+		ID:      req.ID,
+		Article: realworld.Article{Slug: req.Slug},
+		UserID:  req.UserID,
+		Body:    req.Body,
+	}
+}
+
+func UpdateCommentEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(UpdateCommentRequest)
+		comment, err := a.UpdateComment(req.toComment())
+		if err != nil {
+			return nil, err
+		}
+		return NewCommentResponse(comment, &realworld.User{ID: req.UserID}, u, err), nil
+	}
+}
+
+type CommentHistoryRequest struct {
+	ID     int64
+	Slug   string
+	UserID int64
+}
+
+func (req CommentHistoryRequest) toComment() realworld.Comment {
+	return realworld.Comment{
+		ID:      req.ID,
+		Article: realworld.Article{Slug: req.Slug},
+	}
+}
+
+type CommentEdit struct {
+	Body     string
+	EditedAt time.Time
+}
+
+type CommentHistoryResponse struct {
+	Edits []CommentEdit
+	Err   error
+}
+
+func (r CommentHistoryResponse) error() error { return r.Err }
+
+func (r CommentHistoryResponse) Failed() error { return r.Err }
+
+func CommentHistoryEndpoint(a realworld.ArticleService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(CommentHistoryRequest)
+		ee, err := a.CommentHistory(req.toComment(), realworld.User{ID: req.UserID})
+		if err != nil {
+			return nil, err
+		}
+
+		resp := CommentHistoryResponse{Edits: make([]CommentEdit, 0)}
+		for _, e := range ee {
+			resp.Edits = append(resp.Edits, CommentEdit{
+				Body:     e.Body,
+				EditedAt: e.EditedAt,
+			})
+		}
+		return resp, nil
+	}
+}
+
 type DeleteCommentRequest struct {
 	ID     int64
 	Slug   string
@@ -99,19 +184,42 @@ func DeleteCommentEndpoint(a realworld.ArticleService) endpoint.Endpoint {
 	}
 }
 
+// RemoveCommentEndpoint deletes the comment on behalf of the moderator in the request's UserID.
+func RemoveCommentEndpoint(a realworld.ArticleService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(DeleteCommentRequest)
+		err = a.RemoveComment(realworld.User{ID: req.UserID}, req.toComment())
+		if err != nil {
+			return nil, err
+		}
+		return DeleteResponse{}, nil
+	}
+}
+
 type CommentsRequest struct {
 	UserID int64
 	Slug   string
+	// Flat returns the comments as a flat list instead of nesting replies under their parents.
+	Flat   bool
+	Sort   string
+	Limit  int
+	Offset int
 }
 
-func (req CommentsRequest) toArticle() realworld.Article {
-	return realworld.Article{
-		Slug: req.Slug,
+func (req CommentsRequest) serviceRequest() realworld.CommentListRequest {
+	return realworld.CommentListRequest{
+		Article:  realworld.Article{Slug: req.Slug},
+		ViewerID: req.UserID,
+		Sort:     req.Sort,
+		Threaded: !req.Flat,
+		Offset:   req.Offset,
+		Limit:    req.Limit,
 	}
 }
 
 type CommentsResponse struct {
 	Comments []Comment
+	Count    int
 	Err      error
 }
 
@@ -120,22 +228,34 @@ func NewCommentsResponse(
 ) CommentsResponse {
 	var comments CommentsResponse
 	for _, comment := range cc {
+		resp := Comment{
+			ID:              comment.ID,
+			ParentID:        comment.ParentID,
+			Body:            comment.Body,
+			Deleted:         comment.Deleted,
+			Edited:          comment.Edited,
+			Reactions:       comment.Reactions.Counts(),
+			ViewerReactions: comment.Reactions.By(u.ID),
+			CreatedAt:       comment.CreatedAt,
+			UpdatedAt:       comment.UpdatedAt,
+		}
+
+		if comment.Deleted {
+			resp.Body = realworld.DeletedCommentBody
+			comments.Comments = append(comments.Comments, resp)
+			continue
+		}
+
 		author, err := userSrv.Get(realworld.User{ID: comment.UserID})
 		if err != nil {
-			return CommentsResponse{nil, err}
+			return CommentsResponse{nil, 0, err}
 		}
 
-		resp := Comment{
-			ID:   comment.ID,
-			Body: comment.Body,
-			Author: Author{
-				Username:  author.Username,
-				Bio:       author.Bio,
-				Image:     author.Image,
-				Following: author.IsFollower(u),
-			},
-			CreatedAt: comment.CreatedAt,
-			UpdatedAt: comment.UpdatedAt,
+		resp.Author = Author{
+			Username:  author.Username,
+			Bio:       author.Bio,
+			Image:     author.Image,
+			Following: author.IsFollower(u),
 		}
 
 		comments.Comments = append(comments.Comments, resp)
@@ -145,13 +265,49 @@ func NewCommentsResponse(
 	return comments
 }
 
+// nestComments arranges a flat list of comments into a tree, keeping the
+// relative order of siblings. Replies whose parent is missing are kept at the
+// top level rather than dropped.
+func nestComments(cc []Comment) []Comment {
+	present := make(map[int64]bool, len(cc))
+	for _, c := range cc {
+		present[c.ID] = true
+	}
+
+	children := make(map[int64][]Comment)
+	for _, c := range cc {
+		parentID := c.ParentID
+		if !present[parentID] {
+			parentID = 0
+		}
+		children[parentID] = append(children[parentID], c)
+	}
+
+	var build func(parentID int64) []Comment
+	build = func(parentID int64) []Comment {
+		var nested []Comment
+		for _, c := range children[parentID] {
+			c.Replies = build(c.ID)
+			nested = append(nested, c)
+		}
+		return nested
+	}
+
+	return build(0)
+}
+
 func CommentsEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(CommentsRequest)
-		cc, err := a.Comments(req.toArticle())
+		cc, count, err := a.Comments(req.serviceRequest())
 		if err != nil {
 			return nil, err
 		}
-		return NewCommentsResponse(cc, &realworld.User{ID: req.UserID}, u, err), nil
+		resp := NewCommentsResponse(cc, &realworld.User{ID: req.UserID}, u, err)
+		resp.Count = count
+		if !req.Flat {
+			resp.Comments = nestComments(resp.Comments)
+		}
+		return resp, nil
 	}
 }
@@ -37,16 +37,19 @@ func (r CreateRequest) toArticle() (a realworld.Article) {
 }
 
 type Article struct {
-	Slug           string
-	Title          string
-	Description    string
-	Body           string
-	Tags           realworld.Tags
-	Favorited      bool
-	FavoritesCount int
-	Author         Author
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	Slug            string
+	Title           string
+	Description     string
+	Body            string
+	Tags            realworld.Tags
+	Favorited       bool
+	FavoritesCount  int
+	Bookmarked      bool
+	Reactions       map[string]int
+	ViewerReactions []string
+	Author          Author
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 type Response struct {
@@ -91,13 +94,16 @@ func NewResponse(a *realworld.Article, u realworld.User, userSrv realworld.UserS
 
 	return Response{
 		Article{
-			Slug:           a.Slug,
-			Title:          a.Title,
-			Description:    a.Description,
-			Body:           a.Body,
-			Tags:           a.Tags,
-			Favorited:      a.Favorited(viewerID),
-			FavoritesCount: len(a.Favorites),
+			Slug:            a.Slug,
+			Title:           a.Title,
+			Description:     a.Description,
+			Body:            a.Body,
+			Tags:            a.Tags,
+			Favorited:       a.Favorited(viewerID),
+			FavoritesCount:  len(a.Favorites),
+			Bookmarked:      a.Bookmarked(viewerID),
+			Reactions:       a.Reactions.Counts(),
+			ViewerReactions: a.Reactions.By(viewerID),
 			Author: Author{
 				Username:  author.Username,
 				Bio:       author.Bio,
@@ -204,12 +210,14 @@ func NewListResponse(
 		}
 
 		resp := Article{
-			Slug:           article.Slug,
-			Title:          article.Title,
-			Description:    article.Description,
-			Body:           article.Body,
-			Tags:           article.Tags,
-			FavoritesCount: len(article.Favorites),
+			Slug:            article.Slug,
+			Title:           article.Title,
+			Description:     article.Description,
+			Body:            article.Body,
+			Tags:            article.Tags,
+			FavoritesCount:  len(article.Favorites),
+			Reactions:       article.Reactions.Counts(),
+			ViewerReactions: []string{},
 			Author: Author{
 				Username: author.Username,
 				Bio:      author.Bio,
@@ -221,6 +229,8 @@ func NewListResponse(
 
 		if u != nil {
 			resp.Favorited = article.Favorited(u.ID)
+			resp.Bookmarked = article.Bookmarked(u.ID)
+			resp.ViewerReactions = article.Reactions.By(u.ID)
 			resp.Author.Following = author.IsFollower(u)
 		}
 
@@ -319,6 +329,18 @@ func DeleteEndpoint(a realworld.ArticleService) endpoint.Endpoint {
 	}
 }
 
+// RemoveEndpoint deletes the article on behalf of the moderator in the request's UserID.
+func RemoveEndpoint(a realworld.ArticleService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(DeleteRequest)
+		err = a.RemoveArticle(realworld.User{ID: req.UserID}, req.toArticle())
+		if err != nil {
+			return nil, err
+		}
+		return DeleteResponse{}, nil
+	}
+}
+
 type FavoriteRequest struct {
 	UserID int64
 	Slug   string
@@ -356,6 +378,72 @@ func UnfavoriteEndpoint(a realworld.ArticleService, u realworld.UserService) end
 	}
 }
 
+type BookmarkRequest struct {
+	UserID int64
+	Slug   string
+}
+
+func (r BookmarkRequest) toArticle() (a realworld.Article) {
+	a.Slug = r.Slug
+	return
+}
+
+func (r BookmarkRequest) toUser() (u realworld.User) {
+	u.ID = r.UserID
+	return
+}
+
+func BookmarkEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(BookmarkRequest)
+		article, err := a.Bookmark(req.toArticle(), req.toUser())
+		if err != nil {
+			return nil, err
+		}
+		return NewResponse(article, realworld.User{ID: req.UserID}, u, err), nil
+	}
+}
+
+func UnbookmarkEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(BookmarkRequest)
+		article, err := a.Unbookmark(req.toArticle(), req.toUser())
+		if err != nil {
+			return nil, err
+		}
+		return NewResponse(article, realworld.User{ID: req.UserID}, u, err), nil
+	}
+}
+
+type BookmarksRequest struct {
+	UserID int64
+	Limit  int
+	Offset int
+}
+
+func (req BookmarksRequest) serviceRequest() realworld.ListRequest {
+	return realworld.ListRequest{
+		BookmarkerID: req.UserID,
+		Offset:       req.Offset,
+		Limit:        req.Limit,
+	}
+}
+
+func BookmarksEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(BookmarksRequest)
+		user, err := u.Get(realworld.User{ID: req.UserID})
+		if err != nil {
+			return nil, err
+		}
+		aa, count, err := a.List(req.serviceRequest())
+		if err != nil {
+			return nil, err
+		}
+		return NewListResponse(aa, count, user, u, err), nil
+	}
+}
+
 type TagsRequest struct{}
 
 type TagsResponse struct {
@@ -0,0 +1,44 @@
+package article
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+)
+
+func (s Service) RemoveArticle(moderator realworld.User, a realworld.Article) error {
+	if err := s.checkModerator(moderator.ID); err != nil {
+		return err
+	}
+
+	found, err := s.Repo.Get(a.Slug)
+	if err != nil {
+		return err
+	}
+
+	return s.delete(*found)
+}
+
+func (s Service) RemoveComment(moderator realworld.User, c realworld.Comment) error {
+	if err := s.checkModerator(moderator.ID); err != nil {
+		return err
+	}
+
+	return s.deleteComment(c)
+}
+
+// checkModerator looks up the role of the user with the given ID rather than
+// trusting the one in their token, which may be out of date.
+func (s Service) checkModerator(userID int64) error {
+	if s.Users == nil {
+		return realworld.ErrNotModerator
+	}
+
+	u, err := s.Users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !u.HasRole(realworld.RoleModerator) {
+		return realworld.ErrNotModerator
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package article
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	realworld "github.com/xesina/gokit-realworld"
+)
+
+type ReactionRequest struct {
+	UserID int64
+	Slug   string
+	Kind   string
+}
+
+func (r ReactionRequest) toReaction() realworld.Reaction {
+	return realworld.Reaction{
+		Kind:   r.Kind,
+		UserID: r.UserID,
+	}
+}
+
+func (r ReactionRequest) toArticle() (a realworld.Article) {
+	a.Slug = r.Slug
+	return
+}
+
+func ReactEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ReactionRequest)
+		article, err := a.React(req.toReaction(), req.toArticle())
+		if err != nil {
+			return nil, err
+		}
+		return NewResponse(article, realworld.User{ID: req.UserID}, u, err), nil
+	}
+}
+
+func UnreactEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ReactionRequest)
+		article, err := a.Unreact(req.toReaction(), req.toArticle())
+		if err != nil {
+			return nil, err
+		}
+		return NewResponse(article, realworld.User{ID: req.UserID}, u, err), nil
+	}
+}
+
+type CommentReactionRequest struct {
+	UserID    int64
+	Slug      string
+	CommentID int64
+	Kind      string
+}
+
+func (r CommentReactionRequest) toReaction() realworld.Reaction {
+	return realworld.Reaction{
+		Kind:      r.Kind,
+		UserID:    r.UserID,
+		CommentID: r.CommentID,
+	}
+}
+
+func (r CommentReactionRequest) toComment() realworld.Comment {
+	return realworld.Comment{
+		ID:      r.CommentID,
+		Article: realworld.Article{Slug: r.Slug},
+	}
+}
+
+func CommentReactEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(CommentReactionRequest)
+		comment, err := a.ReactToComment(req.toReaction(), req.toComment())
+		if err != nil {
+			return nil, err
+		}
+		return NewCommentResponse(comment, &realworld.User{ID: req.UserID}, u, err), nil
+	}
+}
+
+func CommentUnreactEndpoint(a realworld.ArticleService, u realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(CommentReactionRequest)
+		comment, err := a.UnreactToComment(req.toReaction(), req.toComment())
+		if err != nil {
+			return nil, err
+		}
+		return NewCommentResponse(comment, &realworld.User{ID: req.UserID}, u, err), nil
+	}
+}
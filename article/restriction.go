@@ -0,0 +1,116 @@
+package article
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+)
+
+// hiddenFrom returns the IDs of the users whose content is hidden from the
+// viewer: those the viewer blocks or mutes, and those who block the viewer.
+func (s Service) hiddenFrom(viewerID int64) (map[int64]struct{}, error) {
+	hidden := make(map[int64]struct{})
+	if s.Restrictions == nil || viewerID == 0 {
+		return hidden, nil
+	}
+
+	lists := []func() ([]int64, error){
+		func() ([]int64, error) { return s.Restrictions.TargetIDs(viewerID, realworld.RestrictionBlock) },
+		func() ([]int64, error) { return s.Restrictions.UserIDs(viewerID, realworld.RestrictionBlock) },
+		func() ([]int64, error) { return s.Restrictions.TargetIDs(viewerID, realworld.RestrictionMute) },
+	}
+	for _, list := range lists {
+		ids, err := list()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			hidden[id] = struct{}{}
+		}
+	}
+
+	return hidden, nil
+}
+
+// checkBlocked returns ErrBlocked when the user with the given ID and any of
+// the others block each other.
+func (s Service) checkBlocked(userID int64, others ...int64) error {
+	if s.Restrictions == nil {
+		return nil
+	}
+
+	for _, other := range others {
+		if other == userID {
+			continue
+		}
+		for _, r := range []realworld.Restriction{
+			{UserID: userID, TargetID: other, Kind: realworld.RestrictionBlock},
+			{UserID: other, TargetID: userID, Kind: realworld.RestrictionBlock},
+		} {
+			ok, err := s.Restrictions.Exists(r)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return realworld.ErrBlocked
+			}
+		}
+	}
+
+	return nil
+}
+
+// hideComments leaves out the comments written by hidden users. Those with
+// replies that are still shown are kept as placeholders, as if they had been
+// deleted, and placeholders left without replies are dropped.
+func hideComments(cc []*realworld.Comment, hidden map[int64]struct{}) []*realworld.Comment {
+	if len(hidden) == 0 {
+		return cc
+	}
+
+	byID := make(map[int64]*realworld.Comment, len(cc))
+	for _, c := range cc {
+		byID[c.ID] = c
+	}
+	replies := make(map[int64][]*realworld.Comment)
+	for _, c := range cc {
+		replies[c.ParentID] = append(replies[c.ParentID], c)
+	}
+
+	kept := make(map[int64]*realworld.Comment, len(cc))
+	var keep func(c *realworld.Comment) bool
+	keep = func(c *realworld.Comment) bool {
+		shownReplies := false
+		for _, reply := range replies[c.ID] {
+			if keep(reply) {
+				shownReplies = true
+			}
+		}
+
+		_, isHidden := hidden[c.UserID]
+		switch {
+		case !c.Deleted && !isHidden:
+			kept[c.ID] = c
+		case shownReplies:
+			placeholder := *c
+			placeholder.Body = ""
+			placeholder.Deleted = true
+			placeholder.Edited = false
+			kept[c.ID] = &placeholder
+		default:
+			return false
+		}
+		return true
+	}
+	for _, c := range cc {
+		if _, ok := byID[c.ParentID]; !ok {
+			keep(c)
+		}
+	}
+
+	shown := make([]*realworld.Comment, 0, len(kept))
+	for _, c := range cc {
+		if k, ok := kept[c.ID]; ok {
+			shown = append(shown, k)
+		}
+	}
+	return shown
+}
@@ -0,0 +1,74 @@
+package article
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"testing"
+)
+
+func TestService_Restrictions(t *testing.T) {
+	const alice, bob, carol = 1, 2, 3
+
+	restrictions := inmem.NewMemRestrictionRepo()
+	s := Service{Repo: inmem.NewMemArticleRepo(), Restrictions: restrictions}
+
+	a := realworld.Article{Slug: "by-alice", Title: "By alice", Author: realworld.User{ID: alice}}
+	_, err := s.Create(a)
+	assert.NoError(t, err)
+	_, err = s.Create(realworld.Article{Slug: "by-bob", Title: "By bob", Author: realworld.User{ID: bob}})
+	assert.NoError(t, err)
+
+	add := func(userID, parentID int64) (*realworld.Comment, error) {
+		return s.AddComment(realworld.Comment{Article: a, ParentID: parentID, UserID: userID, Body: "body"})
+	}
+	byBob, err := add(bob, 0)
+	assert.NoError(t, err)
+	byCarol, err := add(carol, byBob.ID)
+	assert.NoError(t, err)
+	_, err = add(bob, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, restrictions.Add(realworld.Restriction{UserID: alice, TargetID: bob, Kind: realworld.RestrictionBlock}))
+
+	// bob can't comment on alice's articles, nor alice reply to bob
+	_, err = add(bob, 0)
+	assert.Equal(t, realworld.ErrBlocked, err)
+	_, err = add(alice, byBob.ID)
+	assert.Equal(t, realworld.ErrBlocked, err)
+	_, err = add(carol, byBob.ID)
+	assert.NoError(t, err)
+
+	// bob's comment with carol's replies stays as a placeholder, the other goes
+	cc, count, err := s.Comments(realworld.CommentListRequest{Article: a, ViewerID: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, byBob.ID, cc[0].ID)
+	assert.True(t, cc[0].Deleted)
+	assert.Empty(t, cc[0].Body)
+	assert.Equal(t, byCarol.ID, cc[1].ID)
+
+	// alice wrote none of the comments, so bob and carol see them all
+	_, count, err = s.Comments(realworld.CommentListRequest{Article: a, ViewerID: bob})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+	_, count, err = s.Comments(realworld.CommentListRequest{Article: a, ViewerID: carol})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	// muting carol hides her from alice, the placeholder and all
+	assert.NoError(t, restrictions.Add(realworld.Restriction{UserID: alice, TargetID: carol, Kind: realworld.RestrictionMute}))
+	_, count, err = s.Comments(realworld.CommentListRequest{Article: a, ViewerID: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	aa, count, err := s.Feed(realworld.FeedRequest{UserID: carol, FollowingIDs: []int64{bob}, Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, aa, 1)
+
+	assert.NoError(t, restrictions.Add(realworld.Restriction{UserID: carol, TargetID: bob, Kind: realworld.RestrictionMute}))
+	_, count, err = s.Feed(realworld.FeedRequest{UserID: carol, FollowingIDs: []int64{bob}, Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
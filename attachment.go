@@ -0,0 +1,52 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrAttachmentNotFound = Error{ENotFound, errors.New("attachment not found")}
+	ErrQuotaExceeded      = Error{ETooLarge, errors.New("attachment would take more storage than you have left")}
+)
+
+// Attachment is an image uploaded to be shown in the body of an article.
+type Attachment struct {
+	ID          int64
+	ArticleID   int64
+	UserID      int64
+	Key         string
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+	// URL is where the attachment is served from, which isn't stored.
+	URL string
+}
+
+type AttachmentService interface {
+	// Attach stores the image r reads for the article's body. Only the
+	// article's author can do this, as long as the attachments they
+	// uploaded take no more than their quota.
+	Attach(a Article, u User, r io.Reader) (*Attachment, error)
+	Attachments(a Article) ([]*Attachment, error)
+	// Detach removes an attachment. Only the article's author can do this.
+	Detach(a Article, u User, id int64) error
+	// DeleteByArticleID removes the attachments of an article that's gone.
+	DeleteByArticleID(id int64) error
+	// DeleteByUserID removes every attachment the user uploaded.
+	DeleteByUserID(id int64) error
+}
+
+type AttachmentRepo interface {
+	// Create returns ErrQuotaExceeded, creating nothing, when the attachments
+	// of a's user would take more than quota bytes along with a. The check
+	// and the creation are one, so concurrent uploads can't both fit in
+	// what's left.
+	Create(a Attachment, quota int64) (*Attachment, error)
+	// Get returns ErrAttachmentNotFound unless the article has an attachment with the ID.
+	Get(articleID, id int64) (*Attachment, error)
+	ListByArticleID(id int64) ([]*Attachment, error)
+	ListByUserID(id int64) ([]*Attachment, error)
+	Delete(id int64) error
+}
@@ -0,0 +1,205 @@
+// Package attachment stores the images authors embed in their articles.
+package attachment
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/imaging"
+	"io"
+)
+
+const (
+	defaultMaxBytes = 5 << 20
+	defaultQuota    = 50 << 20
+	// maxPixels keeps images from taking more memory to re-encode than is
+	// reasonable, like it does for avatars.
+	maxPixels = 4096 * 4096
+)
+
+var contentTypes = map[string]string{
+	imaging.PNG:  "image/png",
+	imaging.JPEG: "image/jpeg",
+	imaging.GIF:  "image/gif",
+}
+
+type Service struct {
+	Repo     realworld.AttachmentRepo
+	Articles realworld.ArticleRepo
+	Storage  realworld.BlobStorage
+	// MaxBytes limits how large uploads can be. defaultMaxBytes is used when it's zero.
+	MaxBytes int64
+	// Quota limits how many bytes the attachments of each user can take in
+	// all. defaultQuota is used when it's zero.
+	Quota int64
+}
+
+func (s Service) Attach(a realworld.Article, u realworld.User, r io.Reader) (*realworld.Attachment, error) {
+	found, err := s.authored(a, u)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := imaging.Read(r, s.maxBytes())
+	if err != nil {
+		return nil, err
+	}
+	b, format, err := clean(b)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := key(found.ID, format)
+	if err != nil {
+		return nil, realworld.InternalError(err)
+	}
+	if err := s.Storage.Put(k, bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+
+	created, err := s.Repo.Create(realworld.Attachment{
+		ArticleID:   found.ID,
+		UserID:      u.ID,
+		Key:         k,
+		ContentType: contentTypes[format],
+		Size:        int64(len(b)),
+	}, s.quota())
+	if err != nil {
+		// Nothing would point to the file anymore.
+		_ = s.Storage.Delete(k)
+		return nil, err
+	}
+
+	created.URL = s.Storage.URL(k)
+	return created, nil
+}
+
+func (s Service) Attachments(a realworld.Article) ([]*realworld.Attachment, error) {
+	found, err := s.Articles.Get(a.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	aa, err := s.Repo.ListByArticleID(found.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, at := range aa {
+		at.URL = s.Storage.URL(at.Key)
+	}
+	return aa, nil
+}
+
+func (s Service) Detach(a realworld.Article, u realworld.User, id int64) error {
+	found, err := s.authored(a, u)
+	if err != nil {
+		return err
+	}
+
+	at, err := s.Repo.Get(found.ID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.delete(at)
+}
+
+func (s Service) DeleteByArticleID(id int64) error {
+	aa, err := s.Repo.ListByArticleID(id)
+	if err != nil {
+		return err
+	}
+	return s.deleteAll(aa)
+}
+
+func (s Service) DeleteByUserID(id int64) error {
+	aa, err := s.Repo.ListByUserID(id)
+	if err != nil {
+		return err
+	}
+	return s.deleteAll(aa)
+}
+
+func (s Service) deleteAll(aa []*realworld.Attachment) error {
+	for _, at := range aa {
+		if err := s.delete(at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// delete removes the file before the record, so that a failure leaves the
+// attachment to be deleted again rather than a file nothing points to.
+func (s Service) delete(at *realworld.Attachment) error {
+	if err := s.Storage.Delete(at.Key); err != nil {
+		return err
+	}
+	return s.Repo.Delete(at.ID)
+}
+
+// authored returns the article, as long as u is its author.
+func (s Service) authored(a realworld.Article, u realworld.User) (*realworld.Article, error) {
+	found, err := s.Articles.Get(a.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if found.Author.ID != u.ID {
+		return nil, realworld.ErrNotArticleAuthor
+	}
+	return found, nil
+}
+
+// clean checks that b is an image and encodes PNG and JPEG images anew,
+// which leaves out metadata such as where a photo was taken. GIFs are kept
+// as they are, since only their first frame would be encoded.
+func clean(b []byte) ([]byte, string, error) {
+	img, format, err := imaging.Decode(b, maxPixels)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == imaging.GIF {
+		return b, format, nil
+	}
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format); err != nil {
+		return nil, "", realworld.InternalError(err)
+	}
+	return buf.Bytes(), format, nil
+}
+
+// key makes up where an attachment of the article is stored, random so
+// that the URLs of attachments can't be guessed from each other.
+func key(articleID int64, format string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("attachments/%d/%s.%s", articleID, hex.EncodeToString(b), extension(format)), nil
+}
+
+func extension(format string) string {
+	if format == imaging.GIF {
+		return "gif"
+	}
+	return imaging.Extension(format)
+}
+
+func (s Service) maxBytes() int64 {
+	if s.MaxBytes == 0 {
+		return defaultMaxBytes
+	}
+	return s.MaxBytes
+}
+
+func (s Service) quota() int64 {
+	if s.Quota == 0 {
+		return defaultQuota
+	}
+	return s.Quota
+}
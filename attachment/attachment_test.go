@@ -0,0 +1,86 @@
+package attachment
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/blob/blobtest"
+	"github.com/xesina/gokit-realworld/inmem"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func pngImage(t *testing.T, w, h int) *bytes.Buffer {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return &buf
+}
+
+func TestService_Attach(t *testing.T) {
+	storage := blobtest.NewStorage(t)
+	articles := inmem.NewMemArticleRepo()
+	repo := inmem.NewMemAttachmentRepo()
+	s := Service{Repo: repo, Articles: articles, Storage: storage}
+
+	author := realworld.User{ID: 1, Username: "author"}
+	other := realworld.User{ID: 2, Username: "other"}
+	a, err := articles.Create(realworld.Article{Slug: "hello", Title: "hello", Author: author})
+	assert.NoError(t, err)
+
+	at, err := s.Attach(*a, author, pngImage(t, 20, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", at.ContentType)
+	assert.True(t, strings.HasPrefix(at.URL, blobtest.BaseURL+"/attachments/"))
+	rc, err := storage.Open(at.Key)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(rc)
+	rc.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, at.Size, int64(len(b)))
+
+	// GIFs are stored as they were uploaded
+	var g bytes.Buffer
+	assert.NoError(t, gif.Encode(&g, image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White}), nil))
+	gifSize := g.Len()
+	animated, err := s.Attach(*a, author, &g)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(gifSize), animated.Size)
+	assert.True(t, strings.HasSuffix(animated.Key, ".gif"))
+
+	_, err = s.Attach(*a, other, pngImage(t, 1, 1))
+	assert.Equal(t, realworld.ErrNotArticleAuthor, err)
+	_, err = s.Attach(*a, author, strings.NewReader("not an image"))
+	assert.Equal(t, realworld.ErrUnsupportedImage, err)
+
+	s.Quota = at.Size + animated.Size
+	_, err = s.Attach(*a, author, pngImage(t, 1, 1))
+	assert.Equal(t, realworld.ErrQuotaExceeded, err)
+
+	aa, err := s.Attachments(realworld.Article{Slug: "hello"})
+	assert.NoError(t, err)
+	if assert.Len(t, aa, 2) {
+		assert.Equal(t, at.URL, aa[0].URL)
+	}
+
+	assert.Equal(t, realworld.ErrNotArticleAuthor, s.Detach(*a, other, at.ID))
+	assert.NoError(t, s.Detach(*a, author, at.ID))
+	assert.Equal(t, realworld.ErrAttachmentNotFound, s.Detach(*a, author, at.ID))
+	_, err = storage.Open(at.Key)
+	assert.Equal(t, realworld.ErrBlobNotFound, err)
+
+	// detaching makes room again
+	_, err = s.Attach(*a, author, pngImage(t, 1, 1))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.DeleteByArticleID(a.ID))
+	aa, err = repo.ListByUserID(author.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, aa)
+	_, err = storage.Open(animated.Key)
+	assert.Equal(t, realworld.ErrBlobNotFound, err)
+}
@@ -0,0 +1,20 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrUnsupportedImage = Error{EInvalidUpload, errors.New("only PNG, JPEG and GIF images are supported")}
+	ErrImageTooLarge    = Error{ETooLarge, errors.New("image is too large")}
+)
+
+type AvatarService interface {
+	// SetAvatar stores the image r reads, cropped to a square, at each of
+	// the avatar sizes and points the user's Image at the largest. It returns
+	// the user along with the URL of each size.
+	SetAvatar(u User, r io.Reader) (*User, map[int]string, error)
+	// RemoveAvatar deletes the stored avatar of the user and removes their Image.
+	RemoveAvatar(u User) (*User, error)
+}
@@ -0,0 +1,130 @@
+package avatar
+
+import (
+	"bytes"
+	"fmt"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/imaging"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxBytes = 5 << 20
+	// maxPixels keeps images from taking more memory to scale than is
+	// reasonable for an avatar.
+	maxPixels = 4096 * 4096
+)
+
+var defaultSizes = []int{64, 128, 256}
+
+type Service struct {
+	Users   realworld.UserRepo
+	Storage realworld.BlobStorage
+	// MaxBytes limits how large uploads can be. defaultMaxBytes is used when it's zero.
+	MaxBytes int64
+	// Sizes are the widths avatars are stored at, the last being the one
+	// users' Image points to. defaultSizes is used when it's empty.
+	Sizes []int
+}
+
+func (s Service) SetAvatar(u realworld.User, r io.Reader) (*realworld.User, map[int]string, error) {
+	found, err := s.Users.GetByID(u.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := imaging.Read(r, s.maxBytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	img, format, err := imaging.Decode(b, maxPixels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Avatars in another format than the new one would be left behind.
+	if err := s.remove(found.ID); err != nil {
+		return nil, nil, err
+	}
+
+	// The version in the URLs keeps clients from showing a cached avatar
+	// after it changed.
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	urls := make(map[int]string)
+	for _, size := range s.sizes() {
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, imaging.Square(img, size), format); err != nil {
+			return nil, nil, realworld.InternalError(err)
+		}
+
+		k := key(found.ID, size, imaging.Extension(format))
+		if err := s.Storage.Put(k, &buf); err != nil {
+			return nil, nil, err
+		}
+		urls[size] = s.Storage.URL(k) + "?v=" + version
+	}
+
+	found.Image = realworld.Image{Value: urls[s.imageSize()], Valid: true}
+	if err := s.Users.SetImage(found.ID, found.Image); err != nil {
+		return nil, nil, err
+	}
+
+	return found, urls, nil
+}
+
+func (s Service) RemoveAvatar(u realworld.User) (*realworld.User, error) {
+	found, err := s.Users.GetByID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.remove(found.ID); err != nil {
+		return nil, err
+	}
+
+	found.Image = realworld.Image{}
+	if err := s.Users.SetImage(found.ID, found.Image); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// remove deletes the avatar of the user with the given ID in every format
+// it could have been stored in.
+func (s Service) remove(id int64) error {
+	for _, size := range s.sizes() {
+		for _, format := range []string{imaging.PNG, imaging.JPEG} {
+			if err := s.Storage.Delete(key(id, size, imaging.Extension(format))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func key(userID int64, size int, ext string) string {
+	return fmt.Sprintf("avatars/%d/%d.%s", userID, size, ext)
+}
+
+func (s Service) maxBytes() int64 {
+	if s.MaxBytes == 0 {
+		return defaultMaxBytes
+	}
+	return s.MaxBytes
+}
+
+func (s Service) sizes() []int {
+	if len(s.Sizes) == 0 {
+		return defaultSizes
+	}
+	return s.Sizes
+}
+
+// imageSize is the size users' Image points to.
+func (s Service) imageSize() int {
+	sizes := s.sizes()
+	return sizes[len(sizes)-1]
+}
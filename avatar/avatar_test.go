@@ -0,0 +1,82 @@
+package avatar
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/blob/blobtest"
+	"github.com/xesina/gokit-realworld/inmem"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encode(t *testing.T, w, h int, enc func(*bytes.Buffer, image.Image) error) *bytes.Buffer {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, enc(&buf, img))
+	return &buf
+}
+
+func pngImage(t *testing.T, w, h int) *bytes.Buffer {
+	return encode(t, w, h, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+}
+
+func TestService_SetAvatar(t *testing.T) {
+	storage := blobtest.NewStorage(t)
+	users := inmem.NewMemUserSaver()
+	s := Service{Users: users, Storage: storage, MaxBytes: 1 << 20, Sizes: []int{32, 256}}
+
+	u, err := users.Create(realworld.User{Username: "alice", Email: "alice@example.com"})
+	assert.NoError(t, err)
+
+	found, urls, err := s.SetAvatar(*u, pngImage(t, 300, 200))
+	assert.NoError(t, err)
+	assert.Len(t, urls, 2)
+	assert.True(t, strings.HasPrefix(urls[32], "http://localhost/media/avatars/1/32.png?v="))
+	assert.Equal(t, urls[256], found.Image.Value)
+
+	stored, err := users.GetByID(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, found.Image, stored.Image)
+
+	// cropped to a square and only scaled down
+	for size, side := range map[int]int{32: 32, 256: 200} {
+		rc, err := storage.Open(key(u.ID, size, "png"))
+		assert.NoError(t, err)
+		cfg, err := png.DecodeConfig(rc)
+		rc.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, side, cfg.Width)
+		assert.Equal(t, side, cfg.Height)
+	}
+
+	// a JPEG replaces the PNG
+	jpg := encode(t, 64, 64, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) })
+	_, urls, err = s.SetAvatar(*u, jpg)
+	assert.NoError(t, err)
+	assert.Contains(t, urls[32], "/avatars/1/32.jpg?v=")
+	_, err = storage.Open(key(u.ID, 32, "png"))
+	assert.Equal(t, realworld.ErrBlobNotFound, err)
+
+	_, _, err = s.SetAvatar(*u, strings.NewReader("<svg xmlns='http://www.w3.org/2000/svg'></svg>"))
+	assert.Equal(t, realworld.ErrUnsupportedImage, err)
+
+	s.MaxBytes = 100
+	_, _, err = s.SetAvatar(*u, pngImage(t, 300, 300))
+	assert.Equal(t, realworld.ErrImageTooLarge, err)
+
+	found, err = s.RemoveAvatar(*u)
+	assert.NoError(t, err)
+	assert.False(t, found.Image.Valid)
+	_, err = storage.Open(key(u.ID, 32, "jpg"))
+	assert.Equal(t, realworld.ErrBlobNotFound, err)
+}
@@ -0,0 +1,21 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = Error{ENotFound, errors.New("file not found")}
+
+// BlobStorage keeps uploaded files under slash separated keys such as
+// "avatars/1/64.png".
+type BlobStorage interface {
+	// Put stores what r reads under key, replacing anything already there.
+	Put(key string, r io.Reader) error
+	// Open returns ErrBlobNotFound when nothing is stored under key.
+	Open(key string) (io.ReadCloser, error)
+	// Delete does nothing when nothing is stored under key.
+	Delete(key string) error
+	// URL returns where what's stored under key can be downloaded from.
+	URL(key string) string
+}
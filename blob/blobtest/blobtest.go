@@ -0,0 +1,30 @@
+// Package blobtest provides blob storage for tests.
+package blobtest
+
+import (
+	"github.com/xesina/gokit-realworld/blob"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// BaseURL is where the blobs of storage from NewStorage are served from.
+const BaseURL = "http://localhost/media"
+
+// NewStorage returns storage in a temporary directory that is removed once
+// the test is done.
+func NewStorage(t testing.TB) *blob.FileStorage {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	s, err := blob.NewFileStorage(dir, BaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
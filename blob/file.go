@@ -0,0 +1,102 @@
+// Package blob stores uploaded files.
+package blob
+
+import (
+	"errors"
+	realworld "github.com/xesina/gokit-realworld"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileStorage keeps blobs as files below Dir, serving them from BaseURL.
+type FileStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewFileStorage(dir, baseURL string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStorage{Dir: dir, BaseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// path returns the file a key is stored in, or false for keys that would
+// end up outside of Dir.
+func (s *FileStorage) path(key string) (string, bool) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", false
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), true
+}
+
+func (s *FileStorage) Put(key string, r io.Reader) error {
+	p, ok := s.path(key)
+	if !ok {
+		return realworld.InternalError(errors.New("invalid blob key " + key))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	// Writing to a temporary file first keeps readers from seeing a file
+	// that's only partly written.
+	f, err := ioutil.TempFile(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), p)
+}
+
+func (s *FileStorage) Open(key string) (io.ReadCloser, error) {
+	p, ok := s.path(key)
+	if !ok {
+		return nil, realworld.ErrBlobNotFound
+	}
+
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, realworld.ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if fi, err := f.Stat(); err != nil || fi.IsDir() {
+		f.Close()
+		return nil, realworld.ErrBlobNotFound
+	}
+
+	return f, nil
+}
+
+func (s *FileStorage) Delete(key string) error {
+	p, ok := s.path(key)
+	if !ok {
+		return nil
+	}
+
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStorage) URL(key string) string {
+	return s.BaseURL + "/" + key
+}
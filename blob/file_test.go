@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blobs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := NewFileStorage(filepath.Join(dir, "media"), "/media/")
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0644))
+
+	assert.NoError(t, s.Put("a/b.txt", strings.NewReader("first")))
+	assert.NoError(t, s.Put("a/b.txt", strings.NewReader("second")))
+	rc, err := s.Open("a/b.txt")
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(rc)
+	rc.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(b))
+	assert.Equal(t, "/media/a/b.txt", s.URL("a/b.txt"))
+
+	// keys can't reach outside of the directory
+	for _, key := range []string{"../secret", "a/../../secret", "/secret", "a", ""} {
+		_, err := s.Open(key)
+		assert.Equal(t, realworld.ErrBlobNotFound, err, key)
+	}
+	assert.Error(t, s.Put("../secret", strings.NewReader("overwritten")))
+
+	assert.NoError(t, s.Delete("a/b.txt"))
+	assert.NoError(t, s.Delete("a/b.txt"))
+	_, err = s.Open("a/b.txt")
+	assert.Equal(t, realworld.ErrBlobNotFound, err)
+}
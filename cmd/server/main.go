@@ -1,15 +1,43 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/account"
+	"github.com/xesina/gokit-realworld/apikey"
 	"github.com/xesina/gokit-realworld/article"
+	"github.com/xesina/gokit-realworld/attachment"
+	"github.com/xesina/gokit-realworld/avatar"
+	"github.com/xesina/gokit-realworld/blob"
 	httpTransport "github.com/xesina/gokit-realworld/http"
+	"github.com/xesina/gokit-realworld/http/middleware"
+	"github.com/xesina/gokit-realworld/inmem"
+	"github.com/xesina/gokit-realworld/mail"
+	"github.com/xesina/gokit-realworld/oidc"
+	"github.com/xesina/gokit-realworld/oidc/oidctest"
 	"github.com/xesina/gokit-realworld/sqlite"
+	"github.com/xesina/gokit-realworld/token"
 	"github.com/xesina/gokit-realworld/user"
+	"net"
 	"net/http"
+	"net/smtp"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
+)
+
+const (
+	listenAddr = "127.0.0.1:8585"
+	// fakeProviderPath is where the fake identity provider is served when
+	// OIDC_FAKE is set.
+	fakeProviderPath = "/oidc-test"
+	// refreshTokenTTL is how long a login lasts without being used.
+	refreshTokenTTL = time.Hour * 24 * 30
 )
 
 func main() {
@@ -17,18 +45,153 @@ func main() {
 	//in-memory implementation
 	//inmemUserRepo := inmem.NewMemUserSaver()
 	//inmemArticleRepo := inmem.NewMemArticleRepo()
+	//inmemRefreshTokenRepo := inmem.NewMemRefreshTokenRepo()
+	//inmemRevocationRepo := inmem.NewMemRevocationRepo()
+	//inmemPasswordResetRepo := inmem.NewMemPasswordResetRepo()
+	//inmemEmailVerificationRepo := inmem.NewMemEmailVerificationRepo()
+	//inmemTwoFactorRepo := inmem.NewMemTwoFactorRepo()
+	//inmemLoginChallengeRepo := inmem.NewMemLoginChallengeRepo()
+	//inmemAPIKeyRepo := inmem.NewMemAPIKeyRepo()
+	//inmemIdentityRepo := inmem.NewMemIdentityRepo()
+	//inmemOAuthStateRepo := inmem.NewMemOAuthStateRepo()
 
 	s, err := sqlite.NewStorage("./realworld.db")
 	if err != nil {
 		panic(err)
 	}
 	s.Migrate()
-	userSrv := user.Service{UserRepo: s.NewUserRepository()}
-	articleSrv := article.Service{Repo: s.NewArticleRepository()}
+	revocations, err := inmem.NewRevocationCache(s.NewRevocationRepository())
+	if err != nil {
+		panic(err)
+	}
+	tokenSrv := token.Service{
+		Repo:        s.NewRefreshTokenRepository(),
+		Revocations: revocations,
+		TTL:         refreshTokenTTL,
+	}
+
+	userSrv := user.Service{
+		UserRepo:      s.NewUserRepository(),
+		Sessions:      tokenSrv,
+		Resets:        s.NewPasswordResetRepository(),
+		Verifications: s.NewEmailVerificationRepository(),
+		Mailer:        newMailer(),
+		MailLimiter:   inmem.NewRateLimiter(3, time.Hour),
+		ResetURL:      os.Getenv("RESET_URL"),
+		VerifyURL:     os.Getenv("VERIFY_URL"),
+		TwoFactor:     s.NewTwoFactorRepository(),
+		Challenges:    s.NewLoginChallengeRepository(),
+		TOTPIssuer:    os.Getenv("TOTP_ISSUER"),
+		// Lock an account after 5 failed logins in a row, for a minute at
+		// first and up to an hour. An IP gets more room since many users
+		// can share it.
+		AccountLockout: inmem.NewLockout(5, time.Minute, time.Hour),
+		IPLockout:      inmem.NewLockout(50, time.Minute, time.Hour),
+		Articles:       s.NewArticleRepository(),
+		Restrictions:   s.NewRestrictionRepository(),
+		Usernames:      s.NewUsernameHistoryRepository(),
+	}
+	if err := promoteAdmins(userSrv.UserRepo); err != nil {
+		panic(err)
+	}
+
+	media, err := mediaStorage()
+	if err != nil {
+		panic(err)
+	}
 
-	h := httpTransport.MakeHTTPHandler(userSrv, articleSrv)
+	quota, err := attachmentQuota()
+	if err != nil {
+		panic(err)
+	}
+
+	attachmentSrv := attachment.Service{
+		Repo:     s.NewAttachmentRepository(),
+		Articles: s.NewArticleRepository(),
+		Storage:  media,
+		Quota:    quota,
+	}
+
+	articleSrv := article.Service{
+		Repo:                 s.NewArticleRepository(),
+		Reactions:            realworld.NewReactionSet(realworld.DefaultReactions...),
+		RequireVerifiedEmail: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
+		Users:                userSrv.UserRepo,
+		Restrictions:         userSrv.Restrictions,
+		Attachments:          attachmentSrv,
+	}
+
+	keys, err := loadKeys()
+	if err != nil {
+		panic(err)
+	}
+
+	apiKeySrv := apikey.Service{
+		Repo:  s.NewAPIKeyRepository(),
+		Users: userSrv.UserRepo,
+	}
+
+	providers, fake, err := loadProviders()
+	if err != nil {
+		panic(err)
+	}
+
+	identitySrv := oidc.Service{
+		Providers: providers,
+		States:    s.NewOAuthStateRepository(),
+		Repo:      s.NewIdentityRepository(),
+		Users:     userSrv.UserRepo,
+		Usernames: userSrv.Usernames,
+	}
+
+	avatarSrv := avatar.Service{
+		Users:   userSrv.UserRepo,
+		Storage: media,
+	}
+
+	policy, err := deletionPolicy()
+	if err != nil {
+		panic(err)
+	}
+
+	accountSrv := account.Service{
+		UserRepo:     userSrv.UserRepo,
+		ArticleRepo:  articleSrv.Repo,
+		Articles:     articleSrv,
+		Policy:       policy,
+		Sessions:     tokenSrv,
+		APIKeys:      apiKeySrv.Repo,
+		Identities:   identitySrv.Repo,
+		TwoFactor:    userSrv.TwoFactor,
+		Restrictions: userSrv.Restrictions,
+		Avatars:      avatarSrv,
+		Attachments:  attachmentSrv,
+		Usernames:    userSrv.Usernames,
+	}
+
+	var h http.Handler
+	h = httpTransport.MakeHTTPHandler(userSrv, articleSrv, tokenSrv, tokenSrv, apiKeySrv, identitySrv, accountSrv, avatarSrv, attachmentSrv, media, keys, sessionCookies())
+	if fake != nil {
+		mux := http.NewServeMux()
+		mux.Handle(fakeProviderPath+"/", fake)
+		mux.Handle("/", h)
+		h = mux
+	}
 
 	errs := make(chan error)
+
+	if every := os.Getenv("JWT_ROTATE_EVERY"); every != "" {
+		d, err := time.ParseDuration(every)
+		if err != nil {
+			panic(err)
+		}
+		go func() {
+			errs <- keys.RotateEvery(context.Background(), d, func() (*middleware.Key, error) {
+				return middleware.GenerateKey(jwtAlg())
+			})
+		}()
+	}
+
 	go func() {
 		c := make(chan os.Signal)
 		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
@@ -36,8 +199,206 @@ func main() {
 	}()
 
 	go func() {
-		errs <- http.ListenAndServe("127.0.0.1:8585", h)
+		errs <- http.ListenAndServe(listenAddr, h)
 	}()
 
 	<-errs
 }
+
+// loadKeys builds the JWT key set from the comma separated PEM files in
+// JWT_KEYS, where the first private key signs and the rest only verify
+// tokens issued before a rotation. Without any files a key is generated for
+// JWT_ALG, so tokens don't survive a restart.
+func loadKeys() (*middleware.KeySet, error) {
+	keys := middleware.NewKeySet(httpTransport.AccessTokenTTL)
+
+	files := os.Getenv("JWT_KEYS")
+	if files == "" {
+		k, err := middleware.GenerateKey(jwtAlg())
+		if err != nil {
+			return nil, err
+		}
+		return keys, keys.Add(k)
+	}
+
+	for _, f := range strings.Split(files, ",") {
+		k, err := middleware.LoadKey(strings.TrimSpace(f))
+		if err != nil {
+			return nil, err
+		}
+		if err := keys.Add(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+func jwtAlg() string {
+	if alg := os.Getenv("JWT_ALG"); alg != "" {
+		return alg
+	}
+	return "RS256"
+}
+
+// publicURL is where clients reach the server, PUBLIC_URL when it's set.
+func publicURL() string {
+	if public := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/"); public != "" {
+		return public
+	}
+	return "http://" + listenAddr
+}
+
+// mediaStorage keeps uploads in the MEDIA_DIR directory, "media" by default.
+// They're served by the server itself unless MEDIA_URL points elsewhere.
+func mediaStorage() (*blob.FileStorage, error) {
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		dir = "media"
+	}
+	url := os.Getenv("MEDIA_URL")
+	if url == "" {
+		url = publicURL() + "/media"
+	}
+	return blob.NewFileStorage(dir, url)
+}
+
+// attachmentQuota reads how many bytes each user's attachments can take
+// from ATTACHMENT_QUOTA, zero leaving it to the default.
+func attachmentQuota() (int64, error) {
+	quota := os.Getenv("ATTACHMENT_QUOTA")
+	if quota == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid ATTACHMENT_QUOTA %q", quota)
+	}
+	return n, nil
+}
+
+// loadProviders configures the identity provider at OIDC_ISSUER, named by
+// OIDC_PROVIDER, for users to log in with. With OIDC_FAKE set to true a fake
+// provider named "fake" is served as well, which logs in a test user without
+// asking anything. Providers send users back to PUBLIC_URL.
+func loadProviders() (map[string]*oidc.Provider, *oidctest.Provider, error) {
+	callback := func(name string) string {
+		return publicURL() + "/api/users/oauth/" + name + "/callback"
+	}
+
+	providers := map[string]*oidc.Provider{}
+
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		name := os.Getenv("OIDC_PROVIDER")
+		if name == "" {
+			name = "oidc"
+		}
+		providers[name] = &oidc.Provider{
+			Name:         name,
+			Issuer:       issuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  callback(name),
+		}
+	}
+
+	if os.Getenv("OIDC_FAKE") != "true" {
+		return providers, nil, nil
+	}
+
+	fake, err := oidctest.NewProvider(publicURL()+fakeProviderPath, "realworld", "secret")
+	if err != nil {
+		return nil, nil, err
+	}
+	fake.AddUser(oidctest.User{
+		Subject:       "test-user",
+		Email:         "test-user@example.com",
+		EmailVerified: true,
+		Username:      "testuser",
+	})
+	providers["fake"] = fake.Client("fake", callback("fake"))
+
+	return providers, fake, nil
+}
+
+// deletionPolicy reads what becomes of the content of deleted accounts from
+// ACCOUNT_DELETION, which is either "anonymize", the default, or "remove".
+func deletionPolicy() (string, error) {
+	policy := os.Getenv("ACCOUNT_DELETION")
+	if policy == "" {
+		return realworld.DeletionAnonymize, nil
+	}
+	if !realworld.ValidDeletionPolicy(policy) {
+		return "", fmt.Errorf("unknown ACCOUNT_DELETION policy %q", policy)
+	}
+	return policy, nil
+}
+
+// sessionCookies turns on the cookie session mode with SESSION_COOKIES set to
+// true. COOKIE_INSECURE set to true allows the cookies over plain HTTP,
+// including the one logins with identity providers use in either mode.
+// COOKIE_DOMAIN sets their domain and CORS_ORIGINS is the comma separated
+// list of other sites allowed to use them.
+func sessionCookies() httpTransport.SessionCookies {
+	var origins []string
+	for _, o := range strings.Split(os.Getenv("CORS_ORIGINS"), ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+
+	return httpTransport.SessionCookies{
+		Enabled:        os.Getenv("SESSION_COOKIES") == "true",
+		Insecure:       os.Getenv("COOKIE_INSECURE") == "true",
+		Domain:         os.Getenv("COOKIE_DOMAIN"),
+		RefreshMaxAge:  refreshTokenTTL,
+		AllowedOrigins: origins,
+	}
+}
+
+// newMailer sends mail through SMTP_ADDR when it's set and otherwise
+// writes it to stderr.
+func newMailer() realworld.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "noreply@localhost"
+	}
+
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return mail.NewLogMailer(from, os.Stderr)
+	}
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	return mail.SMTPMailer{Addr: addr, From: from, Auth: auth}
+}
+
+// promoteAdmins makes admins of the users with the comma separated emails in
+// ADMIN_EMAILS, which is how the first admin comes to be. Emails nobody has
+// registered with yet are skipped.
+func promoteAdmins(users realworld.UserRepo) error {
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		u, err := users.Get(email)
+		if err != nil {
+			if errors.Is(err, realworld.ErrUserNotFound) {
+				continue
+			}
+			return err
+		}
+
+		if err := users.SetRole(u.ID, realworld.RoleAdmin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
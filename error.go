@@ -13,13 +13,29 @@ const (
 	EInternal = "internal"
 	// Entity does not exist.
 	ENotFound = "not_found"
+	// Action is not allowed for the current user.
+	EForbidden = "forbidden"
+	// Credentials are missing, invalid or expired.
+	EUnauthorized = "unauthorized"
 	// Too many API requests.
 	ERateLimit = "rate_limit"
+	// Too many failed attempts; try again once the lockout ends.
+	ELockedOut = "locked_out"
 	// User ID validation failed.
 	EInvalidUserID = "invalid_user_id"
 	// Username validation failed.
 	EInvalidUsername   = "invalid_username"
 	EIncorrectPassword = "incorrect_password"
+	// Reaction kind is not in the configured set.
+	EInvalidReaction = "invalid_reaction"
+	// Comment cannot be added where it was requested.
+	EInvalidComment = "invalid_comment"
+	// One-time token is unknown, used or expired.
+	EInvalidToken = "invalid_token"
+	// Uploaded file isn't of a kind that's accepted.
+	EInvalidUpload = "invalid_upload"
+	// Uploaded file is over the size limit.
+	ETooLarge = "too_large"
 )
 
 type Error struct {
@@ -0,0 +1,288 @@
+package http
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	realworld "github.com/xesina/gokit-realworld"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/user"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Formats of account exports.
+const (
+	exportJSON = "json"
+	exportZIP  = "zip"
+)
+
+type deleteAccountRequest struct {
+	userID int64
+	User   struct {
+		Password string `json:"password"`
+	} `json:"user"`
+}
+
+func (req *deleteAccountRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *deleteAccountRequest) validate() error {
+	return validation.ValidateStruct(
+		&req.User,
+		validation.Field(&req.User.Password, validation.Required),
+	)
+}
+
+func (req *deleteAccountRequest) endpointRequest() user.DeleteAccountRequest {
+	return user.DeleteAccountRequest{
+		UserID:   req.userID,
+		Password: req.User.Password,
+	}
+}
+
+func (h UserHandler) decodeDeleteAccountRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req deleteAccountRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type exportAccountRequest struct {
+	userID int64
+	format string
+}
+
+func (req *exportAccountRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+	req.format = r.URL.Query().Get("format")
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *exportAccountRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.format, validation.In(exportJSON, exportZIP)),
+	)
+}
+
+func (req *exportAccountRequest) endpointRequest() user.ExportAccountRequest {
+	return user.ExportAccountRequest{UserID: req.userID}
+}
+
+func (h UserHandler) decodeExportAccountRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req exportAccountRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type exportProfile struct {
+	Username      string          `json:"username"`
+	Email         string          `json:"email"`
+	Bio           realworld.Bio   `json:"bio"`
+	Image         realworld.Image `json:"image"`
+	EmailVerified bool            `json:"emailVerified"`
+	Role          string          `json:"role"`
+}
+
+type exportArticle struct {
+	Slug        string    `json:"slug"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Body        string    `json:"body"`
+	TagList     []string  `json:"tagList"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type exportComment struct {
+	ID        int64     `json:"id"`
+	Article   string    `json:"article"`
+	ParentID  int64     `json:"parentId,omitempty"`
+	Body      string    `json:"body"`
+	Edited    bool      `json:"edited"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// accountExport is an export as a single JSON document. ZIP archives hold
+// each of its fields in a file of its own.
+type accountExport struct {
+	ExportedAt time.Time       `json:"exportedAt"`
+	Profile    exportProfile   `json:"profile"`
+	Articles   []exportArticle `json:"articles"`
+	Comments   []exportComment `json:"comments"`
+	Favorites  []string        `json:"favorites"`
+	Bookmarks  []string        `json:"bookmarks"`
+	Following  []string        `json:"following"`
+	Followers  []string        `json:"followers"`
+	Identities []identity      `json:"identities"`
+	APIKeys    []apiKey        `json:"apiKeys"`
+}
+
+func newAccountExport(e *realworld.AccountExport) accountExport {
+	resp := accountExport{
+		ExportedAt: e.ExportedAt,
+		Profile: exportProfile{
+			Username:      e.User.Username,
+			Email:         e.User.Email,
+			Bio:           e.User.Bio,
+			Image:         e.User.Image,
+			EmailVerified: e.User.EmailVerified,
+			Role:          e.User.Role,
+		},
+		Articles:   make([]exportArticle, 0, len(e.Articles)),
+		Comments:   make([]exportComment, 0, len(e.Comments)),
+		Favorites:  slugs(e.Favorites),
+		Bookmarks:  slugs(e.Bookmarks),
+		Following:  e.Following,
+		Followers:  e.Followers,
+		Identities: make([]identity, 0, len(e.Identities)),
+		APIKeys:    make([]apiKey, 0, len(e.APIKeys)),
+	}
+
+	for _, a := range e.Articles {
+		tags := a.Tags.TagsList()
+		sort.Strings(tags)
+		resp.Articles = append(resp.Articles, exportArticle{
+			Slug:        a.Slug,
+			Title:       a.Title,
+			Description: a.Description,
+			Body:        a.Body,
+			TagList:     tags,
+			CreatedAt:   a.CreatedAt,
+			UpdatedAt:   a.UpdatedAt,
+		})
+	}
+
+	for _, c := range e.Comments {
+		resp.Comments = append(resp.Comments, exportComment{
+			ID:        c.ID,
+			Article:   c.Article.Slug,
+			ParentID:  c.ParentID,
+			Body:      c.Body,
+			Edited:    c.Edited,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+		})
+	}
+
+	for _, i := range e.Identities {
+		resp.Identities = append(resp.Identities, identity{
+			Provider:  i.Provider,
+			Email:     i.Email,
+			CreatedAt: i.CreatedAt,
+		})
+	}
+
+	for _, k := range e.APIKeys {
+		resp.APIKeys = append(resp.APIKeys, newAPIKey(user.APIKey{
+			ID:         k.ID,
+			Name:       k.Name,
+			Prefix:     k.Prefix,
+			Scopes:     k.Scopes,
+			LastUsedAt: k.LastUsedAt,
+			CreatedAt:  k.CreatedAt,
+		}))
+	}
+
+	return resp
+}
+
+func slugs(aa []*realworld.Article) []string {
+	ss := make([]string, 0, len(aa))
+	for _, a := range aa {
+		ss = append(ss, a.Slug)
+	}
+	return ss
+}
+
+// exportFilename names the file an export is downloaded as.
+func exportFilename(e accountExport, format string) string {
+	return fmt.Sprintf("%s-%s.%s", e.Profile.Username, e.ExportedAt.UTC().Format("20060102"), format)
+}
+
+func (h UserHandler) encodeExportJSONResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := newAccountExport(response.(user.ExportAccountResponse).Export)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(e, exportJSON)))
+	return jsonResponse(w, e, http.StatusOK)
+}
+
+func (h UserHandler) encodeExportZIPResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := newAccountExport(response.(user.ExportAccountResponse).Export)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(e, exportZIP)))
+	w.WriteHeader(http.StatusOK)
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", e.Profile},
+		{"articles.json", e.Articles},
+		{"comments.json", e.Comments},
+		{"favorites.json", e.Favorites},
+		{"bookmarks.json", e.Bookmarks},
+		{"following.json", e.Following},
+		{"followers.json", e.Followers},
+		{"identities.json", e.Identities},
+		{"api-keys.json", e.APIKeys},
+	}
+
+	z := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := z.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: e.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
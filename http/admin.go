@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	realworld "github.com/xesina/gokit-realworld"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/user"
+	"net/http"
+)
+
+type unlockLoginRequest struct {
+	userID   int64
+	username string
+}
+
+func (req *unlockLoginRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.username = chi.URLParam(r, "username")
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *unlockLoginRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.username, validation.Required),
+	)
+}
+
+func (req *unlockLoginRequest) endpointRequest() user.UnlockLoginRequest {
+	return user.UnlockLoginRequest{
+		UserID:   req.userID,
+		Username: req.username,
+	}
+}
+
+func (h UserHandler) decodeUnlockLoginRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req unlockLoginRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type setRoleRequest struct {
+	userID   int64
+	username string
+	Role     string `json:"role"`
+}
+
+func (req *setRoleRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.username = chi.URLParam(r, "username")
+
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *setRoleRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.username, validation.Required),
+		validation.Field(&req.Role, validation.Required, validation.In(realworld.RoleUser, realworld.RoleModerator, realworld.RoleAdmin)),
+	)
+}
+
+func (req *setRoleRequest) endpointRequest() user.SetRoleRequest {
+	return user.SetRoleRequest{
+		UserID:   req.userID,
+		Username: req.username,
+		Role:     req.Role,
+	}
+}
+
+func (h UserHandler) decodeSetRoleRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req setRoleRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+func (h UserHandler) decodeBanRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req unlockLoginRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	return user.BanRequest{UserID: req.userID, Username: req.username}, nil
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	realworld "github.com/xesina/gokit-realworld"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/http/middleware"
+	"github.com/xesina/gokit-realworld/user"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// apiKeyAuthenticator lets middleware.JWTAuth accept the keys of an APIKeyService.
+type apiKeyAuthenticator struct {
+	s realworld.APIKeyService
+}
+
+func (a apiKeyAuthenticator) AuthenticateAPIKey(key string) (*middleware.Claims, error) {
+	k, err := a.s.Authenticate(key)
+	if err != nil {
+		return nil, err
+	}
+	return &middleware.Claims{
+		UserID:   k.UserID,
+		APIKeyID: k.ID,
+		Scopes:   k.Scopes,
+	}, nil
+}
+
+type createAPIKeyRequest struct {
+	userID int64
+	APIKey struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	} `json:"apiKey"`
+}
+
+func (req *createAPIKeyRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *createAPIKeyRequest) validate() error {
+	scopes := make([]interface{}, len(realworld.Scopes))
+	for i, s := range realworld.Scopes {
+		scopes[i] = s
+	}
+
+	return validation.ValidateStruct(
+		&req.APIKey,
+		validation.Field(&req.APIKey.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&req.APIKey.Scopes, validation.Required, validation.Each(validation.In(scopes...))),
+	)
+}
+
+func (req *createAPIKeyRequest) endpointRequest() user.CreateAPIKeyRequest {
+	return user.CreateAPIKeyRequest{
+		UserID: req.userID,
+		Name:   req.APIKey.Name,
+		Scopes: req.APIKey.Scopes,
+	}
+}
+
+func (h UserHandler) decodeCreateAPIKeyRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req createAPIKeyRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type apiKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func newAPIKey(k user.APIKey) apiKey {
+	resp := apiKey{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt,
+	}
+	if resp.Scopes == nil {
+		resp.Scopes = []string{}
+	}
+	if !k.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &k.LastUsedAt
+	}
+	return resp
+}
+
+type apiKeyResponse struct {
+	APIKey apiKey `json:"apiKey"`
+}
+
+func (h UserHandler) encodeAPIKeyResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := response.(user.APIKeyResponse)
+	resp := apiKeyResponse{APIKey: newAPIKey(e.APIKey)}
+	resp.APIKey.Key = e.Key
+	return jsonResponse(w, resp, http.StatusCreated)
+}
+
+func (h UserHandler) decodeAPIKeysRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+	return user.APIKeysRequest{UserID: id}, nil
+}
+
+type apiKeysResponse struct {
+	APIKeys []apiKey `json:"apiKeys"`
+}
+
+func (h UserHandler) encodeAPIKeysResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := response.(user.APIKeysResponse)
+	resp := apiKeysResponse{APIKeys: make([]apiKey, 0, len(e.APIKeys))}
+	for _, k := range e.APIKeys {
+		resp.APIKeys = append(resp.APIKeys, newAPIKey(k))
+	}
+	return jsonResponse(w, resp, http.StatusOK)
+}
+
+func (h UserHandler) decodeRevokeAPIKeyRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return nil, httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+
+	return user.RevokeAPIKeyRequest{UserID: id, ID: keyID}, nil
+}
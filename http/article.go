@@ -10,7 +10,6 @@ import (
 	realworld "github.com/xesina/gokit-realworld"
 	"github.com/xesina/gokit-realworld/article"
 	httpError "github.com/xesina/gokit-realworld/http/error"
-	"github.com/xesina/gokit-realworld/http/middleware"
 	"net/http"
 	"strconv"
 	"time"
@@ -19,6 +18,7 @@ import (
 type ArticleHandler struct {
 	service       realworld.ArticleService
 	userService   realworld.UserService
+	attachments   realworld.AttachmentService
 	serverOptions []transport.ServerOption
 }
 
@@ -26,6 +26,7 @@ func NewArticleHandler(c Context) ArticleHandler {
 	return ArticleHandler{
 		service:       c.articleService,
 		userService:   c.userService,
+		attachments:   c.attachmentService,
 		serverOptions: c.serverOptions,
 	}
 }
@@ -59,13 +60,11 @@ type articleCreateRequest struct {
 }
 
 func (req *articleCreateRequest) bind(r *http.Request) error {
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return err
 	}
-
-	id := claims["id"].(float64)
-	req.userID = int64(id)
+	req.userID = id
 
 	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
 		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
@@ -105,16 +104,19 @@ type Author struct {
 }
 
 type articleResponse struct {
-	Slug           string    `json:"slug"`
-	Title          string    `json:"title"`
-	Description    string    `json:"description"`
-	Body           string    `json:"body"`
-	Tags           []string  `json:"tagList"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
-	Favorited      bool      `json:"favorited"`
-	FavoritesCount int       `json:"favoritesCount"`
-	Author         Author    `json:"author"`
+	Slug            string         `json:"slug"`
+	Title           string         `json:"title"`
+	Description     string         `json:"description"`
+	Body            string         `json:"body"`
+	Tags            []string       `json:"tagList"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+	Favorited       bool           `json:"favorited"`
+	FavoritesCount  int            `json:"favoritesCount"`
+	Bookmarked      bool           `json:"bookmarked"`
+	Reactions       map[string]int `json:"reactions"`
+	ViewerReactions []string       `json:"viewerReactions"`
+	Author          Author         `json:"author"`
 }
 
 type singleArticleResponse struct {
@@ -123,15 +125,18 @@ type singleArticleResponse struct {
 
 func newArticleResponse(a *article.Response) singleArticleResponse {
 	return singleArticleResponse{Article: &articleResponse{
-		Slug:           a.Slug,
-		Title:          a.Title,
-		Description:    a.Description,
-		Body:           a.Body,
-		Tags:           a.TagsList(),
-		CreatedAt:      a.CreatedAt,
-		UpdatedAt:      a.UpdatedAt,
-		Favorited:      a.Favorited,
-		FavoritesCount: a.FavoritesCount,
+		Slug:            a.Slug,
+		Title:           a.Title,
+		Description:     a.Description,
+		Body:            a.Body,
+		Tags:            a.TagsList(),
+		CreatedAt:       a.CreatedAt,
+		UpdatedAt:       a.UpdatedAt,
+		Favorited:       a.Favorited,
+		FavoritesCount:  a.FavoritesCount,
+		Bookmarked:      a.Bookmarked,
+		Reactions:       a.Reactions,
+		ViewerReactions: a.ViewerReactions,
 		Author: Author{
 			Username:  a.Author.Username,
 			Bio:       a.Author.Bio,
@@ -156,13 +161,11 @@ type deleteRequest struct {
 }
 
 func (req *deleteRequest) bind(r *http.Request) error {
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return err
 	}
-
-	id := claims["id"].(float64)
-	req.userID = int64(id)
+	req.userID = id
 
 	req.slug = chi.URLParam(r, "slug")
 
@@ -210,15 +213,7 @@ type getRequest struct {
 }
 
 func (req *getRequest) bind(r *http.Request) error {
-	token, claims, err := middleware.FromContext(r.Context())
-	if err != nil {
-		return err
-	}
-
-	if token != nil {
-		id := claims["id"].(float64)
-		req.userID = int64(id)
-	}
+	req.userID = viewerID(r)
 
 	req.slug = chi.URLParam(r, "slug")
 
@@ -258,13 +253,11 @@ type favoriteRequest struct {
 }
 
 func (req *favoriteRequest) bind(r *http.Request) error {
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return err
 	}
-
-	id := claims["id"].(float64)
-	req.userID = int64(id)
+	req.userID = id
 
 	req.slug = chi.URLParam(r, "slug")
 
@@ -308,15 +301,7 @@ type listRequest struct {
 }
 
 func (req *listRequest) bind(r *http.Request) error {
-	token, claims, err := middleware.FromContext(r.Context())
-	if err != nil {
-		return err
-	}
-
-	if token != nil {
-		id := claims["id"].(float64)
-		req.userID = int64(id)
-	}
+	req.userID = viewerID(r)
 
 	req.tag = r.URL.Query().Get("tag")
 	req.author = r.URL.Query().Get("author")
@@ -367,15 +352,18 @@ func newArticlesResponse(list *article.ListResponse) (aa articleListResponse) {
 
 	for _, a := range list.Articles {
 		resp := articleResponse{
-			Slug:           a.Slug,
-			Title:          a.Title,
-			Description:    a.Description,
-			Body:           a.Body,
-			Tags:           a.Tags.TagsList(),
-			CreatedAt:      a.CreatedAt,
-			UpdatedAt:      a.UpdatedAt,
-			Favorited:      a.Favorited,
-			FavoritesCount: a.FavoritesCount,
+			Slug:            a.Slug,
+			Title:           a.Title,
+			Description:     a.Description,
+			Body:            a.Body,
+			Tags:            a.Tags.TagsList(),
+			CreatedAt:       a.CreatedAt,
+			UpdatedAt:       a.UpdatedAt,
+			Favorited:       a.Favorited,
+			FavoritesCount:  a.FavoritesCount,
+			Bookmarked:      a.Bookmarked,
+			Reactions:       a.Reactions,
+			ViewerReactions: a.ViewerReactions,
 			Author: Author{
 				Username:  a.Author.Username,
 				Bio:       a.Author.Bio,
@@ -413,13 +401,11 @@ func (req *feedRequest) endpointRequest() realworld.FeedRequest {
 }
 
 func (req *feedRequest) bind(r *http.Request) error {
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return err
 	}
-
-	id := claims["id"].(float64)
-	req.userID = int64(id)
+	req.userID = id
 
 	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
 	if err != nil {
@@ -458,13 +444,11 @@ type articleUpdateRequest struct {
 }
 
 func (req *articleUpdateRequest) bind(r *http.Request) error {
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return err
 	}
-
-	id := claims["id"].(float64)
-	req.userID = int64(id)
+	req.userID = id
 
 	req.slug = chi.URLParam(r, "slug")
 
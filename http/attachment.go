@@ -0,0 +1,163 @@
+package http
+
+import (
+	"context"
+	"github.com/go-chi/chi"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/article"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// attachmentField is the form field attachments are uploaded in.
+const attachmentField = "image"
+
+type attachRequest struct {
+	userID int64
+	slug   string
+	image  io.Reader
+}
+
+func (req *attachRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.slug = chi.URLParam(r, "slug")
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	req.image, err = formFile(r, attachmentField)
+	return err
+}
+
+func (req *attachRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.slug, validation.Required),
+	)
+}
+
+func (req *attachRequest) endpointRequest() article.AttachRequest {
+	return article.AttachRequest{
+		UserID: req.userID,
+		Slug:   req.slug,
+		Image:  req.image,
+	}
+}
+
+func (h ArticleHandler) decodeAttachRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req attachRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+func (h ArticleHandler) decodeAttachmentsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return article.AttachmentsRequest{Slug: chi.URLParam(r, "slug")}, nil
+}
+
+type detachRequest struct {
+	userID int64
+	id     int64
+	slug   string
+}
+
+func (req *detachRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.slug = chi.URLParam(r, "slug")
+	attachmentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+	req.id = attachmentID
+
+	return nil
+}
+
+func (req *detachRequest) endpointRequest() article.DetachRequest {
+	return article.DetachRequest{
+		ID:     req.id,
+		UserID: req.userID,
+		Slug:   req.slug,
+	}
+}
+
+func (h ArticleHandler) decodeDetachRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req detachRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type attachment struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Markdown    string    `json:"markdown"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// newAttachment includes the Markdown that shows the attachment, ready to
+// be pasted into an article's body.
+func newAttachment(a *realworld.Attachment) attachment {
+	return attachment{
+		ID:          a.ID,
+		URL:         a.URL,
+		Markdown:    "![](" + a.URL + ")",
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+type attachmentResponse struct {
+	Attachment attachment `json:"attachment"`
+}
+
+func (h ArticleHandler) encodeAttachmentResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := response.(article.AttachmentResponse)
+	return jsonResponse(w, attachmentResponse{Attachment: newAttachment(e.Attachment)}, http.StatusCreated)
+}
+
+type attachmentsResponse struct {
+	Attachments []attachment `json:"attachments"`
+}
+
+func (h ArticleHandler) encodeAttachmentsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := response.(article.AttachmentsResponse)
+	resp := attachmentsResponse{Attachments: make([]attachment, 0, len(e.Attachments))}
+	for _, a := range e.Attachments {
+		resp.Attachments = append(resp.Attachments, newAttachment(a))
+	}
+	return jsonResponse(w, resp, http.StatusOK)
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	realworld "github.com/xesina/gokit-realworld"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/user"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+)
+
+// avatarField is the form field avatars are uploaded in.
+const avatarField = "image"
+
+type setAvatarRequest struct {
+	userID int64
+	image  io.Reader
+}
+
+// bind finds the image in the multipart body without reading it, leaving
+// that and the size limit to the service.
+func (req *setAvatarRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.image, err = formFile(r, avatarField)
+	return err
+}
+
+// formFile returns the part of the multipart body in the form field, to be
+// read from where it starts in the body.
+func formFile(r *http.Request, field string) (io.Reader, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, validation.Errors{field: errors.New("cannot be blank")}
+		}
+		if err != nil {
+			return nil, httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+		}
+		if part.FormName() == field {
+			return part, nil
+		}
+	}
+}
+
+func (req *setAvatarRequest) endpointRequest() user.SetAvatarRequest {
+	return user.SetAvatarRequest{
+		UserID: req.userID,
+		Image:  req.image,
+	}
+}
+
+func (h UserHandler) decodeSetAvatarRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req setAvatarRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+func (h UserHandler) decodeRemoveAvatarRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+	return user.RemoveAvatarRequest{UserID: id}, nil
+}
+
+type avatarResponse struct {
+	Image      realworld.Image   `json:"image"`
+	ImageSizes map[string]string `json:"imageSizes"`
+}
+
+func (h UserHandler) encodeAvatarResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := response.(user.AvatarResponse)
+	resp := avatarResponse{Image: e.Image, ImageSizes: make(map[string]string, len(e.Sizes))}
+	for size, url := range e.Sizes {
+		resp.ImageSizes[strconv.Itoa(size)] = url
+	}
+	return jsonResponse(w, resp, http.StatusOK)
+}
+
+// mediaHandlerFunc serves what's been uploaded to storage, by key.
+func mediaHandlerFunc(storage realworld.BlobStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := chi.URLParam(r, "*")
+		rc, err := storage.Open(key)
+		if err != nil {
+			httpError.EncodeError(r.Context(), err, w)
+			return
+		}
+		defer rc.Close()
+
+		contentType := mime.TypeByExtension(path.Ext(key))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.Copy(w, rc)
+	}
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"github.com/go-chi/chi"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xesina/gokit-realworld/article"
+	"net/http"
+	"strconv"
+)
+
+type bookmarkRequest struct {
+	userID int64
+	slug   string
+}
+
+func (req *bookmarkRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.slug = chi.URLParam(r, "slug")
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *bookmarkRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.slug, validation.Required),
+	)
+}
+
+func (req *bookmarkRequest) endpointRequest() article.BookmarkRequest {
+	return article.BookmarkRequest{
+		UserID: req.userID,
+		Slug:   req.slug,
+	}
+}
+
+func (h ArticleHandler) decodeBookmarkRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req bookmarkRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type bookmarksRequest struct {
+	userID int64
+	limit  int
+	offset int
+}
+
+func (req *bookmarksRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+	req.limit = limit
+
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil {
+		offset = 0
+	}
+	req.offset = offset
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *bookmarksRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.limit, validation.Min(1), validation.Max(100)),
+		validation.Field(&req.offset, validation.Min(0)),
+	)
+}
+
+func (req *bookmarksRequest) endpointRequest() article.BookmarksRequest {
+	return article.BookmarksRequest{
+		UserID: req.userID,
+		Limit:  req.limit,
+		Offset: req.offset,
+	}
+}
+
+func (h ArticleHandler) decodeBookmarksRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req bookmarksRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
@@ -9,7 +9,6 @@ import (
 	realworld "github.com/xesina/gokit-realworld"
 	"github.com/xesina/gokit-realworld/article"
 	httpError "github.com/xesina/gokit-realworld/http/error"
-	"github.com/xesina/gokit-realworld/http/middleware"
 	"net/http"
 	"strconv"
 	"time"
@@ -19,18 +18,17 @@ type addCommentRequest struct {
 	userID  int64
 	slug    string
 	Comment struct {
-		Body string `json:"body"`
+		Body     string `json:"body"`
+		ParentID int64  `json:"parentId"`
 	} `json:"comment"`
 }
 
 func (req *addCommentRequest) bind(r *http.Request) error {
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return err
 	}
-
-	id := claims["id"].(float64)
-	req.userID = int64(id)
+	req.userID = id
 
 	req.slug = chi.URLParam(r, "slug")
 
@@ -54,9 +52,10 @@ func (req *addCommentRequest) validate() error {
 
 func (req *addCommentRequest) endpointRequest() article.AddCommentRequest {
 	return article.AddCommentRequest{
-		Slug:   req.slug,
-		UserID: req.userID,
-		Body:   req.Comment.Body,
+		Slug:     req.slug,
+		UserID:   req.userID,
+		ParentID: req.Comment.ParentID,
+		Body:     req.Comment.Body,
 	}
 }
 
@@ -70,11 +69,17 @@ func (h ArticleHandler) decodeAddCommentRequest(_ context.Context, r *http.Reque
 }
 
 type comment struct {
-	ID        int64     `json:"id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Author    struct {
+	ID              int64          `json:"id"`
+	ParentID        int64          `json:"parentId,omitempty"`
+	Body            string         `json:"body"`
+	Deleted         bool           `json:"deleted"`
+	Edited          bool           `json:"edited"`
+	Replies         []comment      `json:"replies,omitempty"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+	Reactions       map[string]int `json:"reactions"`
+	ViewerReactions []string       `json:"viewerReactions"`
+	Author          struct {
 		Username  string          `json:"username"`
 		Bio       realworld.Bio   `json:"bio"`
 		Image     realworld.Image `json:"image"`
@@ -87,18 +92,34 @@ type commentResponse struct {
 }
 
 func newCommentResponse(c *article.CommentResponse) commentResponse {
-	return commentResponse{Comment: &comment{
-		ID:        c.ID,
-		Body:      c.Body,
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
+	resp := newComment(c.Comment)
+	return commentResponse{Comment: &resp}
+}
+
+func newComment(c article.Comment) comment {
+	resp := comment{
+		ID:              c.ID,
+		ParentID:        c.ParentID,
+		Body:            c.Body,
+		Deleted:         c.Deleted,
+		Edited:          c.Edited,
+		CreatedAt:       c.CreatedAt,
+		UpdatedAt:       c.UpdatedAt,
+		Reactions:       c.Reactions,
+		ViewerReactions: c.ViewerReactions,
 		Author: Author{
 			Username:  c.Author.Username,
 			Bio:       c.Author.Bio,
 			Image:     c.Author.Image,
 			Following: c.Author.Following,
 		},
-	}}
+	}
+
+	for _, r := range c.Replies {
+		resp.Replies = append(resp.Replies, newComment(r))
+	}
+
+	return resp
 }
 
 func (h ArticleHandler) encodeCommentResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
@@ -110,6 +131,134 @@ func (h ArticleHandler) encodeCommentResponse(ctx context.Context, w http.Respon
 	return jsonResponse(w, newCommentResponse(&e), http.StatusOK)
 }
 
+type updateCommentRequest struct {
+	userID  int64
+	id      int64
+	slug    string
+	Comment struct {
+		Body string `json:"body"`
+	} `json:"comment"`
+}
+
+func (req *updateCommentRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.slug = chi.URLParam(r, "slug")
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+	req.id = commentID
+
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *updateCommentRequest) validate() error {
+	return validation.ValidateStruct(
+		&req.Comment,
+		validation.Field(&req.Comment.Body, validation.Required),
+	)
+}
+
+func (req *updateCommentRequest) endpointRequest() article.UpdateCommentRequest {
+	return article.UpdateCommentRequest{
+		ID:     req.id,
+		Slug:   req.slug,
+		UserID: req.userID,
+		Body:   req.Comment.Body,
+	}
+}
+
+func (h ArticleHandler) decodeUpdateCommentRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req updateCommentRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type commentHistoryRequest struct {
+	userID int64
+	id     int64
+	slug   string
+}
+
+func (req *commentHistoryRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.slug = chi.URLParam(r, "slug")
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+	req.id = commentID
+
+	return nil
+}
+
+func (req *commentHistoryRequest) endpointRequest() article.CommentHistoryRequest {
+	return article.CommentHistoryRequest{
+		ID:     req.id,
+		Slug:   req.slug,
+		UserID: req.userID,
+	}
+}
+
+func (h ArticleHandler) decodeCommentHistoryRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req commentHistoryRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type commentEdit struct {
+	Body     string    `json:"body"`
+	EditedAt time.Time `json:"editedAt"`
+}
+
+type commentHistoryResponse struct {
+	Edits []commentEdit `json:"edits"`
+}
+
+func newCommentHistoryResponse(h *article.CommentHistoryResponse) (resp commentHistoryResponse) {
+	resp.Edits = make([]commentEdit, 0)
+	for _, e := range h.Edits {
+		resp.Edits = append(resp.Edits, commentEdit{
+			Body:     e.Body,
+			EditedAt: e.EditedAt,
+		})
+	}
+	return
+}
+
+func (h ArticleHandler) encodeCommentHistoryResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+	e := response.(article.CommentHistoryResponse)
+	return jsonResponse(w, newCommentHistoryResponse(&e), http.StatusOK)
+}
+
 type deleteCommentRequest struct {
 	userID int64
 	id     int64
@@ -117,13 +266,11 @@ type deleteCommentRequest struct {
 }
 
 func (req *deleteCommentRequest) bind(r *http.Request) error {
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return err
 	}
-
-	id := claims["id"].(float64)
-	req.userID = int64(id)
+	req.userID = id
 
 	req.slug = chi.URLParam(r, "slug")
 	commentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
@@ -163,26 +310,67 @@ func (h ArticleHandler) encodeDeleteCommentResponse(ctx context.Context, w http.
 type commentsRequest struct {
 	userID int64
 	slug   string
+	flat   bool
+	sort   string
+	limit  int
+	offset int
 }
 
 func (req *commentsRequest) bind(r *http.Request) error {
-	_, claims, err := middleware.FromContext(r.Context())
+	req.userID = viewerID(r)
+
+	req.slug = chi.URLParam(r, "slug")
+
+	flat, err := strconv.ParseBool(r.URL.Query().Get("flat"))
 	if err != nil {
-		return err
+		flat = false
 	}
+	req.flat = flat
 
-	id := claims["id"].(float64)
-	req.userID = int64(id)
+	req.sort = r.URL.Query().Get("sort")
+	if req.sort == "" {
+		req.sort = realworld.CommentsOldest
+	}
 
-	req.slug = chi.URLParam(r, "slug")
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+	req.limit = limit
+
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil {
+		offset = 0
+	}
+	req.offset = offset
+
+	if err := req.validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+func (req *commentsRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(
+			&req.sort,
+			validation.In(realworld.CommentsOldest, realworld.CommentsNewest, realworld.CommentsMostReacted),
+		),
+		validation.Field(&req.limit, validation.Min(0), validation.Max(100)),
+		validation.Field(&req.offset, validation.Min(0)),
+	)
+}
+
 func (req *commentsRequest) endpointRequest() article.CommentsRequest {
 	return article.CommentsRequest{
 		UserID: req.userID,
 		Slug:   req.slug,
+		Flat:   req.flat,
+		Sort:   req.sort,
+		Limit:  req.limit,
+		Offset: req.offset,
 	}
 }
 
@@ -196,27 +384,17 @@ func (h ArticleHandler) decodeCommentsRequest(_ context.Context, r *http.Request
 }
 
 type commentsResponse struct {
-	Comments []comment `json:"comments"`
+	Comments      []comment `json:"comments"`
+	CommentsCount int       `json:"commentsCount"`
 }
 
 func newCommentsResponse(list *article.CommentsResponse) (cc commentsResponse) {
 	cc.Comments = make([]comment, 0)
 
 	for _, c := range list.Comments {
-		resp := comment{
-			ID:        c.ID,
-			Body:      c.Body,
-			CreatedAt: c.CreatedAt,
-			UpdatedAt: c.UpdatedAt,
-			Author: Author{
-				Username:  c.Author.Username,
-				Bio:       c.Author.Bio,
-				Image:     c.Author.Image,
-				Following: c.Author.Following,
-			},
-		}
-		cc.Comments = append(cc.Comments, resp)
+		cc.Comments = append(cc.Comments, newComment(c))
 	}
+	cc.CommentsCount = list.Count
 	return
 }
 
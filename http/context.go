@@ -8,9 +8,18 @@ import (
 )
 
 type Context struct {
-	router         *chi.Mux
-	jwt            *middleware.JWTAuth
-	serverOptions  []transport.ServerOption
-	userService    realworld.UserService
-	articleService realworld.ArticleService
+	router            *chi.Mux
+	jwt               *middleware.JWTAuth
+	serverOptions     []transport.ServerOption
+	userService       realworld.UserService
+	articleService    realworld.ArticleService
+	tokenService      realworld.RefreshTokenService
+	sessionService    realworld.SessionService
+	apiKeyService     realworld.APIKeyService
+	identityService   realworld.IdentityService
+	accountService    realworld.AccountService
+	avatarService     realworld.AvatarService
+	attachmentService realworld.AttachmentService
+	media             realworld.BlobStorage
+	sessionCookies    SessionCookies
 }
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+// Cookies of the cookie session mode. The CSRF token is readable by scripts
+// so clients can repeat it in csrfHeader.
+const (
+	accessTokenCookie  = "rw_access"
+	refreshTokenCookie = "rw_refresh"
+	csrfCookie         = "rw_csrf"
+	csrfHeader         = "X-CSRF-Token"
+)
+
+// oauthCookie keeps the binding of a login with an identity provider, in
+// either session mode, for the callback to be checked against. Only the
+// callback is sent it.
+const (
+	oauthCookie     = "rw_oauth"
+	oauthCookiePath = "/api/users/oauth"
+)
+
+// SessionCookies configures the cookie session mode, where browsers get their
+// tokens in HttpOnly cookies rather than in response bodies, so scripts never
+// see them. Other clients keep sending tokens in the Authorization header.
+type SessionCookies struct {
+	Enabled bool
+	// Insecure lets the cookies be sent over plain HTTP, for local development.
+	Insecure bool
+	Domain   string
+	// RefreshMaxAge is how long browsers keep refresh tokens, which should
+	// match how long they're valid. They're kept until the browser closes
+	// when it's zero.
+	RefreshMaxAge time.Duration
+	// AllowedOrigins are the origins allowed to make requests with the cookies
+	// from another site. Every origin may still make requests without them.
+	AllowedOrigins []string
+}
+
+// setSessionCookies hands out the tokens of a new or refreshed session in
+// cookies, along with a new CSRF token.
+func (h UserHandler) setSessionCookies(w http.ResponseWriter, accessToken, refreshToken string) error {
+	csrf, err := csrfToken()
+	if err != nil {
+		return err
+	}
+
+	// Access tokens go wherever they're needed; refresh tokens only to
+	// where they're redeemed or revoked.
+	http.SetCookie(w, h.cookie(accessTokenCookie, accessToken, "/api", int(AccessTokenTTL.Seconds()), true))
+	http.SetCookie(w, h.cookie(refreshTokenCookie, refreshToken, "/api/users", int(h.cookies.RefreshMaxAge.Seconds()), true))
+	http.SetCookie(w, h.cookie(csrfCookie, csrf, "/", int(h.cookies.RefreshMaxAge.Seconds()), false))
+
+	return nil
+}
+
+// moveTokensToCookies sets the cookies of a new or refreshed session in u in
+// the cookie session mode, and takes the tokens out of the response body.
+func (h UserHandler) moveTokensToCookies(w http.ResponseWriter, u *userResponse) error {
+	if !h.cookies.Enabled {
+		return nil
+	}
+
+	if u.RefreshToken != "" {
+		if err := h.setSessionCookies(w, u.Token, u.RefreshToken); err != nil {
+			return err
+		}
+	}
+
+	u.Token, u.RefreshToken = "", ""
+	return nil
+}
+
+func (h UserHandler) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, h.cookie(accessTokenCookie, "", "/api", -1, true))
+	http.SetCookie(w, h.cookie(refreshTokenCookie, "", "/api/users", -1, true))
+	http.SetCookie(w, h.cookie(csrfCookie, "", "/", -1, false))
+}
+
+// cookie returns a cookie that lasts for maxAge seconds or, when it's zero,
+// as long as the browser session.
+func (h UserHandler) cookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.cookies.Domain,
+		MaxAge:   maxAge,
+		Secure:   !h.cookies.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// setOAuthCookie keeps binding for the callback until the browser closes,
+// by when the login has expired anyway.
+func (h UserHandler) setOAuthCookie(w http.ResponseWriter, binding string) {
+	http.SetCookie(w, h.cookie(oauthCookie, binding, oauthCookiePath, 0, true))
+}
+
+func (h UserHandler) clearOAuthCookie(w http.ResponseWriter) {
+	http.SetCookie(w, h.cookie(oauthCookie, "", oauthCookiePath, -1, true))
+}
+
+func csrfToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
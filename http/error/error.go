@@ -18,6 +18,7 @@ var (
 	ErrRequestBody  = errors.New("invalid request body")
 	ErrInternal     = errors.New("internal server error")
 	ErrUnauthorized = errors.New("unauthorized access")
+	ErrForbidden    = errors.New("forbidden")
 )
 
 // encode errors from business-logic
@@ -108,10 +109,19 @@ func mapDomainErrorCode(code string) int {
 	switch code {
 	case realworld.EIncorrectPassword:
 		return http.StatusForbidden
-	case realworld.EConflict:
+	case realworld.EConflict, realworld.EInvalidReaction, realworld.EInvalidComment, realworld.EInvalidToken,
+		realworld.EInvalidUpload:
 		return http.StatusUnprocessableEntity
+	case realworld.ETooLarge:
+		return http.StatusRequestEntityTooLarge
 	case realworld.ENotFound:
 		return http.StatusNotFound
+	case realworld.EForbidden:
+		return http.StatusForbidden
+	case realworld.EUnauthorized:
+		return http.StatusUnauthorized
+	case realworld.ERateLimit, realworld.ELockedOut:
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
@@ -9,7 +9,7 @@ import (
 
 func (h UserHandler) registerHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
-		user.RegisterEndpoint(h.service),
+		user.RegisterEndpoint(h.service, h.tokenService),
 		h.decodeRegisterRequest,
 		h.encodeRegisterResponse,
 		h.serverOptions...,
@@ -18,13 +18,289 @@ func (h UserHandler) registerHandlerFunc() http.HandlerFunc {
 
 func (h UserHandler) loginHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
-		user.LoginEndpoint(h.service),
+		user.LoginEndpoint(h.service, h.tokenService),
 		h.decodeLoginRequest,
+		h.encodeLoginResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) loginTwoFactorHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.LoginTwoFactorEndpoint(h.service, h.tokenService),
+		h.decodeLoginTwoFactorRequest,
 		h.encodeUserResponse,
 		h.serverOptions...,
 	))
 }
 
+func (h UserHandler) refreshHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.RefreshEndpoint(h.service, h.tokenService),
+		h.decodeRefreshRequest,
+		h.encodeUserResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) logoutHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.LogoutEndpoint(h.tokenService),
+		h.decodeRefreshRequest,
+		h.encodeLogoutResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) forgotPasswordHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.ForgotPasswordEndpoint(h.service),
+		h.decodeForgotPasswordRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) resetPasswordHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.ResetPasswordEndpoint(h.service),
+		h.decodeResetPasswordRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) verifyEmailHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.VerifyEmailEndpoint(h.service),
+		h.decodeVerifyEmailRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) resendVerificationHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.ResendVerificationEndpoint(h.service),
+		h.decodeResendVerificationRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) enrollTwoFactorHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.EnrollTwoFactorEndpoint(h.service),
+		h.decodeEnrollTwoFactorRequest,
+		h.encodeEnrollTwoFactorResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) confirmTwoFactorHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.ConfirmTwoFactorEndpoint(h.service),
+		h.decodeTwoFactorCodeRequest,
+		h.encodeRecoveryCodesResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) disableTwoFactorHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.DisableTwoFactorEndpoint(h.service),
+		h.decodeTwoFactorCodeRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) createAPIKeyHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.CreateAPIKeyEndpoint(h.apiKeys),
+		h.decodeCreateAPIKeyRequest,
+		h.encodeAPIKeyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) apiKeysHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.APIKeysEndpoint(h.apiKeys),
+		h.decodeAPIKeysRequest,
+		h.encodeAPIKeysResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) revokeAPIKeyHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.RevokeAPIKeyEndpoint(h.apiKeys),
+		h.decodeRevokeAPIKeyRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) providersHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.ProvidersEndpoint(h.identities),
+		h.decodeProvidersRequest,
+		h.encodeProvidersResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) loginRedirectHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.AuthorizationURLEndpoint(h.identities),
+		h.decodeLoginRedirectRequest,
+		h.encodeRedirectResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) oauthCallbackHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.OAuthCallbackEndpoint(h.identities, h.service, h.tokenService),
+		h.decodeOAuthCallbackRequest,
+		h.encodeOAuthCallbackResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) deleteAccountHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.DeleteAccountEndpoint(h.accounts),
+		h.decodeDeleteAccountRequest,
+		h.encodeLogoutResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) setAvatarHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.SetAvatarEndpoint(h.avatars),
+		h.decodeSetAvatarRequest,
+		h.encodeAvatarResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) removeAvatarHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.RemoveAvatarEndpoint(h.avatars),
+		h.decodeRemoveAvatarRequest,
+		h.encodeAvatarResponse,
+		h.serverOptions...,
+	))
+}
+
+// exportAccountHandlerFunc answers with a single JSON document or, given
+// format=zip, a ZIP archive of JSON files.
+func (h UserHandler) exportAccountHandlerFunc() http.HandlerFunc {
+	asJSON := transport.NewServer(
+		user.ExportAccountEndpoint(h.accounts),
+		h.decodeExportAccountRequest,
+		h.encodeExportJSONResponse,
+		h.serverOptions...,
+	)
+	asZIP := transport.NewServer(
+		user.ExportAccountEndpoint(h.accounts),
+		h.decodeExportAccountRequest,
+		h.encodeExportZIPResponse,
+		h.serverOptions...,
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == exportZIP {
+			asZIP.ServeHTTP(w, r)
+			return
+		}
+		asJSON.ServeHTTP(w, r)
+	}
+}
+
+func (h UserHandler) identitiesHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.IdentitiesEndpoint(h.identities),
+		h.decodeIdentitiesRequest,
+		h.encodeIdentitiesResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) linkIdentityHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.AuthorizationURLEndpoint(h.identities),
+		h.decodeLinkIdentityRequest,
+		h.encodeAuthorizationURLResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) unlinkIdentityHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.UnlinkIdentityEndpoint(h.identities),
+		h.decodeUnlinkIdentityRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) sessionsHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.SessionsEndpoint(h.sessions),
+		h.decodeSessionsRequest,
+		h.encodeSessionsResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) revokeSessionHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.RevokeSessionEndpoint(h.sessions),
+		h.decodeRevokeSessionRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) unlockLoginHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.UnlockLoginEndpoint(h.service),
+		h.decodeUnlockLoginRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) setRoleHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.SetRoleEndpoint(h.service),
+		h.decodeSetRoleRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) banHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.BanEndpoint(h.service),
+		h.decodeBanRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) unbanHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.UnbanEndpoint(h.service),
+		h.decodeBanRequest,
+		h.encodeEmptyResponse,
+		h.serverOptions...,
+	))
+}
+
 func (h UserHandler) getHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
 		user.GetEndpoint(h.service),
@@ -36,13 +312,22 @@ func (h UserHandler) getHandlerFunc() http.HandlerFunc {
 
 func (h UserHandler) updateHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
-		user.UpdateEndpoint(h.service),
+		user.UpdateEndpoint(h.service, h.tokenService),
 		h.decodeUpdateRequest,
 		h.encodeUserResponse,
 		h.serverOptions...,
 	))
 }
 
+func (h UserHandler) patchHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.PatchEndpoint(h.service, h.tokenService),
+		h.decodePatchRequest,
+		h.encodeUserResponse,
+		h.serverOptions...,
+	))
+}
+
 func (h UserHandler) profileHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
 		user.GetProfileEndpoint(h.service),
@@ -52,6 +337,42 @@ func (h UserHandler) profileHandlerFunc() http.HandlerFunc {
 	))
 }
 
+func (h UserHandler) followersHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.FollowersEndpoint(h.service),
+		h.decodeFollowListRequest,
+		h.encodeProfilesResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) followingHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.FollowingEndpoint(h.service),
+		h.decodeFollowListRequest,
+		h.encodeProfilesResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) searchHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.SearchEndpoint(h.service),
+		h.decodeSearchRequest,
+		h.encodeProfilesResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) autocompleteHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.AutocompleteEndpoint(h.service),
+		h.decodeAutocompleteRequest,
+		h.encodeProfilesResponse,
+		h.serverOptions...,
+	))
+}
+
 func (h UserHandler) followHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
 		user.FollowEndpoint(h.service),
@@ -70,6 +391,24 @@ func (h UserHandler) unfollowHandlerFunc() http.HandlerFunc {
 	))
 }
 
+func (h UserHandler) restrictHandlerFunc(kind string) http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.RestrictEndpoint(h.service, kind),
+		h.decodeProfileRequest,
+		h.encodeProfileResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h UserHandler) unrestrictHandlerFunc(kind string) http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		user.UnrestrictEndpoint(h.service, kind),
+		h.decodeProfileRequest,
+		h.encodeProfileResponse,
+		h.serverOptions...,
+	))
+}
+
 func (h ArticleHandler) createHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
 		article.CreateEndpoint(h.service, h.userService),
@@ -115,6 +454,15 @@ func (h ArticleHandler) deleteHandlerFunc() http.HandlerFunc {
 	))
 }
 
+func (h ArticleHandler) removeHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.RemoveEndpoint(h.service),
+		h.decodeDeleteRequest,
+		h.encodeDeleteResponse,
+		h.serverOptions...,
+	))
+}
+
 func (h ArticleHandler) updateHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
 		article.UpdateEndpoint(h.service, h.userService),
@@ -142,6 +490,69 @@ func (h ArticleHandler) unfavoriteHandlerFunc() http.HandlerFunc {
 	))
 }
 
+func (h ArticleHandler) bookmarkHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.BookmarkEndpoint(h.service, h.userService),
+		h.decodeBookmarkRequest,
+		h.encodeArticleResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) unbookmarkHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.UnbookmarkEndpoint(h.service, h.userService),
+		h.decodeBookmarkRequest,
+		h.encodeArticleResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) bookmarksHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.BookmarksEndpoint(h.service, h.userService),
+		h.decodeBookmarksRequest,
+		h.encodeArticlesResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) reactHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.ReactEndpoint(h.service, h.userService),
+		h.decodeReactionRequest,
+		h.encodeArticleResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) unreactHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.UnreactEndpoint(h.service, h.userService),
+		h.decodeReactionRequest,
+		h.encodeArticleResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) commentReactHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.CommentReactEndpoint(h.service, h.userService),
+		h.decodeCommentReactionRequest,
+		h.encodeCommentResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) commentUnreactHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.CommentUnreactEndpoint(h.service, h.userService),
+		h.decodeCommentReactionRequest,
+		h.encodeCommentResponse,
+		h.serverOptions...,
+	))
+}
+
 func (h ArticleHandler) addCommentHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
 		article.AddCommentEndpoint(h.service, h.userService),
@@ -151,6 +562,24 @@ func (h ArticleHandler) addCommentHandlerFunc() http.HandlerFunc {
 	))
 }
 
+func (h ArticleHandler) updateCommentHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.UpdateCommentEndpoint(h.service, h.userService),
+		h.decodeUpdateCommentRequest,
+		h.encodeCommentResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) commentHistoryHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.CommentHistoryEndpoint(h.service),
+		h.decodeCommentHistoryRequest,
+		h.encodeCommentHistoryResponse,
+		h.serverOptions...,
+	))
+}
+
 func (h ArticleHandler) commentsHandlerFunc() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
 		article.CommentsEndpoint(h.service, h.userService),
@@ -169,6 +598,15 @@ func (h ArticleHandler) deleteCommentHandlerFunc() http.HandlerFunc {
 	))
 }
 
+func (h ArticleHandler) removeCommentHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.RemoveCommentEndpoint(h.service),
+		h.decodeDeleteCommentRequest,
+		h.encodeDeleteResponse,
+		h.serverOptions...,
+	))
+}
+
 func (h ArticleHandler) tagsHandler() http.HandlerFunc {
 	return wrapHandler(transport.NewServer(
 		article.TagsEndpoint(h.service),
@@ -177,3 +615,30 @@ func (h ArticleHandler) tagsHandler() http.HandlerFunc {
 		h.serverOptions...,
 	))
 }
+
+func (h ArticleHandler) attachHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.AttachEndpoint(h.attachments),
+		h.decodeAttachRequest,
+		h.encodeAttachmentResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) attachmentsHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.AttachmentsEndpoint(h.attachments),
+		h.decodeAttachmentsRequest,
+		h.encodeAttachmentsResponse,
+		h.serverOptions...,
+	))
+}
+
+func (h ArticleHandler) detachHandlerFunc() http.HandlerFunc {
+	return wrapHandler(transport.NewServer(
+		article.DetachEndpoint(h.attachments),
+		h.decodeDetachRequest,
+		h.encodeDeleteResponse,
+		h.serverOptions...,
+	))
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/user"
+	"net/http"
+	"time"
+)
+
+type providersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+func (h UserHandler) encodeProvidersResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+	e := response.(user.ProvidersResponse)
+	return jsonResponse(w, providersResponse{Providers: e.Providers}, http.StatusOK)
+}
+
+func (h UserHandler) decodeProvidersRequest(_ context.Context, _ *http.Request) (request interface{}, err error) {
+	return nil, nil
+}
+
+// decodeLoginRedirectRequest starts a login, which browsers are sent to
+// rather than call; decodeLinkIdentityRequest starts linking an identity to
+// the authenticated user, whose client has to send the user on itself. The
+// callback has to come from the browser that started either, which gets a
+// cookie to prove it.
+func (h UserHandler) decodeLoginRedirectRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return user.AuthorizationURLRequest{Provider: chi.URLParam(r, "provider")}, nil
+}
+
+func (h UserHandler) encodeRedirectResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+	e := response.(user.AuthorizationURLResponse)
+	h.setOAuthCookie(w, e.Binding)
+	w.Header().Set("Location", e.URL)
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func (h UserHandler) decodeLinkIdentityRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+	return user.AuthorizationURLRequest{Provider: chi.URLParam(r, "provider"), UserID: id}, nil
+}
+
+type authorizationURLResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+func (h UserHandler) encodeAuthorizationURLResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+	e := response.(user.AuthorizationURLResponse)
+	h.setOAuthCookie(w, e.Binding)
+	return jsonResponse(w, authorizationURLResponse{AuthorizationURL: e.URL}, http.StatusOK)
+}
+
+type oauthCallbackRequest struct {
+	provider string
+	state    string
+	code     string
+	binding  string
+}
+
+func (req *oauthCallbackRequest) bind(r *http.Request) error {
+	q := r.URL.Query()
+	// Providers send users back with an error instead of a code when they
+	// didn't log in.
+	if e := q.Get("error"); e != "" {
+		return httpError.NewError(http.StatusUnauthorized, fmt.Errorf("login with %s failed: %s", chi.URLParam(r, "provider"), e))
+	}
+
+	req.provider = chi.URLParam(r, "provider")
+	req.state = q.Get("state")
+	req.code = q.Get("code")
+	if c, err := r.Cookie(oauthCookie); err == nil {
+		req.binding = c.Value
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *oauthCallbackRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.state, validation.Required),
+		validation.Field(&req.code, validation.Required),
+	)
+}
+
+func (req *oauthCallbackRequest) endpointRequest() user.OAuthCallbackRequest {
+	return user.OAuthCallbackRequest{
+		Provider: req.provider,
+		State:    req.state,
+		Code:     req.code,
+		Binding:  req.binding,
+	}
+}
+
+func (h UserHandler) decodeOAuthCallbackRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req oauthCallbackRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+// encodeOAuthCallbackResponse answers like a password login, or with the
+// user's identities when one was linked.
+func (h UserHandler) encodeOAuthCallbackResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	// The binding isn't needed anymore.
+	h.clearOAuthCookie(w)
+	if _, ok := response.(user.IdentitiesResponse); ok {
+		return h.encodeIdentitiesResponse(ctx, w, response)
+	}
+	return h.encodeLoginResponse(ctx, w, response)
+}
+
+func (h UserHandler) decodeIdentitiesRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+	return user.IdentitiesRequest{UserID: id}, nil
+}
+
+type identity struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type identitiesResponse struct {
+	Identities []identity `json:"identities"`
+}
+
+func (h UserHandler) encodeIdentitiesResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := response.(user.IdentitiesResponse)
+	resp := identitiesResponse{Identities: make([]identity, 0, len(e.Identities))}
+	for _, i := range e.Identities {
+		resp.Identities = append(resp.Identities, identity{
+			Provider:  i.Provider,
+			Email:     i.Email,
+			CreatedAt: i.CreatedAt,
+		})
+	}
+	return jsonResponse(w, resp, http.StatusOK)
+}
+
+func (h UserHandler) decodeUnlinkIdentityRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+	return user.UnlinkIdentityRequest{UserID: id, Provider: chi.URLParam(r, "provider")}, nil
+}
@@ -0,0 +1,12 @@
+package http
+
+import (
+	"github.com/xesina/gokit-realworld/http/middleware"
+	"net/http"
+)
+
+func jwksHandlerFunc(keys *middleware.KeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = jsonResponse(w, keys.JWKS(), http.StatusOK)
+	}
+}
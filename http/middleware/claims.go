@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"net/http"
+	"time"
+)
+
+var (
+	PrincipalCtxKey = &contextKey{"Principal"}
+)
+
+var ErrInvalidClaims = errors.New("jwtauth: token has no user")
+
+// Claims are the claims of the access tokens we issue.
+type Claims struct {
+	UserID    int64  `json:"id"`
+	SessionID string `json:"sid,omitempty"`
+	Role      string `json:"role,omitempty"`
+	// Scopes and APIKeyID are only set for requests made with an API key,
+	// which aren't tokens we issue.
+	Scopes   []string `json:"-"`
+	APIKeyID int64    `json:"-"`
+	jwt.StandardClaims
+}
+
+// NewClaims returns claims for a token of the given user and session,
+// with a random ID ("jti") so the token can be revoked on its own.
+func NewClaims(userID int64, sessionID string, ttl time.Duration) (*Claims, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return &Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			Id:        hex.EncodeToString(b),
+			IssuedAt:  EpochNow(),
+			ExpiresAt: ExpireIn(ttl),
+		},
+	}, nil
+}
+
+func (c Claims) Valid() error {
+	if err := c.StandardClaims.Valid(); err != nil {
+		return err
+	}
+	if c.UserID <= 0 {
+		return ErrInvalidClaims
+	}
+	return nil
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID    int64
+	SessionID string
+	TokenID   string
+	// Role is the role the user had when the token was issued.
+	Role string
+	// APIKeyID is set when the request was made with an API key, which limits
+	// it to Scopes.
+	APIKeyID int64
+	Scopes   []string
+}
+
+// HasScope reports whether the principal may act within scope. Only API keys
+// are limited to scopes.
+func (p Principal) HasScope(scope string) bool {
+	if p.APIKeyID == 0 {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalResolver turns a verified token into a Principal stored in the
+// request context, so handlers never have to look at the claims themselves.
+// Requests without a valid token pass through anonymously.
+func PrincipalResolver(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, claims, err := FromContext(r.Context())
+		if err != nil || token == nil || !token.Valid || claims.Valid() != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := NewPrincipalContext(r.Context(), Principal{
+			UserID:    claims.UserID,
+			SessionID: claims.SessionID,
+			TokenID:   claims.Id,
+			Role:      claims.Role,
+			APIKeyID:  claims.APIKeyID,
+			Scopes:    claims.Scopes,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func NewPrincipalContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, PrincipalCtxKey, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(PrincipalCtxKey).(Principal)
+	return p, ok
+}
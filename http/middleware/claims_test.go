@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthenticator_BadClaims(t *testing.T) {
+	ja := New("HS256", []byte("secret"), nil)
+	h := Verifier(ja)(PrincipalResolver(Authenticator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := PrincipalFromContext(r.Context())
+		assert.Equal(t, int64(7), p.UserID)
+		assert.Equal(t, "s1", p.SessionID)
+	}))))
+
+	valid, err := NewClaims(7, "s1", time.Minute)
+	assert.NoError(t, err)
+
+	cases := map[string]struct {
+		claims jwt.Claims
+		code   int
+	}{
+		"valid":      {valid, http.StatusOK},
+		"string id":  {jwt.MapClaims{"id": "7"}, http.StatusUnauthorized},
+		"missing id": {jwt.MapClaims{"sid": "s1"}, http.StatusUnauthorized},
+		"expired":    {jwt.MapClaims{"id": 7, "exp": EpochNow() - 10}, http.StatusUnauthorized},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, tokenString, err := ja.Encode(c.claims)
+			assert.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Token "+tokenString)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, c.code, w.Code)
+		})
+	}
+}
+
+type keys map[string]*Claims
+
+func (k keys) AuthenticateAPIKey(key string) (*Claims, error) {
+	c, ok := k[key]
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	return c, nil
+}
+
+func TestRequireScope_APIKey(t *testing.T) {
+	ja := New("HS256", []byte("secret"), nil).SetAPIKeyAuthenticator("key_", keys{
+		"key_read": {UserID: 7, APIKeyID: 1, Scopes: []string{"articles:read"}},
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	chain := func(h http.Handler) http.Handler {
+		return Verifier(ja)(PrincipalResolver(Authenticator(h)))
+	}
+
+	_, session, err := ja.Encode(&Claims{UserID: 7})
+	assert.NoError(t, err)
+
+	cases := map[string]struct {
+		h      http.Handler
+		header string
+		value  string
+		code   int
+	}{
+		"key in scope":        {RequireScope("articles:read")(ok), "X-API-Key", "key_read", http.StatusOK},
+		"key as token":        {RequireScope("articles:read")(ok), "Authorization", "Token key_read", http.StatusOK},
+		"key out of scope":    {RequireScope("articles:write")(ok), "X-API-Key", "key_read", http.StatusForbidden},
+		"unknown key":         {RequireScope("articles:read")(ok), "X-API-Key", "key_nope", http.StatusUnauthorized},
+		"key on session only": {RequireSession(ok), "X-API-Key", "key_read", http.StatusForbidden},
+		"token has any scope": {RequireScope("articles:write")(ok), "Authorization", "Token " + session, http.StatusOK},
+		"token on session":    {RequireSession(ok), "Authorization", "Token " + session, http.StatusOK},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(c.header, c.value)
+			w := httptest.NewRecorder()
+			chain(c.h).ServeHTTP(w, r)
+
+			assert.Equal(t, c.code, w.Code)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"net/http"
+)
+
+var ErrCSRFToken = errors.New("csrf token is missing or invalid")
+
+// TokenFromCookie returns a finder for Verify that reads the token from the
+// named cookie. Routes it's used on need CSRF protection.
+func TokenFromCookie(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		c, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return c.Value
+	}
+}
+
+// CSRF protects requests carrying any of the session cookies with a double
+// submit token: requests other than GET, HEAD and OPTIONS have to repeat the
+// value of the cookie named cookie in the header named header. Other sites
+// can make browsers send our cookies but can't read them.
+//
+// Requests without session cookies pass, as whatever credentials they carry
+// aren't sent by browsers on their own.
+func CSRF(cookie, header string, sessionCookies ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if safeMethod(r.Method) || !hasCookie(r, sessionCookies...) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			c, err := r.Cookie(cookie)
+			sent := r.Header.Get(header)
+			if err != nil || c.Value == "" || subtle.ConstantTimeCompare([]byte(c.Value), []byte(sent)) != 1 {
+				httpError.EncodeError(
+					r.Context(),
+					httpError.NewError(http.StatusForbidden, ErrCSRFToken),
+					w,
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func safeMethod(m string) bool {
+	return m == http.MethodGet || m == http.MethodHead || m == http.MethodOptions
+}
+
+func hasCookie(r *http.Request, names ...string) bool {
+	for _, name := range names {
+		if _, err := r.Cookie(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCSRF(t *testing.T) {
+	ja := New("HS256", []byte("secret"), nil)
+	h := CSRF("csrf", "X-CSRF-Token", "access")(
+		Verify(ja, TokenFromHeader, TokenFromCookie("access"))(PrincipalResolver(Authenticator(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p, _ := PrincipalFromContext(r.Context())
+				assert.Equal(t, int64(7), p.UserID)
+			}),
+		))),
+	)
+
+	claims, err := NewClaims(7, "s1", time.Minute)
+	assert.NoError(t, err)
+	_, token, err := ja.Encode(claims)
+	assert.NoError(t, err)
+
+	cases := map[string]struct {
+		method string
+		cookie bool
+		csrf   string
+		header string
+		code   int
+	}{
+		"read with cookie":         {http.MethodGet, true, "c1", "", http.StatusOK},
+		"write with cookie":        {http.MethodPost, true, "c1", "c1", http.StatusOK},
+		"write without csrf":       {http.MethodPost, true, "c1", "", http.StatusForbidden},
+		"write with wrong csrf":    {http.MethodDelete, true, "c1", "c2", http.StatusForbidden},
+		"write without csrf token": {http.MethodPut, true, "", "", http.StatusForbidden},
+		"write with header token":  {http.MethodPost, false, "", "", http.StatusOK},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, "/", nil)
+			if c.cookie {
+				r.AddCookie(&http.Cookie{Name: "access", Value: token})
+			} else {
+				r.Header.Set("Authorization", "Token "+token)
+			}
+			if c.csrf != "" {
+				r.AddCookie(&http.Cookie{Name: "csrf", Value: c.csrf})
+			}
+			if c.header != "" {
+				r.Header.Set("X-CSRF-Token", c.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, c.code, w.Code)
+		})
+	}
+}
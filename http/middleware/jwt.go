@@ -3,7 +3,6 @@ package middleware
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	httpError "github.com/xesina/gokit-realworld/http/error"
 	"net/http"
@@ -23,13 +22,25 @@ var (
 	ErrIATInvalid   = errors.New("jwtauth: token iat validation failed")
 	ErrNoTokenFound = errors.New("jwtauth: no token found")
 	ErrAlgoInvalid  = errors.New("jwtauth: algorithm mismatch")
+	ErrRevoked      = errors.New("jwtauth: token is revoked")
 )
 
+// RevocationChecker reports whether any of the given token or session IDs has been revoked.
+type RevocationChecker interface {
+	Revoked(ids ...string) (bool, error)
+}
+
+// APIKeyAuthenticator resolves an API key to the claims of the user it acts for.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (*Claims, error)
+}
+
 type JWTAuth struct {
-	signKey   interface{}
-	verifyKey interface{}
-	signer    jwt.SigningMethod
-	parser    *jwt.Parser
+	keys         *KeySet
+	parser       *jwt.Parser
+	revoked      RevocationChecker
+	apiKeys      APIKeyAuthenticator
+	apiKeyPrefix string
 }
 
 // New creates a JWTAuth authenticator instance that provides middleware handlers
@@ -41,17 +52,47 @@ func New(alg string, signKey interface{}, verifyKey interface{}) *JWTAuth {
 // NewWithParser is the same as New, except it supports custom parser settings
 // introduced in jwt-go/v2.4.0.
 func NewWithParser(alg string, parser *jwt.Parser, signKey interface{}, verifyKey interface{}) *JWTAuth {
+	keys := NewKeySet(0)
+	// An empty set can't hold a duplicate.
+	_ = keys.Add(&Key{
+		Method:    jwt.GetSigningMethod(alg),
+		SignKey:   signKey,
+		VerifyKey: verifyKey,
+	})
+	return NewWithKeySet(keys)
+}
+
+// NewWithKeySet creates a JWTAuth that signs with the current key of keys and
+// verifies with whichever key the token's "kid" header names.
+func NewWithKeySet(keys *KeySet) *JWTAuth {
 	return &JWTAuth{
-		signKey:   signKey,
-		verifyKey: verifyKey,
-		signer:    jwt.GetSigningMethod(alg),
-		parser:    parser,
+		keys:   keys,
+		parser: &jwt.Parser{},
 	}
 }
 
+func (ja *JWTAuth) Keys() *KeySet {
+	return ja.keys
+}
+
+// SetRevocationChecker makes Verify reject tokens whose "jti" or "sid" claim
+// has been revoked.
+func (ja *JWTAuth) SetRevocationChecker(rc RevocationChecker) *JWTAuth {
+	ja.revoked = rc
+	return ja
+}
+
+// SetAPIKeyAuthenticator makes Verify accept API keys, told apart from tokens
+// by prefix, in place of tokens.
+func (ja *JWTAuth) SetAPIKeyAuthenticator(prefix string, a APIKeyAuthenticator) *JWTAuth {
+	ja.apiKeyPrefix = prefix
+	ja.apiKeys = a
+	return ja
+}
+
 func Verifier(ja *JWTAuth) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		return Verify(ja, TokenFromHeader)(next)
+		return Verify(ja, TokenFromHeader, APIKeyFromHeader)(next)
 	}
 }
 
@@ -97,6 +138,16 @@ func VerifyRequest(ja *JWTAuth, r *http.Request, findTokenFns ...func(r *http.Re
 		return nil, nil
 	}
 
+	// An API key stands in for a token with the claims of its user. Like a
+	// revoked token, an unknown key leaves the request anonymous.
+	if ja.apiKeys != nil && strings.HasPrefix(tokenStr, ja.apiKeyPrefix) {
+		claims, err := ja.apiKeys.AuthenticateAPIKey(tokenStr)
+		if err != nil {
+			return nil, err
+		}
+		return &jwt.Token{Raw: tokenStr, Claims: claims, Valid: true}, nil
+	}
+
 	// Verify the token
 	token, err := ja.Decode(tokenStr)
 	if err != nil {
@@ -117,25 +168,55 @@ func VerifyRequest(ja *JWTAuth, r *http.Request, findTokenFns ...func(r *http.Re
 		return token, err
 	}
 
-	// Verify signing algorithm
-	if token.Method != ja.signer {
-		return token, ErrAlgoInvalid
+	// A revoked token is dropped altogether, the same way an expired one is,
+	// so optional auth routes treat the request as anonymous.
+	if ja.revoked != nil {
+		revoked, err := ja.revoked.Revoked(tokenIDs(token)...)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrRevoked
+		}
 	}
 
 	// Valid!
 	return token, nil
 }
 
+func tokenIDs(t *jwt.Token) []string {
+	claims, ok := t.Claims.(*Claims)
+	if !ok {
+		return nil
+	}
+
+	var ids []string
+	for _, id := range []string{claims.Id, claims.SessionID} {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 func (ja *JWTAuth) Encode(claims jwt.Claims) (t *jwt.Token, tokenString string, err error) {
-	t = jwt.New(ja.signer)
+	k, err := ja.keys.signing()
+	if err != nil {
+		return nil, "", err
+	}
+
+	t = jwt.New(k.Method)
+	if k.ID != "" {
+		t.Header["kid"] = k.ID
+	}
 	t.Claims = claims
-	tokenString, err = t.SignedString(ja.signKey)
+	tokenString, err = t.SignedString(k.SignKey)
 	t.Raw = tokenString
 	return
 }
 
 func (ja *JWTAuth) Decode(tokenString string) (t *jwt.Token, err error) {
-	t, err = ja.parser.Parse(tokenString, ja.keyFunc)
+	t, err = ja.parser.ParseWithClaims(tokenString, &Claims{}, ja.keyFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -143,36 +224,91 @@ func (ja *JWTAuth) Decode(tokenString string) (t *jwt.Token, err error) {
 }
 
 func (ja *JWTAuth) keyFunc(t *jwt.Token) (interface{}, error) {
-	if ja.verifyKey != nil {
-		return ja.verifyKey, nil
-	} else {
-		return ja.signKey, nil
+	kid, _ := t.Header["kid"].(string)
+	k, err := ja.keys.lookup(kid)
+	if err != nil {
+		return nil, err
 	}
+
+	// Verify signing algorithm against the key rather than trusting the header
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, ErrAlgoInvalid
+	}
+
+	return k.VerifyKey, nil
 }
 
+// Authenticator rejects requests without a principal; see PrincipalResolver.
 func Authenticator(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token, _, err := FromContext(r.Context())
-		if err != nil {
-			// TODO: log err
+		if _, ok := PrincipalFromContext(r.Context()); !ok {
 			httpError.EncodeError(
 				r.Context(),
-				httpError.NewError(http.StatusInternalServerError, httpError.ErrUnauthorized),
+				httpError.NewError(http.StatusUnauthorized, httpError.ErrUnauthorized),
 				w,
 			)
 			return
 		}
 
-		if token == nil || !token.Valid {
+		// Principal is authenticated, pass it through
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole rejects requests whose principal has none of the given roles.
+// It belongs after Authenticator.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, _ := PrincipalFromContext(r.Context())
+			for _, role := range roles {
+				if p.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
 			httpError.EncodeError(
 				r.Context(),
-				httpError.NewError(http.StatusUnauthorized, httpError.ErrUnauthorized),
+				httpError.NewError(http.StatusForbidden, httpError.ErrForbidden),
+				w,
+			)
+		})
+	}
+}
+
+// RequireScope rejects requests made with an API key that doesn't have
+// scope. It belongs after Authenticator.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p, _ := PrincipalFromContext(r.Context()); !p.HasScope(scope) {
+				httpError.EncodeError(
+					r.Context(),
+					httpError.NewError(http.StatusForbidden, httpError.ErrForbidden),
+					w,
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects requests made with an API key, for routes that
+// manage the account itself. It belongs after Authenticator.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, _ := PrincipalFromContext(r.Context()); p.APIKeyID != 0 {
+			httpError.EncodeError(
+				r.Context(),
+				httpError.NewError(http.StatusForbidden, httpError.ErrForbidden),
 				w,
 			)
 			return
 		}
 
-		// Token is authenticated, pass it through
 		next.ServeHTTP(w, r)
 	})
 }
@@ -187,24 +323,26 @@ func TokenFromHeader(r *http.Request) string {
 	return ""
 }
 
+// APIKeyFromHeader retrieves an API key from the "X-API-Key" request header.
+// Keys are also accepted wherever tokens are.
+func APIKeyFromHeader(r *http.Request) string {
+	return r.Header.Get("X-API-Key")
+}
+
 func NewContext(ctx context.Context, t *jwt.Token, err error) context.Context {
 	ctx = context.WithValue(ctx, TokenCtxKey, t)
 	ctx = context.WithValue(ctx, ErrorCtxKey, err)
 	return ctx
 }
 
-func FromContext(ctx context.Context) (*jwt.Token, jwt.MapClaims, error) {
+func FromContext(ctx context.Context) (*jwt.Token, *Claims, error) {
 	token, _ := ctx.Value(TokenCtxKey).(*jwt.Token)
 
-	var claims jwt.MapClaims
+	claims := &Claims{}
 	if token != nil {
-		if tokenClaims, ok := token.Claims.(jwt.MapClaims); ok {
+		if tokenClaims, ok := token.Claims.(*Claims); ok {
 			claims = tokenClaims
-		} else {
-			panic(fmt.Sprintf("jwtauth: unknown type of Claims: %T", token.Claims))
 		}
-	} else {
-		claims = jwt.MapClaims{}
 	}
 
 	err, _ := ctx.Value(ErrorCtxKey).(error)
@@ -0,0 +1,355 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownKey   = errors.New("jwtauth: unknown signing key")
+	ErrNoSigningKey = errors.New("jwtauth: no signing key")
+	ErrDuplicateKey = errors.New("jwtauth: duplicate key id")
+)
+
+// Key is a single signing or verification key identified by its "kid".
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// SignKey is nil for keys that only verify tokens signed elsewhere.
+	SignKey   interface{}
+	VerifyKey interface{}
+
+	retiredAt time.Time
+}
+
+func (k *Key) retired() bool {
+	return !k.retiredAt.IsZero()
+}
+
+// KeySet holds the keys tokens are signed and verified with. One key signs
+// new tokens; keys rotated out of that role keep verifying for the grace
+// period so tokens they signed stay valid until they expire.
+type KeySet struct {
+	rwlock  sync.RWMutex
+	keys    map[string]*Key
+	order   []string
+	current string
+	grace   time.Duration
+}
+
+// NewKeySet creates an empty key set. grace should be at least the lifetime
+// of the tokens the keys sign.
+func NewKeySet(grace time.Duration) *KeySet {
+	return &KeySet{
+		keys:  map[string]*Key{},
+		grace: grace,
+	}
+}
+
+// Add adds a key to the set. The first key able to sign becomes the
+// signing key; use Rotate to replace it later.
+func (ks *KeySet) Add(k *Key) error {
+	ks.rwlock.Lock()
+	defer ks.rwlock.Unlock()
+
+	return ks.add(k)
+}
+
+// Rotate makes k the signing key. The previous one keeps verifying for the
+// grace period.
+func (ks *KeySet) Rotate(k *Key) error {
+	if k.SignKey == nil {
+		return ErrNoSigningKey
+	}
+
+	ks.rwlock.Lock()
+	defer ks.rwlock.Unlock()
+
+	ks.prune()
+
+	prev, hasPrev := ks.keys[ks.current]
+	if err := ks.add(k); err != nil {
+		return err
+	}
+	if hasPrev {
+		prev.retiredAt = time.Now()
+	}
+	ks.current = k.ID
+
+	return nil
+}
+
+// RotateEvery rotates in a key from newKey at every interval until ctx is
+// done or a key can't be created.
+func (ks *KeySet) RotateEvery(ctx context.Context, every time.Duration, newKey func() (*Key, error)) error {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			k, err := newKey()
+			if err != nil {
+				return err
+			}
+			if err := ks.Rotate(k); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (ks *KeySet) add(k *Key) error {
+	if _, ok := ks.keys[k.ID]; ok {
+		return ErrDuplicateKey
+	}
+
+	if k.VerifyKey == nil {
+		if s, ok := k.SignKey.(crypto.Signer); ok {
+			k.VerifyKey = s.Public()
+		} else {
+			k.VerifyKey = k.SignKey
+		}
+	}
+
+	ks.keys[k.ID] = k
+	ks.order = append(ks.order, k.ID)
+
+	if _, ok := ks.keys[ks.current]; !ok && k.SignKey != nil {
+		ks.current = k.ID
+	}
+
+	return nil
+}
+
+// prune drops retired keys whose tokens have all expired.
+func (ks *KeySet) prune() {
+	order := ks.order[:0]
+	for _, id := range ks.order {
+		if ks.expired(ks.keys[id]) {
+			delete(ks.keys, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	ks.order = order
+}
+
+func (ks *KeySet) expired(k *Key) bool {
+	return k.retired() && time.Since(k.retiredAt) > ks.grace
+}
+
+func (ks *KeySet) signing() (*Key, error) {
+	ks.rwlock.RLock()
+	defer ks.rwlock.RUnlock()
+
+	k, ok := ks.keys[ks.current]
+	if !ok {
+		return nil, ErrNoSigningKey
+	}
+	return k, nil
+}
+
+func (ks *KeySet) lookup(id string) (*Key, error) {
+	ks.rwlock.RLock()
+	defer ks.rwlock.RUnlock()
+
+	k, ok := ks.keys[id]
+	if !ok || ks.expired(k) {
+		return nil, ErrUnknownKey
+	}
+	return k, nil
+}
+
+// JWK is the public part of a key as described in RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that tokens may currently be verified with.
+// Symmetric keys are secret and never published.
+func (ks *KeySet) JWKS() JWKS {
+	ks.rwlock.RLock()
+	defer ks.rwlock.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(ks.order))}
+	for _, id := range ks.order {
+		k := ks.keys[id]
+		if ks.expired(k) {
+			continue
+		}
+		if jwk, ok := publicJWK(k); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+func publicJWK(k *Key) (JWK, bool) {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+	enc := base64.RawURLEncoding
+
+	switch pub := k.VerifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc.EncodeToString(pub.N.Bytes())
+		jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = enc.EncodeToString(pad(pub.X.Bytes(), size))
+		jwk.Y = enc.EncodeToString(pad(pub.Y.Bytes(), size))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc.EncodeToString(pub)
+	default:
+		return jwk, false
+	}
+	return jwk, true
+}
+
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
+
+// GenerateKey creates a key with a random ID for one of RS256, ES256 or EdDSA.
+func GenerateKey(alg string) (*Key, error) {
+	var (
+		priv crypto.Signer
+		err  error
+	)
+
+	switch alg {
+	case "RS256":
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("jwtauth: can't generate %s keys", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:      hex.EncodeToString(id),
+		Method:  jwt.GetSigningMethod(alg),
+		SignKey: priv,
+	}, nil
+}
+
+// LoadKey reads a PEM encoded private or public key from path. The file
+// name without its extension becomes the key ID.
+func LoadKey(path string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return ParseKeyPEM(id, data)
+}
+
+// ParseKeyPEM parses a PEM encoded RSA, ECDSA or Ed25519 key. Private keys
+// can sign and verify; public keys only verify. The algorithm is derived
+// from the key type.
+func ParseKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwtauth: no PEM data found")
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwtauth: unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Key{ID: id}
+	if s, ok := key.(crypto.Signer); ok {
+		k.SignKey = s
+		key = s.Public()
+	}
+	k.VerifyKey = key
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		k.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			k.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			k.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			k.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("jwtauth: unsupported curve")
+		}
+	case ed25519.PublicKey:
+		k.Method = SigningMethodEd25519
+	default:
+		return nil, fmt.Errorf("jwtauth: unsupported key type %T", key)
+	}
+
+	return k, nil
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestKeySet_RotateKeepsVerifyingOldTokens(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			keys := NewKeySet(time.Minute)
+			first, err := GenerateKey(alg)
+			assert.NoError(t, err)
+			assert.NoError(t, keys.Add(first))
+			ja := NewWithKeySet(keys)
+
+			_, old, err := ja.Encode(jwt.MapClaims{"id": 1})
+			assert.NoError(t, err)
+
+			second, err := GenerateKey(alg)
+			assert.NoError(t, err)
+			assert.NoError(t, keys.Rotate(second))
+
+			tok, err := ja.Decode(old)
+			assert.NoError(t, err)
+			assert.Equal(t, first.ID, tok.Header["kid"])
+
+			_, fresh, err := ja.Encode(jwt.MapClaims{"id": 1})
+			assert.NoError(t, err)
+			tok, err = ja.Decode(fresh)
+			assert.NoError(t, err)
+			assert.Equal(t, second.ID, tok.Header["kid"])
+
+			assert.Len(t, keys.JWKS().Keys, 2)
+		})
+	}
+}
+
+func TestKeySet_RetiredKeyExpires(t *testing.T) {
+	keys := NewKeySet(0)
+	first, _ := GenerateKey("EdDSA")
+	assert.NoError(t, keys.Add(first))
+	ja := NewWithKeySet(keys)
+
+	_, old, err := ja.Encode(jwt.MapClaims{"id": 1})
+	assert.NoError(t, err)
+
+	second, _ := GenerateKey("EdDSA")
+	assert.NoError(t, keys.Rotate(second))
+	time.Sleep(time.Millisecond)
+
+	_, err = ja.Decode(old)
+	assert.Error(t, err)
+	assert.Len(t, keys.JWKS().Keys, 1)
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	const pub = `-----BEGIN PUBLIC KEY-----
+MCowBQYDK2VwAyEAGb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE=
+-----END PUBLIC KEY-----`
+
+	k, err := ParseKeyPEM("ed", []byte(pub))
+	assert.NoError(t, err)
+	assert.Equal(t, "EdDSA", k.Method.Alg())
+	assert.Nil(t, k.SignKey)
+	assert.Equal(t, "OKP", keysetOf(k).JWKS().Keys[0].Kty)
+}
+
+func keysetOf(k *Key) *KeySet {
+	keys := NewKeySet(0)
+	_ = keys.Add(k)
+	return keys
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/user"
+	"io"
+	"net/http"
+)
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+func (req *forgotPasswordRequest) bind(r io.Reader) error {
+	if e := json.NewDecoder(r).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (req *forgotPasswordRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.Email, validation.Required, is.Email),
+	)
+}
+
+func (req *forgotPasswordRequest) endpointRequest() user.ForgotPasswordRequest {
+	return user.ForgotPasswordRequest{
+		Email: req.Email,
+	}
+}
+
+func (h UserHandler) decodeForgotPasswordRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req forgotPasswordRequest
+	if err := req.bind(r.Body); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (req *resetPasswordRequest) bind(r io.Reader) error {
+	if e := json.NewDecoder(r).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (req *resetPasswordRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.Token, validation.Required),
+		validation.Field(&req.Password, validation.Required, validation.Length(6, 50)),
+	)
+}
+
+func (req *resetPasswordRequest) endpointRequest() user.ResetPasswordRequest {
+	return user.ResetPasswordRequest{
+		Token:    req.Token,
+		Password: req.Password,
+	}
+}
+
+func (h UserHandler) decodeResetPasswordRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req resetPasswordRequest
+	if err := req.bind(r.Body); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/http/middleware"
+	"net"
+	"net/http"
+)
+
+// userID returns the ID of the authenticated user making the request.
+func userID(r *http.Request) (int64, error) {
+	p, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		return 0, httpError.NewError(http.StatusUnauthorized, httpError.ErrUnauthorized)
+	}
+	return p.UserID, nil
+}
+
+// viewerID is like userID for routes that are public, where it's zero for
+// anonymous requests.
+func viewerID(r *http.Request) int64 {
+	p, _ := middleware.PrincipalFromContext(r.Context())
+	return p.UserID
+}
+
+// clientIP returns the address the request came from. Behind a proxy that is
+// the proxy's, unless RemoteAddr was rewritten from a header it sets.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
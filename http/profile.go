@@ -7,9 +7,10 @@ import (
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	realworld "github.com/xesina/gokit-realworld"
 	httpError "github.com/xesina/gokit-realworld/http/error"
-	"github.com/xesina/gokit-realworld/http/middleware"
 	"github.com/xesina/gokit-realworld/user"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
 type profileRequest struct {
@@ -18,18 +19,7 @@ type profileRequest struct {
 }
 
 func (req *profileRequest) bind(r *http.Request) error {
-	// TODO: handle unexpected errors
-	// TODO: move this to a middleware so inject the ID to the context directly
-
-	tk, claims, err := middleware.FromContext(r.Context())
-	if err != nil {
-		return err
-	}
-
-	if tk != nil {
-		viewerID := claims["id"].(float64)
-		req.viewerID = int64(viewerID)
-	}
+	req.viewerID = viewerID(r)
 
 	username := chi.URLParam(r, "username")
 	req.username = username
@@ -61,6 +51,34 @@ type profile struct {
 	Bio       realworld.Bio   `json:"bio"`
 	Image     realworld.Image `json:"image"`
 	Following bool            `json:"following"`
+	Blocking  bool            `json:"blocking,omitempty"`
+	Muting    bool            `json:"muting,omitempty"`
+	*profileStats
+}
+
+type profileStats struct {
+	FollowersCount int `json:"followersCount"`
+	FollowingCount int `json:"followingCount"`
+	ArticlesCount  int `json:"articlesCount"`
+}
+
+func newProfile(u *user.ProfileResponse) profile {
+	p := profile{
+		Username:  u.Username,
+		Bio:       u.Bio,
+		Image:     u.Image,
+		Following: u.Following,
+		Blocking:  u.Blocking,
+		Muting:    u.Muting,
+	}
+	if u.Stats != nil {
+		p.profileStats = &profileStats{
+			FollowersCount: u.Stats.Followers,
+			FollowingCount: u.Stats.Following,
+			ArticlesCount:  u.Stats.Articles,
+		}
+	}
+	return p
 }
 
 type profileResponse struct {
@@ -68,14 +86,7 @@ type profileResponse struct {
 }
 
 func newProfileResponse(u *user.ProfileResponse) profileResponse {
-	return profileResponse{
-		Profile: profile{
-			Username:  u.Username,
-			Bio:       u.Bio,
-			Image:     u.Image,
-			Following: u.Following,
-		},
-	}
+	return profileResponse{Profile: newProfile(u)}
 }
 
 func (h UserHandler) decodeProfileRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
@@ -97,5 +108,91 @@ func (h UserHandler) encodeProfileResponse(ctx context.Context, w http.ResponseW
 
 	hresp := newProfileResponse(&e)
 
+	// An old username redirects to the profile under the current one, which
+	// is relative to it; "./" keeps a colon in it from reading as a scheme.
+	// The redirect isn't permanent since the old one can be taken once its
+	// reservation ends. Clients that don't follow it get the profile all
+	// the same.
+	if e.Renamed {
+		w.Header().Set("Location", "./"+url.PathEscape(e.Username))
+		return jsonResponse(w, hresp, http.StatusFound)
+	}
+
 	return jsonResponse(w, hresp, http.StatusOK)
 }
+
+type followListRequest struct {
+	username string
+	viewerID int64
+	limit    int
+	offset   int
+}
+
+func (req *followListRequest) bind(r *http.Request) error {
+	req.viewerID = viewerID(r)
+	req.username = chi.URLParam(r, "username")
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+	req.limit = limit
+
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil {
+		offset = 0
+	}
+	req.offset = offset
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *followListRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.username, validation.Required, validation.Length(4, 50)),
+		validation.Field(&req.limit, validation.Min(1), validation.Max(100)),
+		validation.Field(&req.offset, validation.Min(0)),
+	)
+}
+
+func (req *followListRequest) endpointRequest() user.FollowListRequest {
+	return user.FollowListRequest{
+		Username: req.username,
+		ViewerID: req.viewerID,
+		Limit:    req.limit,
+		Offset:   req.offset,
+	}
+}
+
+func (h UserHandler) decodeFollowListRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req followListRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type profilesResponse struct {
+	Profiles      []profile `json:"profiles"`
+	ProfilesCount int       `json:"profilesCount"`
+}
+
+func (h UserHandler) encodeProfilesResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := response.(user.ProfilesResponse)
+	resp := profilesResponse{Profiles: make([]profile, 0, len(e.Profiles)), ProfilesCount: e.Count}
+	for i := range e.Profiles {
+		resp.Profiles = append(resp.Profiles, newProfile(&e.Profiles[i]))
+	}
+	return jsonResponse(w, resp, http.StatusOK)
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"github.com/go-chi/chi"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xesina/gokit-realworld/article"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"net/http"
+	"strconv"
+)
+
+type reactionRequest struct {
+	userID int64
+	slug   string
+	kind   string
+}
+
+func (req *reactionRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.slug = chi.URLParam(r, "slug")
+	req.kind = chi.URLParam(r, "reaction")
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *reactionRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.slug, validation.Required),
+		validation.Field(&req.kind, validation.Required),
+	)
+}
+
+func (req *reactionRequest) endpointRequest() article.ReactionRequest {
+	return article.ReactionRequest{
+		UserID: req.userID,
+		Slug:   req.slug,
+		Kind:   req.kind,
+	}
+}
+
+func (h ArticleHandler) decodeReactionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req reactionRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type commentReactionRequest struct {
+	userID    int64
+	slug      string
+	commentID int64
+	kind      string
+}
+
+func (req *commentReactionRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.slug = chi.URLParam(r, "slug")
+	req.kind = chi.URLParam(r, "reaction")
+
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+	req.commentID = commentID
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *commentReactionRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.slug, validation.Required),
+		validation.Field(&req.kind, validation.Required),
+	)
+}
+
+func (req *commentReactionRequest) endpointRequest() article.CommentReactionRequest {
+	return article.CommentReactionRequest{
+		UserID:    req.userID,
+		Slug:      req.slug,
+		CommentID: req.commentID,
+		Kind:      req.kind,
+	}
+}
+
+func (h ArticleHandler) decodeCommentReactionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req commentReactionRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
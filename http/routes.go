@@ -2,6 +2,7 @@ package http
 
 import (
 	"github.com/go-chi/chi"
+	realworld "github.com/xesina/gokit-realworld"
 	"github.com/xesina/gokit-realworld/http/middleware"
 )
 
@@ -9,30 +10,82 @@ func RegisterRoutes(c Context, r *chi.Mux) {
 	uh := NewUserHandler(c)
 	ah := NewArticleHandler(c)
 
+	// Lets other services verify the tokens we issue
+	r.Get("/.well-known/jwks.json", jwksHandlerFunc(c.jwt.Keys()))
+
+	// What users uploaded, for the URLs c.media hands out
+	r.Get("/media/*", mediaHandlerFunc(c.media))
+
 	api := r.Route("/api", nil)
 
 	// Always parse token if available
-	api.Use(middleware.Verifier(c.jwt))
+	if c.sessionCookies.Enabled {
+		api.Use(middleware.CSRF(csrfCookie, csrfHeader, accessTokenCookie, refreshTokenCookie))
+		api.Use(middleware.Verify(c.jwt, middleware.TokenFromHeader, middleware.APIKeyFromHeader, middleware.TokenFromCookie(accessTokenCookie)))
+	} else {
+		api.Use(middleware.Verifier(c.jwt))
+	}
+	api.Use(middleware.PrincipalResolver)
 
 	api.Route("/users", func(r chi.Router) {
 		r.Post("/", uh.registerHandlerFunc())
 		r.Post("/login", uh.loginHandlerFunc())
+		r.Post("/login/2fa", uh.loginTwoFactorHandlerFunc())
+		r.Post("/logout", uh.logoutHandlerFunc())
+		r.Post("/token/refresh", uh.refreshHandlerFunc())
+		r.Post("/password/forgot", uh.forgotPasswordHandlerFunc())
+		r.Post("/password/reset", uh.resetPasswordHandlerFunc())
+		r.Get("/verify", uh.verifyEmailHandlerFunc())
+		r.With(middleware.Authenticator, middleware.RequireSession).Post("/verify/resend", uh.resendVerificationHandlerFunc())
+
+		// logging in with an identity provider
+		r.Get("/oauth", uh.providersHandlerFunc())
+		r.Get("/oauth/{provider}", uh.loginRedirectHandlerFunc())
+		r.Get("/oauth/{provider}/callback", uh.oauthCallbackHandlerFunc())
 	})
 
 	api.Route("/user", func(r chi.Router) {
 		r.Use(middleware.Authenticator)
-		r.Get("/", uh.getHandlerFunc())
-		r.Put("/", uh.updateHandlerFunc())
+		r.With(middleware.RequireScope(realworld.ScopeProfileRead)).Get("/", uh.getHandlerFunc())
+		r.With(middleware.RequireScope(realworld.ScopeArticlesRead)).Get("/bookmarks", ah.bookmarksHandlerFunc())
+
+		// the account itself can't be managed with an API key
+		session := r.With(middleware.RequireSession)
+		session.Put("/", uh.updateHandlerFunc())
+		session.Patch("/", uh.patchHandlerFunc())
+		session.Post("/image", uh.setAvatarHandlerFunc())
+		session.Delete("/image", uh.removeAvatarHandlerFunc())
+		session.Delete("/", uh.deleteAccountHandlerFunc())
+		session.Get("/export", uh.exportAccountHandlerFunc())
+		session.Get("/sessions", uh.sessionsHandlerFunc())
+		session.Delete("/sessions/{id}", uh.revokeSessionHandlerFunc())
+		session.Post("/2fa/enroll", uh.enrollTwoFactorHandlerFunc())
+		session.Post("/2fa/confirm", uh.confirmTwoFactorHandlerFunc())
+		session.Post("/2fa/disable", uh.disableTwoFactorHandlerFunc())
+		session.Get("/api-keys", uh.apiKeysHandlerFunc())
+		session.Post("/api-keys", uh.createAPIKeyHandlerFunc())
+		session.Delete("/api-keys/{id}", uh.revokeAPIKeyHandlerFunc())
+		session.Get("/identities", uh.identitiesHandlerFunc())
+		session.Post("/identities/{provider}", uh.linkIdentityHandlerFunc())
+		session.Delete("/identities/{provider}", uh.unlinkIdentityHandlerFunc())
 	})
 
 	api.Route("/profiles", func(r chi.Router) {
 		// public
+		r.Get("/", uh.searchHandlerFunc())
+		r.Get("/autocomplete", uh.autocompleteHandlerFunc())
 		r.Get("/{username}", uh.profileHandlerFunc())
+		r.Get("/{username}/followers", uh.followersHandlerFunc())
+		r.Get("/{username}/following", uh.followingHandlerFunc())
 
 		// auth required
-		auth := r.With(middleware.Authenticator)
+		auth := r.With(middleware.Authenticator, middleware.RequireScope(realworld.ScopeProfileWrite))
 		auth.Post("/{username}/follow", uh.followHandlerFunc())
 		auth.Delete("/{username}/follow", uh.unfollowHandlerFunc())
+		auth.Post("/{username}/block", uh.restrictHandlerFunc(realworld.RestrictionBlock))
+		auth.Delete("/{username}/block", uh.unrestrictHandlerFunc(realworld.RestrictionBlock))
+		auth.Post("/{username}/mute", uh.restrictHandlerFunc(realworld.RestrictionMute))
+		auth.Delete("/{username}/mute", uh.unrestrictHandlerFunc(realworld.RestrictionMute))
 	})
 
 	api.Route("/articles", func(r chi.Router) {
@@ -43,18 +96,52 @@ func RegisterRoutes(c Context, r *chi.Mux) {
 
 		r.Get("/{slug}/comments", ah.commentsHandlerFunc())
 
+		r.Get("/{slug}/attachments", ah.attachmentsHandlerFunc())
+
 		// auth required
 		auth := r.With(middleware.Authenticator)
 
-		auth.Post("/", ah.createHandlerFunc())
-		auth.Get("/feed", ah.feedHandlerFunc())
-		auth.Put("/{slug}", ah.updateHandlerFunc())
-		auth.Delete("/{slug}", ah.deleteHandlerFunc())
-		auth.Post("/{slug}/comments", ah.addCommentHandlerFunc())
-		auth.Delete("/{slug}/comments/{id}", ah.deleteCommentHandlerFunc())
-		auth.Post("/{slug}/favorite", ah.favoriteHandlerFunc())
-		auth.Delete("/{slug}/favorite", ah.unfavoriteHandlerFunc())
+		articlesRead := auth.With(middleware.RequireScope(realworld.ScopeArticlesRead))
+		articlesRead.Get("/feed", ah.feedHandlerFunc())
+
+		articlesWrite := auth.With(middleware.RequireScope(realworld.ScopeArticlesWrite))
+		articlesWrite.Post("/", ah.createHandlerFunc())
+		articlesWrite.Put("/{slug}", ah.updateHandlerFunc())
+		articlesWrite.Delete("/{slug}", ah.deleteHandlerFunc())
+		articlesWrite.Post("/{slug}/favorite", ah.favoriteHandlerFunc())
+		articlesWrite.Delete("/{slug}/favorite", ah.unfavoriteHandlerFunc())
+		articlesWrite.Post("/{slug}/bookmark", ah.bookmarkHandlerFunc())
+		articlesWrite.Delete("/{slug}/bookmark", ah.unbookmarkHandlerFunc())
+		articlesWrite.Post("/{slug}/reactions/{reaction}", ah.reactHandlerFunc())
+		articlesWrite.Delete("/{slug}/reactions/{reaction}", ah.unreactHandlerFunc())
+		articlesWrite.Post("/{slug}/attachments", ah.attachHandlerFunc())
+		articlesWrite.Delete("/{slug}/attachments/{id}", ah.detachHandlerFunc())
+
+		commentsRead := auth.With(middleware.RequireScope(realworld.ScopeCommentsRead))
+		commentsRead.Get("/{slug}/comments/{id}/history", ah.commentHistoryHandlerFunc())
+
+		commentsWrite := auth.With(middleware.RequireScope(realworld.ScopeCommentsWrite))
+		commentsWrite.Post("/{slug}/comments", ah.addCommentHandlerFunc())
+		commentsWrite.Put("/{slug}/comments/{id}", ah.updateCommentHandlerFunc())
+		commentsWrite.Delete("/{slug}/comments/{id}", ah.deleteCommentHandlerFunc())
+		commentsWrite.Post("/{slug}/comments/{id}/reactions/{reaction}", ah.commentReactHandlerFunc())
+		commentsWrite.Delete("/{slug}/comments/{id}/reactions/{reaction}", ah.commentUnreactHandlerFunc())
+	})
+
+	api.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.Authenticator, middleware.RequireSession)
+
+		// users are managed by admins
+		admin := r.With(middleware.RequireRole(realworld.RoleAdmin))
+		admin.Delete("/users/{username}/lockout", uh.unlockLoginHandlerFunc())
+		admin.Put("/users/{username}/role", uh.setRoleHandlerFunc())
+		admin.Post("/users/{username}/ban", uh.banHandlerFunc())
+		admin.Delete("/users/{username}/ban", uh.unbanHandlerFunc())
 
+		// content can be taken down by moderators too
+		mod := r.With(middleware.RequireRole(realworld.RoleModerator, realworld.RoleAdmin))
+		mod.Delete("/articles/{slug}", ah.removeHandlerFunc())
+		mod.Delete("/articles/{slug}/comments/{id}", ah.removeCommentHandlerFunc())
 	})
 
 	api.Get("/tags", ah.tagsHandler())
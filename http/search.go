@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/user"
+	"net/http"
+	"strconv"
+)
+
+type searchRequest struct {
+	q        string
+	viewerID int64
+	limit    int
+	offset   int
+}
+
+func (req *searchRequest) bind(r *http.Request) error {
+	req.viewerID = viewerID(r)
+	req.q = r.URL.Query().Get("q")
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+	req.limit = limit
+
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil {
+		offset = 0
+	}
+	req.offset = offset
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *searchRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.q, validation.Required, validation.Length(1, 50)),
+		validation.Field(&req.limit, validation.Min(1), validation.Max(100)),
+		validation.Field(&req.offset, validation.Min(0)),
+	)
+}
+
+func (req *searchRequest) endpointRequest() realworld.UserSearchRequest {
+	return realworld.UserSearchRequest{
+		Query:    req.q,
+		ViewerID: req.viewerID,
+		Limit:    req.limit,
+		Offset:   req.offset,
+	}
+}
+
+func (h UserHandler) decodeSearchRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req searchRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type autocompleteRequest struct {
+	q        string
+	viewerID int64
+	limit    int
+}
+
+func (req *autocompleteRequest) bind(r *http.Request) error {
+	req.viewerID = viewerID(r)
+	req.q = r.URL.Query().Get("q")
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 10
+	}
+	req.limit = limit
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *autocompleteRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.q, validation.Required, validation.Length(1, 50)),
+		validation.Field(&req.limit, validation.Min(1), validation.Max(20)),
+	)
+}
+
+func (req *autocompleteRequest) endpointRequest() user.AutocompleteRequest {
+	return user.AutocompleteRequest{
+		Prefix:   req.q,
+		ViewerID: req.viewerID,
+		Limit:    req.limit,
+	}
+}
+
+func (h UserHandler) decodeAutocompleteRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req autocompleteRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
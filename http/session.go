@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"github.com/go-chi/chi"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/http/middleware"
+	"github.com/xesina/gokit-realworld/user"
+	"net/http"
+	"time"
+)
+
+type sessionsRequest struct {
+	userID  int64
+	current string
+}
+
+func (req *sessionsRequest) bind(r *http.Request) error {
+	p, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		return httpError.NewError(http.StatusUnauthorized, httpError.ErrUnauthorized)
+	}
+	req.userID = p.UserID
+	req.current = p.SessionID
+
+	return nil
+}
+
+func (req *sessionsRequest) endpointRequest() user.SessionsRequest {
+	return user.SessionsRequest{
+		UserID:  req.userID,
+		Current: req.current,
+	}
+}
+
+func (h UserHandler) decodeSessionsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req sessionsRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type session struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+type sessionsResponse struct {
+	Sessions []session `json:"sessions"`
+}
+
+func newSessionsResponse(r user.SessionsResponse) sessionsResponse {
+	sessions := make([]session, 0, len(r.Sessions))
+	for _, s := range r.Sessions {
+		sessions = append(sessions, session{
+			ID:         s.ID,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.Current,
+		})
+	}
+	return sessionsResponse{Sessions: sessions}
+}
+
+func (h UserHandler) encodeSessionsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+
+	e := response.(user.SessionsResponse)
+	return jsonResponse(w, newSessionsResponse(e), http.StatusOK)
+}
+
+type revokeSessionRequest struct {
+	userID int64
+	id     string
+}
+
+func (req *revokeSessionRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	req.id = chi.URLParam(r, "id")
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *revokeSessionRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.id, validation.Required),
+	)
+}
+
+func (req *revokeSessionRequest) endpointRequest() user.RevokeSessionRequest {
+	return user.RevokeSessionRequest{
+		UserID: req.userID,
+		ID:     req.id,
+	}
+}
+
+func (h UserHandler) decodeRevokeSessionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req revokeSessionRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
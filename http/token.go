@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/user"
+	"io"
+	"net/http"
+)
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (req *refreshRequest) bind(r io.Reader) error {
+	if e := json.NewDecoder(r).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (req *refreshRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.RefreshToken, validation.Required),
+	)
+}
+
+func (req *refreshRequest) endpointRequest() user.RefreshRequest {
+	return user.RefreshRequest{
+		RefreshToken: req.RefreshToken,
+	}
+}
+
+func (h UserHandler) decodeRefreshRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req refreshRequest
+
+	// Browsers in the cookie session mode don't know their refresh token.
+	if c, err := r.Cookie(refreshTokenCookie); h.cookies.Enabled && err == nil {
+		req.RefreshToken = c.Value
+		return req.endpointRequest(), nil
+	}
+
+	if err := req.bind(r.Body); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+// encodeLogoutResponse also clears the cookies of the cookie session mode.
+func (h UserHandler) encodeLogoutResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+	if h.cookies.Enabled {
+		h.clearSessionCookies(w)
+	}
+	return jsonResponse(w, nil, http.StatusOK)
+}
+
+func (h UserHandler) encodeEmptyResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+	return jsonResponse(w, nil, http.StatusOK)
+}
@@ -14,7 +14,20 @@ import (
 	"os"
 )
 
-func MakeHTTPHandler(userSrv realworld.UserService, articleSrv realworld.ArticleService) http.Handler {
+func MakeHTTPHandler(
+	userSrv realworld.UserService,
+	articleSrv realworld.ArticleService,
+	tokenSrv realworld.RefreshTokenService,
+	sessionSrv realworld.SessionService,
+	apiKeySrv realworld.APIKeyService,
+	identitySrv realworld.IdentityService,
+	accountSrv realworld.AccountService,
+	avatarSrv realworld.AvatarService,
+	attachmentSrv realworld.AttachmentService,
+	media realworld.BlobStorage,
+	keys *middleware.KeySet,
+	cookies SessionCookies,
+) http.Handler {
 	var logger log.Logger
 	{
 		logger = log.NewLogfmtLogger(os.Stderr)
@@ -27,24 +40,42 @@ func MakeHTTPHandler(userSrv realworld.UserService, articleSrv realworld.Article
 		transport.ServerErrorEncoder(httpError.EncodeError),
 	}
 
-	tokenAuth := middleware.New("HS256", []byte("secret"), nil)
+	tokenAuth := middleware.NewWithKeySet(keys).
+		SetRevocationChecker(sessionSrv).
+		SetAPIKeyAuthenticator(realworld.APIKeyPrefix, apiKeyAuthenticator{apiKeySrv})
 
-	r := chi.NewRouter()
-	r.Use(cors.Handler(cors.Options{
+	corsOptions := cors.Options{
 		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"HEAD", "GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedMethods: []string{"HEAD", "GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key"},
 		ExposedHeaders: []string{"Link"},
-	}))
+	}
+	// Browsers only send cookies cross-origin to origins named explicitly.
+	if cookies.Enabled && len(cookies.AllowedOrigins) > 0 {
+		corsOptions.AllowedOrigins = cookies.AllowedOrigins
+		corsOptions.AllowCredentials = true
+	}
+
+	r := chi.NewRouter()
+	r.Use(cors.Handler(corsOptions))
 
 	r.Use(chimiddleware.Logger)
 
 	c := Context{
-		router:         r,
-		jwt:            tokenAuth,
-		serverOptions:  options,
-		userService:    userSrv,
-		articleService: articleSrv,
+		router:            r,
+		jwt:               tokenAuth,
+		serverOptions:     options,
+		userService:       userSrv,
+		articleService:    articleSrv,
+		tokenService:      tokenSrv,
+		sessionService:    sessionSrv,
+		apiKeyService:     apiKeySrv,
+		identityService:   identitySrv,
+		accountService:    accountSrv,
+		avatarService:     avatarSrv,
+		attachmentService: attachmentSrv,
+		media:             media,
+		sessionCookies:    cookies,
 	}
 
 	RegisterRoutes(c, r)
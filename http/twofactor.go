@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	httpError "github.com/xesina/gokit-realworld/http/error"
+	"github.com/xesina/gokit-realworld/user"
+	"io"
+	"net/http"
+)
+
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	Challenge         string `json:"challenge"`
+}
+
+// encodeLoginResponse answers a password login with a challenge instead of
+// tokens when the account has two-factor authentication enabled.
+func (h UserHandler) encodeLoginResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(user.Response); ok && e.Err == nil && e.Challenge != "" {
+		return jsonResponse(w, twoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			Challenge:         e.Challenge,
+		}, http.StatusOK)
+	}
+	return h.encodeUserResponse(ctx, w, response)
+}
+
+type loginTwoFactorRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+func (req *loginTwoFactorRequest) bind(r io.Reader) error {
+	if e := json.NewDecoder(r).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (req *loginTwoFactorRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.Challenge, validation.Required),
+		validation.Field(&req.Code, validation.Required),
+	)
+}
+
+func (req *loginTwoFactorRequest) endpointRequest() user.LoginTwoFactorRequest {
+	return user.LoginTwoFactorRequest{
+		Challenge: req.Challenge,
+		Code:      req.Code,
+	}
+}
+
+func (h UserHandler) decodeLoginTwoFactorRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req loginTwoFactorRequest
+	if err := req.bind(r.Body); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	er.ClientIP = clientIP(r)
+	return er, nil
+}
+
+func (h UserHandler) decodeEnrollTwoFactorRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+	return user.EnrollTwoFactorRequest{UserID: id}, nil
+}
+
+type enrollTwoFactorResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+func (h UserHandler) encodeEnrollTwoFactorResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+	e := response.(user.EnrollTwoFactorResponse)
+	return jsonResponse(w, enrollTwoFactorResponse{Secret: e.Secret, URI: e.URI}, http.StatusOK)
+}
+
+type twoFactorCodeRequest struct {
+	userID int64
+	Code   string `json:"code"`
+}
+
+func (req *twoFactorCodeRequest) bind(r *http.Request) error {
+	id, err := userID(r)
+	if err != nil {
+		return err
+	}
+	req.userID = id
+
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *twoFactorCodeRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.Code, validation.Required),
+	)
+}
+
+func (req *twoFactorCodeRequest) endpointRequest() user.TwoFactorCodeRequest {
+	return user.TwoFactorCodeRequest{
+		UserID: req.userID,
+		Code:   req.Code,
+	}
+}
+
+func (h UserHandler) decodeTwoFactorCodeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req twoFactorCodeRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func (h UserHandler) encodeRecoveryCodesResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if resp, ok := response.(endpoint.Failer); ok && resp.Failed() != nil {
+		httpError.EncodeError(ctx, resp.Failed(), w)
+		return nil
+	}
+	e := response.(user.RecoveryCodesResponse)
+	return jsonResponse(w, recoveryCodesResponse{RecoveryCodes: e.RecoveryCodes}, http.StatusOK)
+}
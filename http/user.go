@@ -3,7 +3,6 @@ package http
 import (
 	"context"
 	"encoding/json"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/go-kit/kit/endpoint"
 	transport "github.com/go-kit/kit/transport/http"
 	"github.com/go-ozzo/ozzo-validation/v4"
@@ -17,8 +16,20 @@ import (
 	"time"
 )
 
+// AccessTokenTTL is kept short so a token revoked by ID only needs to be
+// remembered briefly; clients renew them with the refresh token. Signing keys
+// rotated out should keep verifying for at least this long.
+const AccessTokenTTL = time.Minute * 15
+
 type UserHandler struct {
 	service       realworld.UserService
+	tokenService  realworld.RefreshTokenService
+	sessions      realworld.SessionService
+	apiKeys       realworld.APIKeyService
+	identities    realworld.IdentityService
+	accounts      realworld.AccountService
+	avatars       realworld.AvatarService
+	cookies       SessionCookies
 	jwt           *middleware.JWTAuth
 	serverOptions []transport.ServerOption
 }
@@ -26,6 +37,13 @@ type UserHandler struct {
 func NewUserHandler(c Context) UserHandler {
 	return UserHandler{
 		service:       c.userService,
+		tokenService:  c.tokenService,
+		sessions:      c.sessionService,
+		apiKeys:       c.apiKeyService,
+		identities:    c.identityService,
+		accounts:      c.accountService,
+		avatars:       c.avatarService,
+		cookies:       c.sessionCookies,
 		jwt:           c.jwt,
 		serverOptions: c.serverOptions,
 	}
@@ -50,24 +68,44 @@ func (h UserHandler) encodeRegisterResponse(ctx context.Context, w http.Response
 
 	hresp := newUserResponse(&e)
 
-	claims := jwt.MapClaims{
-		"id": e.ID,
-	}
-
-	middleware.SetIssuedNow(claims)
-	middleware.SetExpiryIn(claims, time.Hour*24*5)
-
-	_, tokenString, err := h.jwt.Encode(claims)
-
+	tokenString, err := h.accessToken(ctx, e)
 	if err != nil {
 		return err
 	}
 
 	hresp.User.Token = tokenString
+	hresp.User.RefreshToken = e.RefreshToken
+
+	if err := h.moveTokensToCookies(w, &hresp.User); err != nil {
+		return err
+	}
 
 	return jsonResponse(w, hresp, http.StatusCreated)
 }
 
+// accessToken mints a token for the user in e. It belongs to the session in e
+// or, when no new session was started, to the one the request was made with.
+// Requests made with an API key get none, as it would escape the key's scopes.
+func (h UserHandler) accessToken(ctx context.Context, e user.Response) (string, error) {
+	sid := e.SessionID
+	if sid == "" {
+		current, _ := middleware.PrincipalFromContext(ctx)
+		if current.APIKeyID != 0 {
+			return "", nil
+		}
+		sid = current.SessionID
+	}
+
+	claims, err := middleware.NewClaims(e.ID, sid, AccessTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	claims.Role = e.Role
+
+	_, tokenString, err := h.jwt.Encode(claims)
+	return tokenString, err
+}
+
 type userRegisterRequest struct {
 	User struct {
 		Username string `json:"username" valid:"required~username is blank"`
@@ -104,11 +142,15 @@ func (req *userRegisterRequest) endpointRequest() user.RegisterRequest {
 }
 
 type userResponse struct {
-	Username string          `json:"username"`
-	Email    string          `json:"email"`
-	Bio      realworld.Bio   `json:"bio"`
-	Image    realworld.Image `json:"image"`
-	Token    string          `json:"token"`
+	Username      string          `json:"username"`
+	Email         string          `json:"email"`
+	Bio           realworld.Bio   `json:"bio"`
+	Image         realworld.Image `json:"image"`
+	EmailVerified bool            `json:"emailVerified"`
+	PendingEmail  string          `json:"pendingEmail,omitempty"`
+	Role          string          `json:"role"`
+	Token         string          `json:"token"`
+	RefreshToken  string          `json:"refreshToken,omitempty"`
 }
 
 type userRegisterResponse struct {
@@ -118,10 +160,13 @@ type userRegisterResponse struct {
 func newUserResponse(u *user.Response) userRegisterResponse {
 	return userRegisterResponse{
 		User: userResponse{
-			Username: u.Username,
-			Email:    u.Email,
-			Bio:      u.Bio,
-			Image:    u.Image,
+			Username:      u.Username,
+			Email:         u.Email,
+			Bio:           u.Bio,
+			Image:         u.Image,
+			EmailVerified: u.EmailVerified,
+			PendingEmail:  u.PendingEmail,
+			Role:          u.Role,
 		},
 	}
 }
@@ -132,6 +177,7 @@ func (h UserHandler) decodeLoginRequest(_ context.Context, r *http.Request) (req
 		return nil, err
 	}
 	er := req.endpointRequest()
+	er.ClientIP = clientIP(r)
 	return er, nil
 }
 
@@ -145,20 +191,17 @@ func (h UserHandler) encodeUserResponse(ctx context.Context, w http.ResponseWrit
 
 	hresp := newUserResponse(&e)
 
-	claims := jwt.MapClaims{
-		"id": e.ID,
-	}
-
-	middleware.SetIssuedNow(claims)
-	middleware.SetExpiryIn(claims, time.Hour*24*5)
-
-	_, tokenString, err := h.jwt.Encode(claims)
-
+	tokenString, err := h.accessToken(ctx, e)
 	if err != nil {
 		return err
 	}
 
 	hresp.User.Token = tokenString
+	hresp.User.RefreshToken = e.RefreshToken
+
+	if err := h.moveTokensToCookies(w, &hresp.User); err != nil {
+		return err
+	}
 
 	return jsonResponse(w, hresp, http.StatusOK)
 }
@@ -207,15 +250,11 @@ func (req *userGetRequest) endpointRequest() user.GetRequest {
 }
 
 func (h UserHandler) decodeGetRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
-	// TODO: handle unexpected errors
-	// TODO: move this to a middleware so inject the ID to the context directly
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return nil, err
 	}
-	t := claims["id"].(float64)
 
-	id := int64(t)
 	req := userGetRequest{
 		ID: id,
 	}
@@ -225,14 +264,10 @@ func (h UserHandler) decodeGetRequest(_ context.Context, r *http.Request) (reque
 }
 
 func (h UserHandler) decodeUpdateRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
-	// TODO: handle unexpected errors
-	// TODO: move this to a middleware so inject the ID to the context directly
-	_, claims, err := middleware.FromContext(r.Context())
+	id, err := userID(r)
 	if err != nil {
 		return nil, err
 	}
-	t := claims["id"].(float64)
-	id := int64(t)
 
 	var req updateRequest
 	if err := req.bind(r.Body); err != nil {
@@ -283,3 +318,80 @@ func (req *updateRequest) endpointRequest() user.UpdateRequest {
 		Image:    req.User.Image,
 	}
 }
+
+func (h UserHandler) decodePatchRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req patchRequest
+	if err := req.bind(r.Body); err != nil {
+		return nil, err
+	}
+	req.id = id
+	er := req.endpointRequest()
+	return er, nil
+}
+
+// patchRequest leaves out of the patch the fields that aren't in the body.
+// Bio and image are kept raw to tell a null, which removes them, from
+// leaving them out.
+type patchRequest struct {
+	id   int64
+	User struct {
+		Username *string         `json:"username"`
+		Email    *string         `json:"email"`
+		Password *string         `json:"password"`
+		Bio      json.RawMessage `json:"bio"`
+		Image    json.RawMessage `json:"image"`
+	} `json:"user"`
+	bio   *realworld.Bio
+	image *realworld.Image
+}
+
+func (req *patchRequest) bind(r io.Reader) error {
+	if e := json.NewDecoder(r).Decode(&req); e != nil {
+		return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+	}
+
+	if req.User.Bio != nil {
+		req.bio = new(realworld.Bio)
+		if err := json.Unmarshal(req.User.Bio, req.bio); err != nil {
+			return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+		}
+	}
+	if req.User.Image != nil {
+		req.image = new(realworld.Image)
+		if err := json.Unmarshal(req.User.Image, req.image); err != nil {
+			return httpError.NewError(http.StatusUnprocessableEntity, httpError.ErrRequestBody)
+		}
+	}
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (req *patchRequest) validate() error {
+	return validation.ValidateStruct(
+		&req.User,
+		validation.Field(&req.User.Username, validation.NilOrNotEmpty, validation.Length(4, 50)),
+		validation.Field(&req.User.Email, validation.NilOrNotEmpty, is.Email),
+		validation.Field(&req.User.Password, validation.NilOrNotEmpty, validation.Length(6, 50)),
+	)
+}
+
+func (req *patchRequest) endpointRequest() user.PatchRequest {
+	return user.PatchRequest{
+		ID: req.id,
+		Patch: realworld.UserPatch{
+			Username: req.User.Username,
+			Email:    req.User.Email,
+			Password: req.User.Password,
+			Bio:      req.bio,
+			Image:    req.image,
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xesina/gokit-realworld/user"
+	"net/http"
+)
+
+type verifyEmailRequest struct {
+	token string
+}
+
+func (req *verifyEmailRequest) bind(r *http.Request) error {
+	req.token = r.URL.Query().Get("token")
+
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (req *verifyEmailRequest) validate() error {
+	return validation.ValidateStruct(
+		req,
+		validation.Field(&req.token, validation.Required),
+	)
+}
+
+func (req *verifyEmailRequest) endpointRequest() user.VerifyEmailRequest {
+	return user.VerifyEmailRequest{
+		Token: req.token,
+	}
+}
+
+func (h UserHandler) decodeVerifyEmailRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req verifyEmailRequest
+	if err := req.bind(r); err != nil {
+		return nil, err
+	}
+	er := req.endpointRequest()
+	return er, nil
+}
+
+func (h UserHandler) decodeResendVerificationRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	id, err := userID(r)
+	if err != nil {
+		return nil, err
+	}
+	return user.ResendVerificationRequest{UserID: id}, nil
+}
@@ -0,0 +1,101 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnknownProvider   = Error{ENotFound, errors.New("unknown identity provider")}
+	ErrInvalidOAuthState = Error{EInvalidToken, errors.New("login is invalid or has expired")}
+	ErrIdentityNotFound  = Error{ENotFound, errors.New("identity not found")}
+	ErrIdentityLinked    = Error{EConflict, errors.New("identity is already linked")}
+	ErrIdentityEmail     = Error{EConflict, errors.New("an account with this email already exists, log in to link the identity to it")}
+	ErrIdentityNoEmail   = Error{EConflict, errors.New("identity provider didn't share an email address")}
+	ErrLastLoginMethod   = Error{EConflict, errors.New("set a password before unlinking the last identity")}
+)
+
+// Identity links a user to their account at an external identity provider.
+type Identity struct {
+	ID       int64
+	UserID   int64
+	Provider string
+	// Subject identifies the account at the provider; unlike Email it never changes.
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// ExternalIdentity is what a provider vouches for about the user who logged in with it.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	// Username is only a suggestion for new accounts.
+	Username string
+}
+
+// OAuthState ties the callback from a provider to the login it started.
+// Only a hash of the state sent to the provider is stored.
+type OAuthState struct {
+	Hash     string
+	Provider string
+	// Verifier is the PKCE code verifier, proving the callback is ours to redeem.
+	Verifier string
+	Nonce    string
+	// BindingHash is the hash of the binding handed to the browser that
+	// started the login, which has to come back with it.
+	BindingHash string
+	// LinkUserID is set when an identity is being linked to an existing user.
+	LinkUserID int64
+	ExpiresAt  time.Time
+}
+
+// OAuthCallback is what a provider sends users back to us with, along with
+// what their browser brings.
+type OAuthCallback struct {
+	Provider string
+	State    string
+	Code     string
+	// Binding is what AuthorizationURL handed the browser the login started in.
+	Binding string
+}
+
+func (s OAuthState) Expired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+type IdentityService interface {
+	ProviderNames() []string
+	// AuthorizationURL starts a login with provider, returning where the
+	// user is sent to and a binding for their browser to keep until the
+	// callback, so a login can't be finished in a browser it wasn't started
+	// in. When linkTo has an ID the identity is linked to that user instead,
+	// who has to be the one the binding was handed to.
+	AuthorizationURL(provider string, linkTo User) (authURL, binding string, err error)
+	// Callback finishes what AuthorizationURL started. It returns the user
+	// logged in or linked to, creating one if needed, and whether an
+	// identity was linked.
+	Callback(c OAuthCallback) (*User, bool, error)
+	Identities(u User) ([]*Identity, error)
+	Unlink(u User, provider string) error
+}
+
+type IdentityRepo interface {
+	// Create returns ErrIdentityLinked when the provider account or the
+	// user already has an identity with the provider.
+	Create(i Identity) (*Identity, error)
+	// Get returns ErrIdentityNotFound when no user is linked to the provider account.
+	Get(provider, subject string) (*Identity, error)
+	ListByUserID(userID int64) ([]*Identity, error)
+	// Delete returns ErrIdentityNotFound unless the user has an identity with provider.
+	Delete(userID int64, provider string) error
+}
+
+type OAuthStateRepo interface {
+	Create(s OAuthState) error
+	// Take removes and returns the state with hash, so each can only be used
+	// once. It returns ErrInvalidOAuthState when there's none.
+	Take(hash string) (*OAuthState, error)
+}
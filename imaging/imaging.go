@@ -0,0 +1,139 @@
+// Package imaging checks, scales and re-encodes uploaded images.
+package imaging
+
+import (
+	"bytes"
+	realworld "github.com/xesina/gokit-realworld"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Formats of the images that are accepted.
+const (
+	PNG  = "png"
+	JPEG = "jpeg"
+	GIF  = "gif"
+)
+
+var formats = map[string]string{
+	"image/png":  PNG,
+	"image/jpeg": JPEG,
+	"image/gif":  GIF,
+}
+
+// Sniff returns the format of the image in b, judging by its first bytes
+// rather than whatever it claims to be.
+func Sniff(b []byte) (string, error) {
+	format, ok := formats[http.DetectContentType(b)]
+	if !ok {
+		return "", realworld.ErrUnsupportedImage
+	}
+	return format, nil
+}
+
+// Read reads up to maxBytes of an image from r, returning
+// ErrImageTooLarge when there's more.
+func Read(r io.Reader, maxBytes int64) ([]byte, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > maxBytes {
+		return nil, realworld.ErrImageTooLarge
+	}
+	return b, nil
+}
+
+// Decode decodes the image in b, which must have no more than maxPixels
+// pixels. Only the first frame of animated GIFs is decoded.
+func Decode(b []byte, maxPixels int) (image.Image, string, error) {
+	format, err := Sniff(b)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Checking the dimensions first keeps a small file from decoding into
+	// a huge image.
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", realworld.ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", realworld.ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", realworld.ErrUnsupportedImage
+	}
+
+	return img, format, nil
+}
+
+// Square crops the largest square out of the middle of img and scales it
+// down to size pixels wide, averaging the pixels that shrink into one.
+// Images smaller than size are only cropped.
+func Square(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	crop := image.Rect(0, 0, side, side)
+	offset := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+
+	src := image.NewRGBA(crop)
+	draw.Draw(src, crop, img, offset, draw.Src)
+	if side <= size {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+
+			n := (y1 - y0) * (x1 - x0)
+			d := dst.Pix[y*dst.Stride+x*4:]
+			for i := range sum {
+				d[i] = uint8(sum[i] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// Encode writes img to w. JPEG images stay JPEG and everything else is
+// encoded as PNG, which is also what Extension names.
+func Encode(w io.Writer, img image.Image, format string) error {
+	if format == JPEG {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return png.Encode(w, img)
+}
+
+// Extension returns the file extension of images Encode writes in format.
+func Extension(format string) string {
+	if format == JPEG {
+		return "jpg"
+	}
+	return "png"
+}
@@ -0,0 +1,92 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func NewMemAPIKeyRepo() realworld.APIKeyRepo {
+	return &memAPIKeyRepo{
+		m: map[int64]realworld.APIKey{},
+	}
+}
+
+type memAPIKeyRepo struct {
+	rwlock  sync.RWMutex
+	m       map[int64]realworld.APIKey
+	counter int64
+}
+
+func (store *memAPIKeyRepo) Create(k realworld.APIKey) (*realworld.APIKey, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	k.ID = atomic.AddInt64(&store.counter, 1)
+	k.CreatedAt = time.Now()
+	k.Scopes = append([]string(nil), k.Scopes...)
+	store.m[k.ID] = k
+
+	return &k, nil
+}
+
+func (store *memAPIKeyRepo) GetByHash(hash string) (*realworld.APIKey, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	for _, k := range store.m {
+		if k.Hash == hash {
+			return &k, nil
+		}
+	}
+
+	return nil, realworld.ErrInvalidAPIKey
+}
+
+func (store *memAPIKeyRepo) ListByUserID(userID int64) ([]*realworld.APIKey, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	kk := make([]*realworld.APIKey, 0)
+	for _, k := range store.m {
+		if k.UserID == userID {
+			k := k
+			kk = append(kk, &k)
+		}
+	}
+
+	sort.Slice(kk, func(i, j int) bool { return kk[i].ID < kk[j].ID })
+
+	return kk, nil
+}
+
+func (store *memAPIKeyRepo) Delete(userID, id int64) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	k, ok := store.m[id]
+	if !ok || k.UserID != userID {
+		return realworld.ErrAPIKeyNotFound
+	}
+
+	delete(store.m, id)
+
+	return nil
+}
+
+func (store *memAPIKeyRepo) Touch(id int64, at time.Time) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	k, ok := store.m[id]
+	if !ok {
+		return realworld.ErrAPIKeyNotFound
+	}
+
+	k.LastUsedAt = at
+	store.m[id] = k
+
+	return nil
+}
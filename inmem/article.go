@@ -10,13 +10,15 @@ import (
 
 func NewMemArticleRepo() realworld.ArticleRepo {
 	return &memArticleRepo{
-		m: map[string]realworld.Article{},
+		m:     map[string]realworld.Article{},
+		edits: map[int64][]realworld.CommentEdit{},
 	}
 }
 
 type memArticleRepo struct {
 	rwlock  sync.RWMutex
 	m       map[string]realworld.Article
+	edits   map[int64][]realworld.CommentEdit
 	counter int64
 }
 
@@ -30,6 +32,8 @@ func (store *memArticleRepo) Create(a realworld.Article) (*realworld.Article, er
 
 	a.ID = atomic.AddInt64(&store.counter, 1)
 	a.Favorites = make(realworld.Favorites, 0)
+	a.Bookmarks = make(realworld.Bookmarks, 0)
+	a.Reactions = make(realworld.Reactions)
 	a.Comments = make(realworld.Comments, 0)
 	a.CreatedAt = time.Now()
 	a.UpdatedAt = time.Now()
@@ -50,6 +54,8 @@ func (store *memArticleRepo) Update(slug string, a realworld.Article) (*realworl
 	a.ID = old.ID
 	a.Comments = old.Comments
 	a.Favorites = old.Favorites
+	a.Bookmarks = old.Bookmarks
+	a.Reactions = old.Reactions
 	a.CreatedAt = old.CreatedAt
 	a.UpdatedAt = time.Now()
 
@@ -69,6 +75,43 @@ func (store *memArticleRepo) Delete(a realworld.Article) error {
 	return nil
 }
 
+func (store *memArticleRepo) DeleteByAuthorID(id int64) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	for slug, a := range store.m {
+		if a.Author.ID != id {
+			continue
+		}
+		for cid := range a.Comments {
+			delete(store.edits, cid)
+		}
+		delete(store.m, slug)
+	}
+
+	return nil
+}
+
+func (store *memArticleRepo) ForgetUser(id int64) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	for _, a := range store.m {
+		delete(a.Favorites, id)
+		delete(a.Bookmarks, id)
+		for _, kind := range a.Reactions.By(id) {
+			a.Reactions.Remove(kind, id)
+		}
+		for _, c := range a.Comments {
+			for _, kind := range c.Reactions.By(id) {
+				c.Reactions.Remove(kind, id)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (store *memArticleRepo) Get(slug string) (*realworld.Article, error) {
 	store.rwlock.RLock()
 	defer store.rwlock.RUnlock()
@@ -203,6 +246,20 @@ func (store *memArticleRepo) ListByAuthorID(id int64, offset, limit int) ([]*rea
 	return limited, len(limited), nil
 }
 
+func (store *memArticleRepo) CountByAuthorID(id int64) (int, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	count := 0
+	for _, a := range store.m {
+		if a.Author.ID == id {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
 func (store *memArticleRepo) ListByFavoriterID(id int64, offset, limit int) ([]*realworld.Article, int, error) {
 	store.rwlock.RLock()
 	defer store.rwlock.RUnlock()
@@ -231,7 +288,7 @@ func (store *memArticleRepo) ListByFavoriterID(id int64, offset, limit int) ([]*
 		}
 	}
 
-	qualified := store.filterByAuthorID(id, orderedByIDs)
+	qualified := store.filterByFavotiterID(id, orderedByIDs)
 
 	var limited []*realworld.Article
 	for i := offset; i < limit; i++ {
@@ -244,6 +301,31 @@ func (store *memArticleRepo) ListByFavoriterID(id int64, offset, limit int) ([]*
 	return limited, len(limited), nil
 }
 
+func (store *memArticleRepo) ListByBookmarkerID(id int64, offset, limit int) ([]*realworld.Article, int, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	bookmarked := make([]*realworld.Article, 0)
+	for _, a := range store.m {
+		if a.Bookmarks.BookmarkedBy(id) {
+			a := a
+			bookmarked = append(bookmarked, &a)
+		}
+	}
+
+	// Newest first, like the sqlite store.
+	sort.Slice(bookmarked, func(i, j int) bool {
+		return bookmarked[i].ID > bookmarked[j].ID
+	})
+
+	limited := make([]*realworld.Article, 0)
+	for i := offset; i < offset+limit && i < len(bookmarked); i++ {
+		limited = append(limited, bookmarked[i])
+	}
+
+	return limited, len(bookmarked), nil
+}
+
 func (store *memArticleRepo) Feed(req realworld.FeedRequest) ([]*realworld.Article, int, error) {
 	store.rwlock.RLock()
 	defer store.rwlock.RUnlock()
@@ -370,6 +452,62 @@ func (store *memArticleRepo) RemoveFavorite(a realworld.Article, u realworld.Use
 	return &article, nil
 }
 
+func (store *memArticleRepo) AddBookmark(a realworld.Article, u realworld.User) (*realworld.Article, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	article, ok := store.m[a.Slug]
+	if !ok {
+		return nil, realworld.ErrArticleNotFound
+	}
+
+	article.Bookmarks[u.ID] = struct{}{}
+
+	return &article, nil
+}
+
+func (store *memArticleRepo) RemoveBookmark(a realworld.Article, u realworld.User) (*realworld.Article, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	article, ok := store.m[a.Slug]
+	if !ok {
+		return nil, realworld.ErrArticleNotFound
+	}
+
+	delete(article.Bookmarks, u.ID)
+
+	return &article, nil
+}
+
+func (store *memArticleRepo) AddReaction(r realworld.Reaction, a realworld.Article) (*realworld.Article, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	article, ok := store.m[a.Slug]
+	if !ok {
+		return nil, realworld.ErrArticleNotFound
+	}
+
+	article.Reactions.Add(r.Kind, r.UserID)
+
+	return &article, nil
+}
+
+func (store *memArticleRepo) RemoveReaction(r realworld.Reaction, a realworld.Article) (*realworld.Article, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	article, ok := store.m[a.Slug]
+	if !ok {
+		return nil, realworld.ErrArticleNotFound
+	}
+
+	article.Reactions.Remove(r.Kind, r.UserID)
+
+	return &article, nil
+}
+
 func (store *memArticleRepo) Tags() ([]*realworld.Tag, error) {
 	tags := make(map[string]struct{})
 	tt := make([]*realworld.Tag, 0)
@@ -0,0 +1,92 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func NewMemAttachmentRepo() realworld.AttachmentRepo {
+	return &memAttachmentRepo{
+		m: map[int64]realworld.Attachment{},
+	}
+}
+
+type memAttachmentRepo struct {
+	rwlock  sync.RWMutex
+	m       map[int64]realworld.Attachment
+	counter int64
+}
+
+func (store *memAttachmentRepo) Create(a realworld.Attachment, quota int64) (*realworld.Attachment, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	used := a.Size
+	for _, found := range store.m {
+		if found.UserID == a.UserID {
+			used += found.Size
+		}
+	}
+	if used > quota {
+		return nil, realworld.ErrQuotaExceeded
+	}
+
+	a.ID = atomic.AddInt64(&store.counter, 1)
+	a.CreatedAt = time.Now()
+	store.m[a.ID] = a
+
+	return &a, nil
+}
+
+func (store *memAttachmentRepo) Get(articleID, id int64) (*realworld.Attachment, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	a, ok := store.m[id]
+	if !ok || a.ArticleID != articleID {
+		return nil, realworld.ErrAttachmentNotFound
+	}
+
+	return &a, nil
+}
+
+func (store *memAttachmentRepo) ListByArticleID(id int64) ([]*realworld.Attachment, error) {
+	return store.list(func(a realworld.Attachment) bool { return a.ArticleID == id }), nil
+}
+
+func (store *memAttachmentRepo) ListByUserID(id int64) ([]*realworld.Attachment, error) {
+	return store.list(func(a realworld.Attachment) bool { return a.UserID == id }), nil
+}
+
+// list returns the attachments that match, oldest first.
+func (store *memAttachmentRepo) list(match func(a realworld.Attachment) bool) []*realworld.Attachment {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	aa := make([]*realworld.Attachment, 0)
+	for _, a := range store.m {
+		if match(a) {
+			a := a
+			aa = append(aa, &a)
+		}
+	}
+
+	sort.Slice(aa, func(i, j int) bool { return aa[i].ID < aa[j].ID })
+
+	return aa
+}
+
+func (store *memAttachmentRepo) Delete(id int64) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	if _, ok := store.m[id]; !ok {
+		return realworld.ErrAttachmentNotFound
+	}
+	delete(store.m, id)
+
+	return nil
+}
@@ -2,26 +2,101 @@ package inmem
 
 import (
 	realworld "github.com/xesina/gokit-realworld"
+	"sort"
 	"sync/atomic"
 	"time"
 )
 
 func (store *memArticleRepo) AddComment(c realworld.Comment) (comment *realworld.Comment, err error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
 	article, ok := store.m[c.Article.Slug]
 	if !ok {
 		return nil, realworld.ErrArticleNotFound
 	}
 
 	c.ID = atomic.AddInt64(&store.counter, 1)
+	c.ArticleID = article.ID
+	c.Reactions = make(realworld.Reactions)
 	c.CreatedAt = time.Now()
 	c.UpdatedAt = time.Now()
 
 	article.Comments[c.ID] = c
 
-	return &c, nil
+	return copyComment(c), nil
+}
+
+func (store *memArticleRepo) Comment(c realworld.Comment) (*realworld.Comment, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	article, ok := store.m[c.Article.Slug]
+	if !ok {
+		return nil, realworld.ErrArticleNotFound
+	}
+
+	comment, ok := article.Comments[c.ID]
+	if !ok {
+		return nil, realworld.ErrCommentNotFound
+	}
+
+	return copyComment(comment), nil
+}
+
+func (store *memArticleRepo) UpdateComment(c realworld.Comment) (*realworld.Comment, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	article, ok := store.m[c.Article.Slug]
+	if !ok {
+		return nil, realworld.ErrArticleNotFound
+	}
+
+	comment, ok := article.Comments[c.ID]
+	if !ok {
+		return nil, realworld.ErrCommentNotFound
+	}
+
+	comment.Body = c.Body
+	comment.Deleted = c.Deleted
+	comment.Edited = c.Edited
+	comment.UpdatedAt = time.Now()
+
+	article.Comments[c.ID] = comment
+
+	return copyComment(comment), nil
+}
+
+func (store *memArticleRepo) AddCommentEdit(e realworld.CommentEdit) (*realworld.CommentEdit, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	e.ID = atomic.AddInt64(&store.counter, 1)
+	e.EditedAt = time.Now()
+
+	store.edits[e.CommentID] = append(store.edits[e.CommentID], e)
+
+	return &e, nil
+}
+
+func (store *memArticleRepo) CommentEdits(c realworld.Comment) ([]*realworld.CommentEdit, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	edits := make([]*realworld.CommentEdit, 0)
+	for i := range store.edits[c.ID] {
+		e := store.edits[c.ID][i]
+		edits = append(edits, &e)
+	}
+
+	return edits, nil
 }
 
 func (store *memArticleRepo) DeleteComment(c realworld.Comment) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
 	article, ok := store.m[c.Article.Slug]
 	if !ok {
 		return realworld.ErrArticleNotFound
@@ -33,16 +108,112 @@ func (store *memArticleRepo) DeleteComment(c realworld.Comment) error {
 }
 
 func (store *memArticleRepo) Comments(a realworld.Article) ([]*realworld.Comment, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
 	article, ok := store.m[a.Slug]
 	if !ok {
 		return nil, realworld.ErrArticleNotFound
 	}
 
 	var comments []*realworld.Comment
-	for k, _ := range article.Comments {
-		c := article.Comments[k]
-		comments = append(comments, &c)
+	for _, c := range article.Comments {
+		comments = append(comments, copyComment(c))
 	}
 
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+
 	return comments, nil
 }
+
+func (store *memArticleRepo) AddCommentReaction(r realworld.Reaction, c realworld.Comment) (*realworld.Comment, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	article, ok := store.m[c.Article.Slug]
+	if !ok {
+		return nil, realworld.ErrArticleNotFound
+	}
+
+	comment, ok := article.Comments[c.ID]
+	if !ok {
+		return nil, realworld.ErrCommentNotFound
+	}
+
+	comment.Reactions.Add(r.Kind, r.UserID)
+
+	return copyComment(comment), nil
+}
+
+func (store *memArticleRepo) RemoveCommentReaction(r realworld.Reaction, c realworld.Comment) (*realworld.Comment, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	article, ok := store.m[c.Article.Slug]
+	if !ok {
+		return nil, realworld.ErrArticleNotFound
+	}
+
+	comment, ok := article.Comments[c.ID]
+	if !ok {
+		return nil, realworld.ErrCommentNotFound
+	}
+
+	comment.Reactions.Remove(r.Kind, r.UserID)
+
+	return copyComment(comment), nil
+}
+
+func (store *memArticleRepo) DeleteCommentEdits(c realworld.Comment) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	delete(store.edits, c.ID)
+
+	return nil
+}
+
+func (store *memArticleRepo) CommentsByUserID(id int64) ([]*realworld.Comment, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	comments := make([]*realworld.Comment, 0)
+	for _, a := range store.m {
+		for k := range a.Comments {
+			c := a.Comments[k]
+			if c.UserID != id {
+				continue
+			}
+			c.Article = realworld.Article{ID: a.ID, Slug: a.Slug}
+			comments = append(comments, copyComment(c))
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+
+	return comments, nil
+}
+
+// copyComment returns a copy of c that shares none of its reactions, which
+// are only to be changed under the lock.
+func copyComment(c realworld.Comment) *realworld.Comment {
+	reactions := make(realworld.Reactions, len(c.Reactions))
+	for kind, ids := range c.Reactions {
+		reactions[kind] = make(map[int64]struct{}, len(ids))
+		for id := range ids {
+			reactions[kind][id] = struct{}{}
+		}
+	}
+	c.Reactions = reactions
+	return &c
+}
@@ -0,0 +1,58 @@
+package inmem
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"testing"
+)
+
+// TestMemArticleRepo_ConcurrentComments is meant for go test -race.
+func TestMemArticleRepo_ConcurrentComments(t *testing.T) {
+	repo := NewMemArticleRepo()
+	a := realworld.Article{Slug: "hello", Title: "hello"}
+	_, err := repo.Create(a)
+	assert.NoError(t, err)
+
+	first, err := repo.AddComment(realworld.Comment{Article: a, UserID: 1, Body: "first"})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			c, err := repo.AddComment(realworld.Comment{Article: a, ParentID: first.ID, UserID: 2, Body: "reply"})
+			assert.NoError(t, err)
+			assert.NoError(t, repo.DeleteComment(realworld.Comment{ID: c.ID, Article: a}))
+		}()
+		go func(id int64) {
+			defer wg.Done()
+			_, err := repo.AddCommentReaction(realworld.Reaction{Kind: "like", UserID: id}, *first)
+			assert.NoError(t, err)
+		}(int64(i))
+		go func() {
+			defer wg.Done()
+			cc, err := repo.Comments(a)
+			assert.NoError(t, err)
+			for _, c := range cc {
+				c.Reactions.Counts()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c, err := repo.Comment(*first)
+			assert.NoError(t, err)
+			c.Reactions.Add("heart", 99)
+		}()
+	}
+	wg.Wait()
+
+	// what was handed out is a copy
+	c, err := repo.Comment(*first)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"like": 10}, c.Reactions.Counts())
+	cc, err := repo.Comments(a)
+	assert.NoError(t, err)
+	assert.Len(t, cc, 1)
+}
@@ -0,0 +1,126 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func NewMemIdentityRepo() realworld.IdentityRepo {
+	return &memIdentityRepo{
+		m: map[int64]realworld.Identity{},
+	}
+}
+
+type memIdentityRepo struct {
+	rwlock  sync.RWMutex
+	m       map[int64]realworld.Identity
+	counter int64
+}
+
+func (store *memIdentityRepo) Create(i realworld.Identity) (*realworld.Identity, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	for _, found := range store.m {
+		if found.Provider != i.Provider {
+			continue
+		}
+		if found.Subject == i.Subject || found.UserID == i.UserID {
+			return nil, realworld.ErrIdentityLinked
+		}
+	}
+
+	i.ID = atomic.AddInt64(&store.counter, 1)
+	i.CreatedAt = time.Now()
+	store.m[i.ID] = i
+
+	return &i, nil
+}
+
+func (store *memIdentityRepo) Get(provider, subject string) (*realworld.Identity, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	for _, i := range store.m {
+		if i.Provider == provider && i.Subject == subject {
+			return &i, nil
+		}
+	}
+
+	return nil, realworld.ErrIdentityNotFound
+}
+
+func (store *memIdentityRepo) ListByUserID(userID int64) ([]*realworld.Identity, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	ii := make([]*realworld.Identity, 0)
+	for _, i := range store.m {
+		if i.UserID == userID {
+			i := i
+			ii = append(ii, &i)
+		}
+	}
+
+	sort.Slice(ii, func(a, b int) bool { return ii[a].ID < ii[b].ID })
+
+	return ii, nil
+}
+
+func (store *memIdentityRepo) Delete(userID int64, provider string) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	for id, i := range store.m {
+		if i.UserID == userID && i.Provider == provider {
+			delete(store.m, id)
+			return nil
+		}
+	}
+
+	return realworld.ErrIdentityNotFound
+}
+
+func NewMemOAuthStateRepo() realworld.OAuthStateRepo {
+	return &memOAuthStateRepo{
+		m: map[string]realworld.OAuthState{},
+	}
+}
+
+type memOAuthStateRepo struct {
+	rwlock sync.RWMutex
+	m      map[string]realworld.OAuthState
+}
+
+func (store *memOAuthStateRepo) Create(s realworld.OAuthState) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	// States left behind by logins nobody finished aren't needed anymore.
+	for hash, found := range store.m {
+		if found.Expired() {
+			delete(store.m, hash)
+		}
+	}
+
+	store.m[s.Hash] = s
+
+	return nil
+}
+
+func (store *memOAuthStateRepo) Take(hash string) (*realworld.OAuthState, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	s, ok := store.m[hash]
+	if !ok {
+		return nil, realworld.ErrInvalidOAuthState
+	}
+
+	delete(store.m, hash)
+
+	return &s, nil
+}
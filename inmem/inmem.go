@@ -2,6 +2,7 @@ package inmem
 
 import (
 	realworld "github.com/xesina/gokit-realworld"
+	"sort"
 	"sync"
 	"sync/atomic"
 )
@@ -13,16 +14,17 @@ func NewMemUserSaver() realworld.UserRepo {
 }
 
 type memUserSaver struct {
-	rwlock  sync.RWMutex
-	m       map[string]realworld.User
-	counter int64
+	rwlock    sync.RWMutex
+	m         map[string]realworld.User
+	usernames usernameIndex
+	counter   int64
 }
 
 func (store *memUserSaver) Create(u realworld.User) (*realworld.User, error) {
 	store.rwlock.Lock()
 	defer store.rwlock.Unlock()
 
-	if _, ok := store.m[u.Email]; ok {
+	if store.taken(u.Username, u.Email, 0) {
 		return nil, realworld.ErrUserAlreadyExists
 	}
 
@@ -30,6 +32,7 @@ func (store *memUserSaver) Create(u realworld.User) (*realworld.User, error) {
 	u.Followings = make(map[int64]struct{})
 	u.Followers = make(map[int64]struct{})
 	store.m[u.Email] = u
+	store.usernames.add(u)
 	return &u, nil
 }
 
@@ -37,11 +40,15 @@ func (store *memUserSaver) Update(u realworld.User) (*realworld.User, error) {
 	store.rwlock.Lock()
 	defer store.rwlock.Unlock()
 
-	old, ok := store.m[u.Email]
+	old, ok := store.byID(u.ID)
 	if !ok {
 		return nil, realworld.ErrUserNotFound
 	}
 
+	if store.taken(u.Username, u.Email, u.ID) {
+		return nil, realworld.ErrUserAlreadyExists
+	}
+
 	// TODO: should we do this password related thing in storage!?
 	if u.Password == "" {
 		u.Password = old.Password
@@ -50,14 +57,15 @@ func (store *memUserSaver) Update(u realworld.User) (*realworld.User, error) {
 	// TODO: I'm not sure if this is the best place to prevent followers/followings from change in update
 	u.Followers = old.Followers
 	u.Followings = old.Followings
+	u.EmailVerified = u.EmailVerified || old.EmailVerified
+	u.Role = old.Role
+	u.Banned = old.Banned
 
+	// Users are kept by email, so a new one moves the user to another entry.
+	delete(store.m, old.Email)
 	store.m[u.Email] = u
-
-	// If user has changes her email we need to create a new map entry
-	// preserving ID as before and also delete the old email and entry
-	if u.Email != old.Email {
-		delete(store.m, old.Email)
-	}
+	store.usernames.remove(old)
+	store.usernames.add(u)
 
 	return &u, nil
 }
@@ -146,3 +154,136 @@ func (store *memUserSaver) RemoveFollower(follower, followee int64) (*realworld.
 
 	return followeeUser, nil
 }
+
+func (store *memUserSaver) ListFollowers(id int64, offset, limit int) ([]*realworld.User, int, error) {
+	return store.listFollows(id, offset, limit, func(u realworld.User) realworld.Follows { return u.Followers })
+}
+
+func (store *memUserSaver) ListFollowing(id int64, offset, limit int) ([]*realworld.User, int, error) {
+	return store.listFollows(id, offset, limit, func(u realworld.User) realworld.Follows { return u.Followings })
+}
+
+// listFollows pages through the users in the follows of the user with the
+// given ID that follows picks.
+func (store *memUserSaver) listFollows(id int64, offset, limit int, follows func(u realworld.User) realworld.Follows) ([]*realworld.User, int, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	u, ok := store.byID(id)
+	if !ok {
+		return nil, 0, realworld.ErrUserNotFound
+	}
+
+	ff := follows(u)
+	users := make([]*realworld.User, 0, len(ff))
+	for _, other := range store.m {
+		if _, ok := ff[other.ID]; ok {
+			other := other
+			users = append(users, &other)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+
+	return pageUsers(users, offset, limit), len(users), nil
+}
+
+func (store *memUserSaver) SetRole(id int64, role string) error {
+	return store.set(id, func(u *realworld.User) { u.Role = role })
+}
+
+func (store *memUserSaver) SetBanned(id int64, banned bool) error {
+	return store.set(id, func(u *realworld.User) { u.Banned = banned })
+}
+
+func (store *memUserSaver) SetImage(id int64, image realworld.Image) error {
+	return store.set(id, func(u *realworld.User) { u.Image = image })
+}
+
+func (store *memUserSaver) set(id int64, f func(u *realworld.User)) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	for k, u := range store.m {
+		if u.ID == id {
+			f(&u)
+			store.m[k] = u
+			return nil
+		}
+	}
+
+	return realworld.ErrUserNotFound
+}
+
+func (store *memUserSaver) Anonymize(id int64, username, email string) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	old, ok := store.byID(id)
+	if !ok {
+		return realworld.ErrUserNotFound
+	}
+
+	store.unfollow(old)
+	delete(store.m, old.Email)
+	store.usernames.remove(old)
+	anonymized := realworld.User{
+		ID:         old.ID,
+		Username:   username,
+		Email:      email,
+		Followers:  make(map[int64]struct{}),
+		Followings: make(map[int64]struct{}),
+		Role:       realworld.RoleUser,
+		Banned:     true,
+	}
+	store.m[email] = anonymized
+	store.usernames.add(anonymized)
+
+	return nil
+}
+
+func (store *memUserSaver) Delete(id int64) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	u, ok := store.byID(id)
+	if !ok {
+		return realworld.ErrUserNotFound
+	}
+
+	store.unfollow(u)
+	delete(store.m, u.Email)
+	store.usernames.remove(u)
+
+	return nil
+}
+
+// taken reports whether a user other than the one with the given ID has the
+// username or email.
+func (store *memUserSaver) taken(username, email string, id int64) bool {
+	for _, u := range store.m {
+		if u.ID != id && (u.Username == username || u.Email == email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (store *memUserSaver) byID(id int64) (realworld.User, bool) {
+	for _, u := range store.m {
+		if u.ID == id {
+			return u, true
+		}
+	}
+	return realworld.User{}, false
+}
+
+// unfollow removes u from the follows of everyone u follows or is followed by.
+func (store *memUserSaver) unfollow(u realworld.User) {
+	for _, other := range store.m {
+		delete(other.Followers, u.ID)
+		delete(other.Followings, u.ID)
+	}
+}
@@ -0,0 +1,92 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"time"
+)
+
+// NewLockout locks a key out once it has failed threshold times in a row.
+// The first lockout lasts base and every further failure doubles it, up to
+// max. Failures are forgotten after max without any. State is kept per process.
+func NewLockout(threshold int, base, max time.Duration) realworld.Lockout {
+	return &lockout{
+		threshold: threshold,
+		base:      base,
+		max:       max,
+		keys:      map[string]lockoutEntry{},
+	}
+}
+
+type lockoutEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+type lockout struct {
+	mu        sync.Mutex
+	threshold int
+	base      time.Duration
+	max       time.Duration
+	keys      map[string]lockoutEntry
+}
+
+func (l *lockout) LockedUntil(key string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := l.keys[key]
+	if time.Now().Before(e.lockedUntil) {
+		return e.lockedUntil
+	}
+	return time.Time{}
+}
+
+func (l *lockout) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	e, ok := l.keys[key]
+	if !ok || l.stale(e, now) {
+		e = lockoutEntry{}
+	}
+
+	e.failures++
+	e.lastFailure = now
+	if e.failures >= l.threshold {
+		e.lockedUntil = now.Add(l.backoff(e.failures - l.threshold))
+	}
+	l.keys[key] = e
+
+	// Keep the map from growing with keys nobody uses anymore.
+	for k, e := range l.keys {
+		if l.stale(e, now) {
+			delete(l.keys, k)
+		}
+	}
+}
+
+func (l *lockout) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.keys, key)
+}
+
+// backoff returns base doubled n times, capped at max.
+func (l *lockout) backoff(n int) time.Duration {
+	d := l.base
+	for i := 0; i < n && d < l.max; i++ {
+		d *= 2
+	}
+	if d > l.max {
+		return l.max
+	}
+	return d
+}
+
+func (l *lockout) stale(e lockoutEntry, now time.Time) bool {
+	return now.After(e.lockedUntil) && now.Sub(e.lastFailure) > l.max
+}
@@ -0,0 +1,17 @@
+package inmem
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLockout_Backoff(t *testing.T) {
+	l := &lockout{threshold: 3, base: time.Minute, max: time.Hour}
+
+	assert.Equal(t, time.Minute, l.backoff(0))
+	assert.Equal(t, 2*time.Minute, l.backoff(1))
+	assert.Equal(t, 32*time.Minute, l.backoff(5))
+	assert.Equal(t, time.Hour, l.backoff(6))
+	assert.Equal(t, time.Hour, l.backoff(100))
+}
@@ -0,0 +1,58 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func NewMemPasswordResetRepo() realworld.PasswordResetRepo {
+	return &memPasswordResetRepo{
+		m: map[string]realworld.PasswordReset{},
+	}
+}
+
+type memPasswordResetRepo struct {
+	rwlock  sync.RWMutex
+	m       map[string]realworld.PasswordReset
+	counter int64
+}
+
+func (store *memPasswordResetRepo) Create(r realworld.PasswordReset) (*realworld.PasswordReset, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	r.ID = atomic.AddInt64(&store.counter, 1)
+	r.CreatedAt = time.Now()
+	store.m[r.Hash] = r
+
+	return &r, nil
+}
+
+func (store *memPasswordResetRepo) GetByHash(hash string) (*realworld.PasswordReset, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	r, ok := store.m[hash]
+	if !ok {
+		return nil, realworld.ErrInvalidResetToken
+	}
+
+	return &r, nil
+}
+
+func (store *memPasswordResetRepo) Use(r realworld.PasswordReset) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	found, ok := store.m[r.Hash]
+	if !ok || found.Used {
+		return realworld.ErrInvalidResetToken
+	}
+
+	found.Used = true
+	store.m[r.Hash] = found
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"time"
+)
+
+// NewRateLimiter allows limit attempts per key within any sliding window
+// of the given length. State is kept per process.
+func NewRateLimiter(limit int, window time.Duration) realworld.RateLimiter {
+	return &rateLimiter{
+		limit:    limit,
+		window:   window,
+		attempts: map[string][]time.Time{},
+	}
+}
+
+type rateLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Duration
+	attempts map[string][]time.Time
+}
+
+func (l *rateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	recent := l.recent(key, now)
+	if len(recent) >= l.limit {
+		l.attempts[key] = recent
+		return false
+	}
+
+	l.attempts[key] = append(recent, now)
+
+	// Keep the map from growing with keys nobody uses anymore.
+	for k := range l.attempts {
+		if len(l.recent(k, now)) == 0 {
+			delete(l.attempts, k)
+		}
+	}
+
+	return true
+}
+
+func (l *rateLimiter) recent(key string, now time.Time) []time.Time {
+	attempts := l.attempts[key]
+	i := 0
+	for i < len(attempts) && now.Sub(attempts[i]) >= l.window {
+		i++
+	}
+	return attempts[i:]
+}
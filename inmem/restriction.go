@@ -0,0 +1,90 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sort"
+	"sync"
+	"time"
+)
+
+func NewMemRestrictionRepo() realworld.RestrictionRepo {
+	return &memRestrictionRepo{
+		m: map[restrictionKey]realworld.Restriction{},
+	}
+}
+
+type restrictionKey struct {
+	userID   int64
+	targetID int64
+	kind     string
+}
+
+func restrictionKeyOf(r realworld.Restriction) restrictionKey {
+	return restrictionKey{userID: r.UserID, targetID: r.TargetID, kind: r.Kind}
+}
+
+type memRestrictionRepo struct {
+	rwlock sync.RWMutex
+	m      map[restrictionKey]realworld.Restriction
+}
+
+func (store *memRestrictionRepo) Add(r realworld.Restriction) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	if _, ok := store.m[restrictionKeyOf(r)]; ok {
+		return nil
+	}
+
+	r.CreatedAt = time.Now()
+	store.m[restrictionKeyOf(r)] = r
+
+	return nil
+}
+
+func (store *memRestrictionRepo) Remove(r realworld.Restriction) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	delete(store.m, restrictionKeyOf(r))
+
+	return nil
+}
+
+func (store *memRestrictionRepo) Exists(r realworld.Restriction) (bool, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	_, ok := store.m[restrictionKeyOf(r)]
+
+	return ok, nil
+}
+
+func (store *memRestrictionRepo) TargetIDs(userID int64, kind string) ([]int64, error) {
+	return store.ids(func(r realworld.Restriction) (int64, bool) {
+		return r.TargetID, r.UserID == userID && r.Kind == kind
+	})
+}
+
+func (store *memRestrictionRepo) UserIDs(targetID int64, kind string) ([]int64, error) {
+	return store.ids(func(r realworld.Restriction) (int64, bool) {
+		return r.UserID, r.TargetID == targetID && r.Kind == kind
+	})
+}
+
+// ids returns the sorted IDs picked out of the restrictions that match.
+func (store *memRestrictionRepo) ids(pick func(r realworld.Restriction) (int64, bool)) ([]int64, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	ids := make([]int64, 0)
+	for _, r := range store.m {
+		if id, ok := pick(r); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, nil
+}
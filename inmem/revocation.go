@@ -0,0 +1,93 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"time"
+)
+
+func NewMemRevocationRepo() realworld.RevocationRepo {
+	return &memRevocationRepo{
+		m: map[string]time.Time{},
+	}
+}
+
+// NewRevocationCache keeps every active revocation of next in memory so
+// checking a token doesn't hit the underlying store. Writes go through to
+// next, which means the cache is only accurate while this process is the
+// sole writer.
+func NewRevocationCache(next realworld.RevocationRepo) (realworld.RevocationRepo, error) {
+	rs, err := next.Active()
+	if err != nil {
+		return nil, err
+	}
+
+	store := &memRevocationRepo{
+		m:    make(map[string]time.Time, len(rs)),
+		next: next,
+	}
+	for _, r := range rs {
+		store.m[r.ID] = r.ExpiresAt
+	}
+
+	return store, nil
+}
+
+type memRevocationRepo struct {
+	rwlock sync.RWMutex
+	m      map[string]time.Time
+	next   realworld.RevocationRepo
+}
+
+func (store *memRevocationRepo) Add(r realworld.Revocation) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	if store.next != nil {
+		if err := store.next.Add(r); err != nil {
+			return err
+		}
+	}
+
+	store.m[r.ID] = r.ExpiresAt
+
+	// Expired entries can't match a valid token anymore, so this is a
+	// convenient place to drop them.
+	now := time.Now()
+	for id, exp := range store.m {
+		if !exp.After(now) {
+			delete(store.m, id)
+		}
+	}
+
+	return nil
+}
+
+func (store *memRevocationRepo) Revoked(ids ...string) (bool, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if exp, ok := store.m[id]; ok && exp.After(now) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (store *memRevocationRepo) Active() ([]*realworld.Revocation, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	now := time.Now()
+	rs := make([]*realworld.Revocation, 0, len(store.m))
+	for id, exp := range store.m {
+		if exp.After(now) {
+			rs = append(rs, &realworld.Revocation{ID: id, ExpiresAt: exp})
+		}
+	}
+
+	return rs, nil
+}
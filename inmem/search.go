@@ -0,0 +1,124 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sort"
+	"strings"
+)
+
+// usernameEntry points from a lowercased username to the key of its user.
+type usernameEntry struct {
+	username string
+	email    string
+}
+
+// usernameIndex is kept sorted by username so users can be looked up by
+// prefix without going through all of them.
+type usernameIndex []usernameEntry
+
+// search returns the position of the first entry whose username isn't
+// ordered before username.
+func (idx usernameIndex) search(username string) int {
+	return sort.Search(len(idx), func(i int) bool { return idx[i].username >= username })
+}
+
+func (idx *usernameIndex) add(u realworld.User) {
+	e := usernameEntry{username: strings.ToLower(u.Username), email: u.Email}
+	i := idx.search(e.username)
+	*idx = append(*idx, usernameEntry{})
+	copy((*idx)[i+1:], (*idx)[i:])
+	(*idx)[i] = e
+}
+
+func (idx *usernameIndex) remove(u realworld.User) {
+	username := strings.ToLower(u.Username)
+	for i := idx.search(username); i < len(*idx) && (*idx)[i].username == username; i++ {
+		if (*idx)[i].email == u.Email {
+			*idx = append((*idx)[:i], (*idx)[i+1:]...)
+			return
+		}
+	}
+}
+
+func (store *memUserSaver) ListByUsernamePrefix(prefix string, limit int) ([]*realworld.User, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	prefix = strings.ToLower(prefix)
+	users := make([]*realworld.User, 0)
+	for i := store.usernames.search(prefix); i < len(store.usernames) && len(users) < limit; i++ {
+		e := store.usernames[i]
+		if !strings.HasPrefix(e.username, prefix) {
+			break
+		}
+		if u, ok := store.m[e.email]; ok && !u.Banned {
+			users = append(users, &u)
+		}
+	}
+
+	return users, nil
+}
+
+// Ranks of the users found by a search, best first.
+const (
+	rankUsernamePrefix = iota
+	rankUsername
+	rankBio
+)
+
+func (store *memUserSaver) Search(r realworld.UserSearchRequest) ([]*realworld.User, int, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	query := strings.ToLower(r.Query)
+	ranks := make(map[int64]int)
+	users := make([]*realworld.User, 0)
+	for _, u := range store.m {
+		if u.Banned {
+			continue
+		}
+
+		username := strings.ToLower(u.Username)
+		switch {
+		case strings.HasPrefix(username, query):
+			ranks[u.ID] = rankUsernamePrefix
+		case strings.Contains(username, query):
+			ranks[u.ID] = rankUsername
+		case u.Bio.Valid && strings.Contains(strings.ToLower(u.Bio.Value), query):
+			ranks[u.ID] = rankBio
+		default:
+			continue
+		}
+
+		u := u
+		users = append(users, &u)
+	}
+
+	viewer := &realworld.User{ID: r.ViewerID}
+	sort.Slice(users, func(i, j int) bool {
+		if fi, fj := users[i].IsFollower(viewer), users[j].IsFollower(viewer); fi != fj {
+			return fi
+		}
+		if ri, rj := ranks[users[i].ID], ranks[users[j].ID]; ri != rj {
+			return ri < rj
+		}
+		if ui, uj := strings.ToLower(users[i].Username), strings.ToLower(users[j].Username); ui != uj {
+			return ui < uj
+		}
+		return users[i].ID < users[j].ID
+	})
+
+	return pageUsers(users, r.Offset, r.Limit), len(users), nil
+}
+
+// pageUsers returns the page of users starting at offset. A limit of zero
+// returns every user from there on.
+func pageUsers(users []*realworld.User, offset, limit int) []*realworld.User {
+	if offset > len(users) {
+		offset = len(users)
+	}
+	if limit > 0 && offset+limit < len(users) {
+		return users[offset : offset+limit]
+	}
+	return users[offset:]
+}
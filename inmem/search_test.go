@@ -0,0 +1,75 @@
+package inmem
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"testing"
+)
+
+func usernames(uu []*realworld.User) (names []string) {
+	for _, u := range uu {
+		names = append(names, u.Username)
+	}
+	return
+}
+
+func TestMemUserSaver_Search(t *testing.T) {
+	store := NewMemUserSaver()
+
+	create := func(username, bio string) *realworld.User {
+		u, err := store.Create(realworld.User{
+			Username: username,
+			Email:    username + "@example.com",
+			Bio:      realworld.Bio{Value: bio, Valid: bio != ""},
+		})
+		assert.NoError(t, err)
+		return u
+	}
+	viewer := create("viewer", "")
+	create("Annabel", "")
+	create("joanna", "")
+	create("bobby", "likes anna's articles")
+	followed := create("zanna", "")
+	create("carol", "")
+	banned := create("annie", "")
+
+	_, err := store.AddFollower(viewer.ID, followed.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, store.SetBanned(banned.ID, true))
+
+	uu, total, err := store.Search(realworld.UserSearchRequest{Query: "ANNA", ViewerID: viewer.ID, Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []string{"zanna", "Annabel", "joanna", "bobby"}, usernames(uu))
+
+	uu, total, err = store.Search(realworld.UserSearchRequest{Query: "anna", Offset: 1, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []string{"joanna", "zanna"}, usernames(uu))
+
+	uu, err = store.ListByUsernamePrefix("an", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Annabel"}, usernames(uu))
+
+	// the index follows renames, anonymizations and deletions
+	renamed := *viewer
+	renamed.Username = "andrew"
+	_, err = store.Update(renamed)
+	assert.NoError(t, err)
+	uu, err = store.ListByUsernamePrefix("an", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"andrew", "Annabel"}, usernames(uu))
+	uu, err = store.ListByUsernamePrefix("view", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, uu)
+
+	assert.NoError(t, store.Anonymize(viewer.ID, "deleted-1", "deleted-1@deleted.invalid"))
+	uu, err = store.ListByUsernamePrefix("an", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Annabel"}, usernames(uu))
+
+	assert.NoError(t, store.Delete(followed.ID))
+	uu, err = store.ListByUsernamePrefix("z", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, uu)
+}
@@ -0,0 +1,96 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func NewMemRefreshTokenRepo() realworld.RefreshTokenRepo {
+	return &memRefreshTokenRepo{
+		m: map[string]realworld.RefreshToken{},
+	}
+}
+
+type memRefreshTokenRepo struct {
+	rwlock  sync.RWMutex
+	m       map[string]realworld.RefreshToken
+	counter int64
+}
+
+func (store *memRefreshTokenRepo) Create(t realworld.RefreshToken) (*realworld.RefreshToken, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	t.ID = atomic.AddInt64(&store.counter, 1)
+	t.CreatedAt = time.Now()
+	store.m[t.Hash] = t
+
+	return &t, nil
+}
+
+func (store *memRefreshTokenRepo) GetByHash(hash string) (*realworld.RefreshToken, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	t, ok := store.m[hash]
+	if !ok {
+		return nil, realworld.ErrInvalidRefreshToken
+	}
+
+	return &t, nil
+}
+
+func (store *memRefreshTokenRepo) Use(t realworld.RefreshToken) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	found, ok := store.m[t.Hash]
+	if !ok {
+		return realworld.ErrInvalidRefreshToken
+	}
+
+	if found.Used {
+		return realworld.ErrRefreshTokenReused
+	}
+
+	found.Used = true
+	store.m[t.Hash] = found
+
+	return nil
+}
+
+func (store *memRefreshTokenRepo) RevokeFamily(family string) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	for k, t := range store.m {
+		if t.Family == family {
+			t.Revoked = true
+			store.m[k] = t
+		}
+	}
+
+	return nil
+}
+
+func (store *memRefreshTokenRepo) ListByUserID(id int64) ([]*realworld.RefreshToken, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	tokens := make([]*realworld.RefreshToken, 0)
+	for _, t := range store.m {
+		if t.UserID == id {
+			t := t
+			tokens = append(tokens, &t)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID < tokens[j].ID
+	})
+
+	return tokens, nil
+}
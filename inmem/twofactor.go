@@ -0,0 +1,116 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func NewMemTwoFactorRepo() realworld.TwoFactorRepo {
+	return &memTwoFactorRepo{
+		m: map[int64]realworld.TwoFactor{},
+	}
+}
+
+type memTwoFactorRepo struct {
+	rwlock sync.RWMutex
+	m      map[int64]realworld.TwoFactor
+}
+
+func (store *memTwoFactorRepo) Get(userID int64) (*realworld.TwoFactor, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	tf, ok := store.m[userID]
+	if !ok {
+		return nil, realworld.ErrTwoFactorNotFound
+	}
+
+	tf.RecoveryCodes = append([]string(nil), tf.RecoveryCodes...)
+	return &tf, nil
+}
+
+func (store *memTwoFactorRepo) Save(tf realworld.TwoFactor) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	tf.RecoveryCodes = append([]string(nil), tf.RecoveryCodes...)
+	store.m[tf.UserID] = tf
+
+	return nil
+}
+
+func (store *memTwoFactorRepo) Delete(userID int64) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	delete(store.m, userID)
+
+	return nil
+}
+
+func NewMemLoginChallengeRepo() realworld.LoginChallengeRepo {
+	return &memLoginChallengeRepo{
+		m: map[string]realworld.LoginChallenge{},
+	}
+}
+
+type memLoginChallengeRepo struct {
+	rwlock  sync.RWMutex
+	m       map[string]realworld.LoginChallenge
+	counter int64
+}
+
+func (store *memLoginChallengeRepo) Create(c realworld.LoginChallenge) (*realworld.LoginChallenge, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	c.ID = atomic.AddInt64(&store.counter, 1)
+	c.CreatedAt = time.Now()
+	store.m[c.Hash] = c
+
+	return &c, nil
+}
+
+func (store *memLoginChallengeRepo) GetByHash(hash string) (*realworld.LoginChallenge, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	c, ok := store.m[hash]
+	if !ok {
+		return nil, realworld.ErrInvalidLoginChallenge
+	}
+
+	return &c, nil
+}
+
+func (store *memLoginChallengeRepo) Use(c realworld.LoginChallenge) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	found, ok := store.m[c.Hash]
+	if !ok || found.Used {
+		return realworld.ErrInvalidLoginChallenge
+	}
+
+	found.Used = true
+	store.m[c.Hash] = found
+
+	return nil
+}
+
+func (store *memLoginChallengeRepo) Fail(c realworld.LoginChallenge) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	found, ok := store.m[c.Hash]
+	if !ok {
+		return realworld.ErrInvalidLoginChallenge
+	}
+
+	found.Attempts++
+	store.m[c.Hash] = found
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"time"
+)
+
+func NewMemUsernameHistoryRepo() realworld.UsernameHistoryRepo {
+	return &memUsernameHistoryRepo{}
+}
+
+// memUsernameHistoryRepo keeps changes in the order they were added.
+type memUsernameHistoryRepo struct {
+	rwlock  sync.RWMutex
+	changes []realworld.UsernameChange
+}
+
+func (store *memUsernameHistoryRepo) Add(c realworld.UsernameChange) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	c.ID = int64(len(store.changes) + 1)
+	c.CreatedAt = time.Now()
+	store.changes = append(store.changes, c)
+
+	return nil
+}
+
+func (store *memUsernameHistoryRepo) Latest(username string) (*realworld.UsernameChange, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	for i := len(store.changes) - 1; i >= 0; i-- {
+		if c := store.changes[i]; c.Username == username {
+			return &c, nil
+		}
+	}
+
+	return nil, realworld.ErrUserNotFound
+}
+
+func (store *memUsernameHistoryRepo) DeleteByUserID(id int64) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	kept := store.changes[:0]
+	for _, c := range store.changes {
+		if c.UserID != id {
+			kept = append(kept, c)
+		}
+	}
+	store.changes = kept
+
+	return nil
+}
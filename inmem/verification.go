@@ -0,0 +1,58 @@
+package inmem
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func NewMemEmailVerificationRepo() realworld.EmailVerificationRepo {
+	return &memEmailVerificationRepo{
+		m: map[string]realworld.EmailVerification{},
+	}
+}
+
+type memEmailVerificationRepo struct {
+	rwlock  sync.RWMutex
+	m       map[string]realworld.EmailVerification
+	counter int64
+}
+
+func (store *memEmailVerificationRepo) Create(v realworld.EmailVerification) (*realworld.EmailVerification, error) {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	v.ID = atomic.AddInt64(&store.counter, 1)
+	v.CreatedAt = time.Now()
+	store.m[v.Hash] = v
+
+	return &v, nil
+}
+
+func (store *memEmailVerificationRepo) GetByHash(hash string) (*realworld.EmailVerification, error) {
+	store.rwlock.RLock()
+	defer store.rwlock.RUnlock()
+
+	v, ok := store.m[hash]
+	if !ok {
+		return nil, realworld.ErrInvalidVerificationToken
+	}
+
+	return &v, nil
+}
+
+func (store *memEmailVerificationRepo) Use(v realworld.EmailVerification) error {
+	store.rwlock.Lock()
+	defer store.rwlock.Unlock()
+
+	found, ok := store.m[v.Hash]
+	if !ok || found.Used {
+		return realworld.ErrInvalidVerificationToken
+	}
+
+	found.Used = true
+	store.m[v.Hash] = found
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrLoginLockedOut = Error{ELockedOut, errors.New("too many failed login attempts, try again later")}
+)
+
+// Lockout tracks failed attempts per key, such as an email address or a
+// client IP, and locks a key out for exponentially longer once there have
+// been too many.
+type Lockout interface {
+	// LockedUntil returns when the lockout of key ends, or the zero time if
+	// it isn't locked out.
+	LockedUntil(key string) time.Time
+	Fail(key string)
+	Reset(key string)
+}
@@ -0,0 +1,12 @@
+package gokit_realworld
+
+// Message is an email sent to a user.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(m Message) error
+}
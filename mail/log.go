@@ -0,0 +1,31 @@
+package mail
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+	"io"
+	"sync"
+)
+
+// LogMailer writes messages to W instead of delivering them, which is
+// handy in development and tests. W is usually os.Stderr or a file.
+type LogMailer struct {
+	From string
+	W    io.Writer
+
+	mu sync.Mutex
+}
+
+func NewLogMailer(from string, w io.Writer) *LogMailer {
+	return &LogMailer{From: from, W: w}
+}
+
+func (m *LogMailer) Send(msg realworld.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.W.Write(format(m.From, msg)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(m.W, "\r\n\r\n")
+	return err
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	realworld "github.com/xesina/gokit-realworld"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer sends messages through an SMTP server.
+type SMTPMailer struct {
+	// Addr is the host:port of the server.
+	Addr string
+	From string
+	// Auth may be nil for servers that don't require authentication.
+	Auth smtp.Auth
+}
+
+func (m SMTPMailer) Send(msg realworld.Message) error {
+	return smtp.SendMail(m.Addr, m.Auth, m.From, []string{msg.To}, format(m.From, msg))
+}
+
+// format renders msg as a plain text email.
+func format(from string, msg realworld.Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return b.Bytes()
+}
@@ -0,0 +1,318 @@
+package oidc
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	realworld "github.com/xesina/gokit-realworld"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownKey   = errors.New("oidc: id token signed with an unknown key")
+	ErrInvalidToken = errors.New("oidc: id token is invalid")
+	ErrNoIDToken    = errors.New("oidc: token response has no id token")
+)
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// Provider is an OpenID Connect provider users can log in with, using the
+// authorization code flow with PKCE. Its endpoints are discovered from Issuer.
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is where the provider sends users back to with a code.
+	RedirectURL string
+	// Scopes default to openid, email and profile when empty.
+	Scopes []string
+	// Client defaults to a client with a 10 second timeout when nil.
+	Client *http.Client
+
+	mu     sync.Mutex
+	config *discovery
+	keys   map[string]*rsa.PublicKey
+}
+
+// discovery is the part of the provider's metadata we need.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// AuthCodeURL returns the URL users are sent to to log in. The code they
+// come back with can only be exchanged together with verifier.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) (string, error) {
+	c, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.scopes(), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(c.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return c.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems code for an ID token and returns the identity it vouches
+// for, once it's verified to be issued by the provider for us and for the
+// login with nonce.
+func (p *Provider) Exchange(code, verifier, nonce string) (*realworld.ExternalIdentity, error) {
+	c, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client().PostForm(c.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token endpoint responded with %s", resp.Status)
+	}
+
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.IDToken == "" {
+		return nil, ErrNoIDToken
+	}
+
+	claims, err := p.verify(body.IDToken, c.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Nonce != nonce {
+		return nil, ErrInvalidToken
+	}
+
+	return &realworld.ExternalIdentity{
+		Provider:      p.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Username:      claims.PreferredUsername,
+	}, nil
+}
+
+// IDTokenClaims are the claims of an ID token we make use of.
+type IDTokenClaims struct {
+	jwt.StandardClaims
+	// Audience shadows the one in StandardClaims, which can't be a list.
+	Audience          Audience `json:"aud,omitempty"`
+	Nonce             string   `json:"nonce,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	EmailVerified     bool     `json:"email_verified,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+}
+
+// Audience is the "aud" claim, which is either a single string or a list.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Provider) verify(raw, issuer string) (*IDTokenClaims, error) {
+	claims := &IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		// Providers have to support RS256, and it's all we accept.
+		if t.Method != jwt.SigningMethodRS256 {
+			return nil, ErrInvalidToken
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.key(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" || !claims.VerifyIssuer(issuer, true) || !claims.Audience.Contains(p.ClientID) {
+		return nil, ErrInvalidToken
+	}
+	// Unlike jwt-go, we don't take a token without an expiry.
+	if claims.ExpiresAt == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// key returns the provider's key with the ID, fetching the provider's keys
+// again when it's unknown in case they were rotated.
+func (p *Provider) key(kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	k, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+
+	c, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := p.fetchKeys(c.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (p *Provider) fetchKeys(uri string) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(uri, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+func (p *Provider) discover() (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.config != nil {
+		return p.config, nil
+	}
+
+	var c discovery
+	if err := p.getJSON(strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &c); err != nil {
+		return nil, err
+	}
+	if c.Issuer != p.Issuer {
+		return nil, fmt.Errorf("oidc: issuer %q doesn't match the configured %q", c.Issuer, p.Issuer)
+	}
+
+	p.config = &c
+	return p.config, nil
+}
+
+func (p *Provider) getJSON(uri string, v interface{}) error {
+	resp, err := p.client().Get(uri)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: %s responded with %s", uri, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (p *Provider) scopes() []string {
+	if len(p.Scopes) == 0 {
+		return defaultScopes
+	}
+	return p.Scopes
+}
+
+func (p *Provider) client() *http.Client {
+	if p.Client == nil {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return p.Client
+}
+
+// CodeChallenge derives the S256 PKCE code challenge from verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,276 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/token"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStateTTL = time.Minute * 10
+	// usernameAttempts is how many suffixed usernames are tried for a new
+	// user before giving up.
+	usernameAttempts = 5
+)
+
+type Service struct {
+	Providers map[string]*Provider
+	States    realworld.OAuthStateRepo
+	Repo      realworld.IdentityRepo
+	Users     realworld.UserRepo
+	// StateTTL is how long users have to log in with a provider. defaultStateTTL is used when it's zero.
+	StateTTL time.Duration
+	// Usernames, when set, keeps new users from being given a username
+	// someone gave up within UsernameReservation, as user.Service does.
+	Usernames           realworld.UsernameHistoryRepo
+	UsernameReservation time.Duration
+}
+
+func (s Service) ProviderNames() []string {
+	names := make([]string, 0, len(s.Providers))
+	for name := range s.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s Service) AuthorizationURL(provider string, linkTo realworld.User) (string, string, error) {
+	p, ok := s.Providers[provider]
+	if !ok {
+		return "", "", realworld.ErrUnknownProvider
+	}
+
+	state, hash, err := token.Generate()
+	if err != nil {
+		return "", "", realworld.InternalError(err)
+	}
+	binding, bindingHash, err := token.Generate()
+	if err != nil {
+		return "", "", realworld.InternalError(err)
+	}
+	verifier, _, err := token.Generate()
+	if err != nil {
+		return "", "", realworld.InternalError(err)
+	}
+	nonce, _, err := token.Generate()
+	if err != nil {
+		return "", "", realworld.InternalError(err)
+	}
+
+	err = s.States.Create(realworld.OAuthState{
+		Hash:        hash,
+		Provider:    provider,
+		Verifier:    verifier,
+		Nonce:       nonce,
+		BindingHash: bindingHash,
+		LinkUserID:  linkTo.ID,
+		ExpiresAt:   time.Now().Add(s.stateTTL()),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	u, err := p.AuthCodeURL(state, nonce, verifier)
+	if err != nil {
+		return "", "", realworld.InternalError(err)
+	}
+	return u, binding, nil
+}
+
+func (s Service) Callback(c realworld.OAuthCallback) (*realworld.User, bool, error) {
+	st, err := s.States.Take(token.Hash(c.State))
+	if err != nil {
+		return nil, false, err
+	}
+	if st.Expired() || st.Provider != c.Provider {
+		return nil, false, realworld.ErrInvalidOAuthState
+	}
+	// Otherwise anyone could have a victim's browser finish a login they
+	// started, logging the victim in as them, or linking their identity to
+	// the victim. Links are only started for the authenticated user, so the
+	// binding is all it takes to know they're the one finishing it.
+	if subtle.ConstantTimeCompare([]byte(st.BindingHash), []byte(token.Hash(c.Binding))) != 1 {
+		return nil, false, realworld.ErrInvalidOAuthState
+	}
+
+	p, ok := s.Providers[c.Provider]
+	if !ok {
+		return nil, false, realworld.ErrUnknownProvider
+	}
+
+	ext, err := p.Exchange(c.Code, st.Verifier, st.Nonce)
+	if err != nil {
+		// Whatever went wrong, the code is of no use to us.
+		return nil, false, realworld.Error{Code: realworld.EUnauthorized, Err: err}
+	}
+
+	if st.LinkUserID != 0 {
+		u, err := s.Users.GetByID(st.LinkUserID)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := s.link(u, ext); err != nil {
+			return nil, false, err
+		}
+		return u, true, nil
+	}
+
+	u, err := s.login(ext)
+	if err != nil {
+		return nil, false, err
+	}
+	if u.Banned {
+		return nil, false, realworld.ErrUserBanned
+	}
+	return u, false, nil
+}
+
+// login returns the user linked to ext. Failing that, an existing user with
+// the same email gets it linked when both the provider and we have verified
+// the address, so neither side can claim an account it doesn't own. Anyone
+// else gets a new user.
+func (s Service) login(ext *realworld.ExternalIdentity) (*realworld.User, error) {
+	i, err := s.Repo.Get(ext.Provider, ext.Subject)
+	if err == nil {
+		return s.Users.GetByID(i.UserID)
+	}
+	if realworld.ErrorCode(err) != realworld.ENotFound {
+		return nil, err
+	}
+
+	// Every user needs an email to log in with, or to reset a password.
+	if ext.Email == "" {
+		return nil, realworld.ErrIdentityNoEmail
+	}
+
+	u, err := s.Users.Get(ext.Email)
+	if err == nil {
+		if !ext.EmailVerified || !u.EmailVerified {
+			return nil, realworld.ErrIdentityEmail
+		}
+		return u, s.link(u, ext)
+	}
+	if realworld.ErrorCode(err) != realworld.ENotFound {
+		return nil, err
+	}
+
+	u, err = s.register(ext)
+	if err != nil {
+		return nil, err
+	}
+	return u, s.link(u, ext)
+}
+
+// register creates a user without a password for ext, so they can only log
+// in with the provider until they set one.
+func (s Service) register(ext *realworld.ExternalIdentity) (*realworld.User, error) {
+	base := usernameFor(ext)
+
+	for i := 0; i < usernameAttempts; i++ {
+		username := base
+		if i > 0 {
+			n, err := rand.Int(rand.Reader, big.NewInt(10000))
+			if err != nil {
+				return nil, realworld.InternalError(err)
+			}
+			username = fmt.Sprintf("%s%04d", base, n)
+		}
+
+		if _, err := s.Users.GetByUsername(username); err == nil {
+			continue
+		} else if realworld.ErrorCode(err) != realworld.ENotFound {
+			return nil, err
+		}
+		// A reserved username is as good as taken.
+		if err := realworld.CheckUsername(s.Usernames, s.UsernameReservation, 0, username); err != nil {
+			if errors.Is(err, realworld.ErrUsernameReserved) {
+				continue
+			}
+			return nil, err
+		}
+
+		u, err := s.Users.Create(realworld.User{
+			Username:      username,
+			Email:         ext.Email,
+			EmailVerified: ext.EmailVerified,
+			Role:          realworld.RoleUser,
+		})
+		if realworld.ErrorCode(err) == realworld.EConflict {
+			// Taken in the meantime.
+			continue
+		}
+		return u, err
+	}
+
+	return nil, realworld.ErrUserAlreadyExists
+}
+
+func (s Service) link(u *realworld.User, ext *realworld.ExternalIdentity) error {
+	_, err := s.Repo.Create(realworld.Identity{
+		UserID:   u.ID,
+		Provider: ext.Provider,
+		Subject:  ext.Subject,
+		Email:    ext.Email,
+	})
+	return err
+}
+
+func (s Service) Identities(u realworld.User) ([]*realworld.Identity, error) {
+	return s.Repo.ListByUserID(u.ID)
+}
+
+// Unlink won't leave a user without a way to log in.
+func (s Service) Unlink(u realworld.User, provider string) error {
+	found, err := s.Users.GetByID(u.ID)
+	if err != nil {
+		return err
+	}
+
+	if found.Password == "" {
+		ii, err := s.Repo.ListByUserID(u.ID)
+		if err != nil {
+			return err
+		}
+		if len(ii) == 1 && ii[0].Provider == provider {
+			return realworld.ErrLastLoginMethod
+		}
+	}
+
+	return s.Repo.Delete(u.ID, provider)
+}
+
+func (s Service) stateTTL() time.Duration {
+	if s.StateTTL == 0 {
+		return defaultStateTTL
+	}
+	return s.StateTTL
+}
+
+var usernameInvalid = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// usernameFor suggests a username from what the provider calls the user or
+// else their email, padded to the 4 characters usernames need.
+func usernameFor(ext *realworld.ExternalIdentity) string {
+	name := ext.Username
+	if name == "" {
+		name = strings.SplitN(ext.Email, "@", 2)[0]
+	}
+
+	name = usernameInvalid.ReplaceAllString(strings.ToLower(name), "")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	for len(name) < 4 {
+		name += "_"
+	}
+	return name
+}
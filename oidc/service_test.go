@@ -0,0 +1,263 @@
+package oidc_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"github.com/xesina/gokit-realworld/oidc"
+	"github.com/xesina/gokit-realworld/oidc/oidctest"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const callbackURL = "http://localhost/api/users/oauth/fake/callback"
+
+func newService(t *testing.T) (oidc.Service, *oidctest.Provider) {
+	p, srv, err := oidctest.NewServer("realworld", "secret")
+	assert.NoError(t, err)
+	t.Cleanup(srv.Close)
+
+	return oidc.Service{
+		Providers: map[string]*oidc.Provider{"fake": p.Client("fake", callbackURL)},
+		States:    inmem.NewMemOAuthStateRepo(),
+		Repo:      inmem.NewMemIdentityRepo(),
+		Users:     inmem.NewMemUserSaver(),
+	}, p
+}
+
+// authorize follows the authorization URL the way a browser would, up to
+// the redirect back to us, and returns the state and code it carries.
+func authorize(t *testing.T, authURL string) (state, code string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, callbackURL, loc.Scheme+"://"+loc.Host+loc.Path)
+	return loc.Query().Get("state"), loc.Query().Get("code")
+}
+
+// callback finishes a login in the browser it was started in.
+func callback(s oidc.Service, state, code, binding string) (*realworld.User, bool, error) {
+	return s.Callback(realworld.OAuthCallback{Provider: "fake", State: state, Code: code, Binding: binding})
+}
+
+func TestService_Login(t *testing.T) {
+	s, p := newService(t)
+	p.AddUser(oidctest.User{Subject: "1", Email: "alice@example.com", EmailVerified: true, Username: "Al"})
+
+	_, _, err := s.AuthorizationURL("nope", realworld.User{})
+	assert.Equal(t, realworld.ErrUnknownProvider, err)
+
+	authURL, binding, err := s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, binding)
+	q := mustQuery(t, authURL)
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.NotEmpty(t, q.Get("code_challenge"))
+
+	state, code := authorize(t, authURL)
+
+	_, _, err = callback(s, "bogus", code, binding)
+	assert.Equal(t, realworld.ErrInvalidOAuthState, err)
+
+	u, linked, err := callback(s, state, code, binding)
+	assert.NoError(t, err)
+	assert.False(t, linked)
+	assert.Equal(t, "al__", u.Username)
+	assert.Equal(t, "alice@example.com", u.Email)
+	assert.True(t, u.EmailVerified)
+	assert.Empty(t, u.Password)
+
+	// states are good for one callback only
+	_, _, err = callback(s, state, code, binding)
+	assert.Equal(t, realworld.ErrInvalidOAuthState, err)
+
+	// the identity is recognised next time
+	authURL, binding, err = s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+	state, code = authorize(t, authURL)
+	again, _, err := callback(s, state, code, binding)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, again.ID)
+
+	ii, err := s.Identities(*u)
+	assert.NoError(t, err)
+	assert.Len(t, ii, 1)
+	assert.Equal(t, "fake", ii[0].Provider)
+
+	assert.Equal(t, realworld.ErrLastLoginMethod, s.Unlink(*u, "fake"))
+}
+
+func TestService_CallbackNeedsBinding(t *testing.T) {
+	s, p := newService(t)
+	p.AddUser(oidctest.User{Subject: "1", Email: "alice@example.com", EmailVerified: true})
+
+	// A login started by someone else can't be finished in a browser that
+	// has no binding, or the binding of another login.
+	authURL, _, err := s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+	state, code := authorize(t, authURL)
+	_, _, err = callback(s, state, code, "")
+	assert.Equal(t, realworld.ErrInvalidOAuthState, err)
+
+	_, own, err := s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+	authURL, _, err = s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+	state, code = authorize(t, authURL)
+	_, _, err = callback(s, state, code, own)
+	assert.Equal(t, realworld.ErrInvalidOAuthState, err)
+}
+
+func TestService_LoginReservedUsername(t *testing.T) {
+	s, p := newService(t)
+	s.Usernames = inmem.NewMemUsernameHistoryRepo()
+	renamed, err := s.Users.Create(realworld.User{Username: "frank", Email: "frank@example.com", Password: "hash"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Usernames.Add(realworld.UsernameChange{UserID: renamed.ID, Username: "francis"}))
+
+	login := func() *realworld.User {
+		authURL, binding, err := s.AuthorizationURL("fake", realworld.User{})
+		assert.NoError(t, err)
+		state, code := authorize(t, authURL)
+		u, _, err := callback(s, state, code, binding)
+		assert.NoError(t, err)
+		return u
+	}
+
+	// the name frank gave up is kept from new users like one that's taken
+	p.AddUser(oidctest.User{Subject: "5", Email: "francis@example.com", EmailVerified: true, Username: "Francis"})
+	u := login()
+	assert.Regexp(t, `^francis\d{4}$`, u.Username)
+
+	// until the reservation is over
+	s.UsernameReservation = time.Nanosecond
+	p.AddUser(oidctest.User{Subject: "6", Email: "francis@work.example.com", EmailVerified: true, Username: "Francis"})
+	u = login()
+	assert.Equal(t, "francis", u.Username)
+}
+
+func TestService_LoginWithExistingEmail(t *testing.T) {
+	s, p := newService(t)
+	local, err := s.Users.Create(realworld.User{Username: "bobby", Email: "bob@example.com", Password: "hash", EmailVerified: true})
+	assert.NoError(t, err)
+
+	login := func() (*realworld.User, error) {
+		authURL, binding, err := s.AuthorizationURL("fake", realworld.User{})
+		assert.NoError(t, err)
+		state, code := authorize(t, authURL)
+		u, _, err := callback(s, state, code, binding)
+		return u, err
+	}
+
+	// an address the provider hasn't verified doesn't get the account
+	p.AddUser(oidctest.User{Subject: "2", Email: "bob@example.com"})
+	_, err = login()
+	assert.Equal(t, realworld.ErrIdentityEmail, err)
+
+	p.AddUser(oidctest.User{Subject: "2", Email: "bob@example.com", EmailVerified: true})
+	u, err := login()
+	assert.NoError(t, err)
+	assert.Equal(t, local.ID, u.ID)
+
+	// with a password left, the identity can go
+	assert.NoError(t, s.Unlink(*u, "fake"))
+	assert.Equal(t, realworld.ErrIdentityNotFound, s.Unlink(*u, "fake"))
+}
+
+func TestService_Link(t *testing.T) {
+	s, p := newService(t)
+	local, err := s.Users.Create(realworld.User{Username: "carol", Email: "carol@example.com", Password: "hash"})
+	assert.NoError(t, err)
+	p.AddUser(oidctest.User{Subject: "3", Email: "carol@work.example.com", EmailVerified: true})
+
+	authURL, binding, err := s.AuthorizationURL("fake", *local)
+	assert.NoError(t, err)
+	state, code := authorize(t, authURL)
+	u, linked, err := callback(s, state, code, binding)
+	assert.NoError(t, err)
+	assert.True(t, linked)
+	assert.Equal(t, local.ID, u.ID)
+
+	// the identity now logs in as the user it was linked to
+	authURL, binding, err = s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+	state, code = authorize(t, authURL)
+	u, linked, err = callback(s, state, code, binding)
+	assert.NoError(t, err)
+	assert.False(t, linked)
+	assert.Equal(t, local.ID, u.ID)
+
+	// and can't be linked to anyone else
+	other, err := s.Users.Create(realworld.User{Username: "dave", Email: "dave@example.com", Password: "hash"})
+	assert.NoError(t, err)
+	authURL, binding, err = s.AuthorizationURL("fake", *other)
+	assert.NoError(t, err)
+	state, code = authorize(t, authURL)
+	_, _, err = callback(s, state, code, binding)
+	assert.Equal(t, realworld.ErrIdentityLinked, err)
+}
+
+func TestService_LinkNeedsBinding(t *testing.T) {
+	s, p := newService(t)
+	local, err := s.Users.Create(realworld.User{Username: "carol", Email: "carol@example.com", Password: "hash"})
+	assert.NoError(t, err)
+	p.AddUser(oidctest.User{Subject: "3", Email: "carol@work.example.com", EmailVerified: true})
+
+	// Whoever finishes a link the user started, in a browser without the
+	// binding the user got, doesn't get their identity linked to the user.
+	_, own, err := s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+	for _, binding := range []string{"", own} {
+		authURL, _, err := s.AuthorizationURL("fake", *local)
+		assert.NoError(t, err)
+		state, code := authorize(t, authURL)
+		_, _, err = callback(s, state, code, binding)
+		assert.Equal(t, realworld.ErrInvalidOAuthState, err)
+	}
+
+	ii, err := s.Identities(*local)
+	assert.NoError(t, err)
+	assert.Empty(t, ii)
+
+	// The user's own browser needs no more than the binding, as the
+	// callback comes without the token clients authenticate with.
+	authURL, binding, err := s.AuthorizationURL("fake", *local)
+	assert.NoError(t, err)
+	state, code := authorize(t, authURL)
+	u, linked, err := callback(s, state, code, binding)
+	assert.NoError(t, err)
+	assert.True(t, linked)
+	assert.Equal(t, local.ID, u.ID)
+}
+
+func TestService_CodeNeedsVerifier(t *testing.T) {
+	s, p := newService(t)
+	p.AddUser(oidctest.User{Subject: "4", Email: "erin@example.com", EmailVerified: true})
+
+	first, _, err := s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+	second, binding, err := s.AuthorizationURL("fake", realworld.User{})
+	assert.NoError(t, err)
+
+	// A code intercepted from one login can't finish another.
+	_, code := authorize(t, first)
+	state, _ := authorize(t, second)
+
+	_, _, err = callback(s, state, code, binding)
+	assert.Equal(t, realworld.EUnauthorized, realworld.ErrorCode(err))
+}
+
+func mustQuery(t *testing.T, raw string) url.Values {
+	u, err := url.Parse(raw)
+	assert.NoError(t, err)
+	return u.Query()
+}
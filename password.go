@@ -0,0 +1,33 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidResetToken = Error{EInvalidToken, errors.New("invalid or expired reset token")}
+	ErrTooManyResets     = Error{ERateLimit, errors.New("too many password reset requests")}
+)
+
+// PasswordReset is a single-use token allowing a user to set a new
+// password. Only the hash of the token is stored.
+type PasswordReset struct {
+	ID        int64
+	UserID    int64
+	Hash      string
+	Used      bool
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (r PasswordReset) Expired() bool {
+	return time.Now().After(r.ExpiresAt)
+}
+
+type PasswordResetRepo interface {
+	Create(r PasswordReset) (*PasswordReset, error)
+	GetByHash(hash string) (*PasswordReset, error)
+	// Use marks the reset as used. It returns ErrInvalidResetToken if it already was.
+	Use(r PasswordReset) error
+}
@@ -0,0 +1,7 @@
+package gokit_realworld
+
+// RateLimiter counts attempts per key, such as an email address.
+type RateLimiter interface {
+	// Allow records an attempt for key and reports whether it's within the limit.
+	Allow(key string) bool
+}
@@ -0,0 +1,90 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	ErrReactionNotAllowed = Error{EInvalidReaction, errors.New("reaction is not allowed")}
+)
+
+// DefaultReactions is the set of reactions accepted when no other set is configured.
+var DefaultReactions = []string{"like", "clap", "insightful", "funny", "love"}
+
+// ReactionSet is the configurable set of reaction kinds users may add to
+// articles and comments.
+type ReactionSet map[string]struct{}
+
+func NewReactionSet(kinds ...string) ReactionSet {
+	rs := make(ReactionSet)
+	for _, k := range kinds {
+		rs[k] = struct{}{}
+	}
+	return rs
+}
+
+func (rs ReactionSet) Allowed(kind string) bool {
+	_, ok := rs[kind]
+	return ok
+}
+
+// Reactions maps a reaction kind to the IDs of the users who reacted with it.
+type Reactions map[string]map[int64]struct{}
+
+func (rr Reactions) Add(kind string, id int64) {
+	if rr[kind] == nil {
+		rr[kind] = make(map[int64]struct{})
+	}
+	rr[kind][id] = struct{}{}
+}
+
+func (rr Reactions) Remove(kind string, id int64) {
+	delete(rr[kind], id)
+	if len(rr[kind]) == 0 {
+		delete(rr, kind)
+	}
+}
+
+func (rr Reactions) ReactedBy(kind string, id int64) bool {
+	_, ok := rr[kind][id]
+	return ok
+}
+
+// Counts returns the number of users per reaction kind.
+func (rr Reactions) Counts() map[string]int {
+	counts := make(map[string]int)
+	for kind, users := range rr {
+		if len(users) > 0 {
+			counts[kind] = len(users)
+		}
+	}
+	return counts
+}
+
+// Total returns the number of reactions of every kind.
+func (rr Reactions) Total() (total int) {
+	for _, users := range rr {
+		total += len(users)
+	}
+	return
+}
+
+// By returns the sorted reaction kinds the given user has added.
+func (rr Reactions) By(id int64) []string {
+	kinds := make([]string, 0)
+	for kind, users := range rr {
+		if _, ok := users[id]; ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+type Reaction struct {
+	Kind      string
+	UserID    int64
+	ArticleID int64
+	CommentID int64
+}
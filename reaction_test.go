@@ -0,0 +1,21 @@
+package gokit_realworld
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReactions(t *testing.T) {
+	rr := make(Reactions)
+	rr.Add("like", 1)
+	rr.Add("like", 2)
+	rr.Add("clap", 1)
+
+	assert.Equal(t, map[string]int{"like": 2, "clap": 1}, rr.Counts())
+	assert.Equal(t, []string{"clap", "like"}, rr.By(1))
+	assert.Equal(t, 3, rr.Total())
+
+	rr.Remove("clap", 1)
+	assert.False(t, rr.ReactedBy("clap", 1))
+	assert.Equal(t, map[string]int{"like": 2}, rr.Counts())
+}
@@ -0,0 +1,56 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrBlocked            = Error{EForbidden, errors.New("you can't do this while either of you blocks the other")}
+	ErrSelfRestriction    = Error{EConflict, errors.New("you can't block or mute yourself")}
+	ErrInvalidRestriction = Error{EConflict, errors.New("unknown restriction")}
+)
+
+// Kinds of restrictions users can put on each other. Blocking keeps the users
+// from following or commenting on each other and hides their content from
+// each other. Muting only hides the muted user's content from the one who
+// muted them.
+const (
+	RestrictionBlock = "block"
+	RestrictionMute  = "mute"
+)
+
+func ValidRestriction(kind string) bool {
+	return kind == RestrictionBlock || kind == RestrictionMute
+}
+
+// Restriction is put by the user with UserID on the user with TargetID.
+type Restriction struct {
+	UserID    int64
+	TargetID  int64
+	Kind      string
+	CreatedAt time.Time
+}
+
+// RestrictionRequest has the user with UserID put a restriction of Kind on,
+// or lift it from, the user with Username.
+type RestrictionRequest struct {
+	Username string
+	UserID   int64
+	Kind     string
+}
+
+type RestrictionRepo interface {
+	// Add and Remove do nothing when there's nothing to change.
+	Add(r Restriction) error
+	Remove(r Restriction) error
+	// Exists reports whether the user with r.UserID put a restriction of
+	// r.Kind on the user with r.TargetID.
+	Exists(r Restriction) (bool, error)
+	// TargetIDs returns the IDs of the users the user with the given ID put
+	// restrictions of kind on.
+	TargetIDs(userID int64, kind string) ([]int64, error)
+	// UserIDs returns the IDs of the users who put restrictions of kind on
+	// the user with the given ID.
+	UserIDs(targetID int64, kind string) ([]int64, error)
+}
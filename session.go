@@ -0,0 +1,43 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrSessionNotFound = Error{ENotFound, errors.New("session not found")}
+)
+
+// Session is a login together with the refresh tokens rotated from it. Its ID
+// is the refresh token family and is carried by access tokens as "sid".
+type Session struct {
+	ID         string
+	UserID     int64
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+type SessionService interface {
+	Sessions(u User) ([]*Session, error)
+	RevokeSession(u User, id string) error
+	// RevokeSessions ends every session of the user.
+	RevokeSessions(u User) error
+	// Revoked reports whether any of the given access token or session IDs has been revoked.
+	Revoked(ids ...string) (bool, error)
+}
+
+// Revocation marks an access token ID or a session ID as no longer valid
+// until ExpiresAt, after which every token it could match has expired anyway.
+type Revocation struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+type RevocationRepo interface {
+	Add(r Revocation) error
+	Revoked(ids ...string) (bool, error)
+	// Active returns the revocations that haven't expired yet.
+	Active() ([]*Revocation, error)
+}
@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"strings"
+	"time"
+)
+
+type APIKey struct {
+	Model
+	UserID int64  `gorm:"index;not null"`
+	Name   string `gorm:"not null"`
+	Prefix string `gorm:"not null"`
+	Hash   string `gorm:"unique_index;not null"`
+	// Scopes is the comma separated list of scopes.
+	Scopes     string
+	LastUsedAt *time.Time
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func (s *apiKeyRepository) Create(k realworld.APIKey) (*realworld.APIKey, error) {
+	m := &APIKey{
+		UserID: k.UserID,
+		Name:   k.Name,
+		Prefix: k.Prefix,
+		Hash:   k.Hash,
+		Scopes: strings.Join(k.Scopes, ","),
+	}
+	if err := s.db.Create(m).Error; err != nil {
+		return nil, err
+	}
+	return domainAPIKey(m), nil
+}
+
+func (s *apiKeyRepository) GetByHash(hash string) (*realworld.APIKey, error) {
+	var m APIKey
+	if err := s.db.Where(&APIKey{Hash: hash}).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	return domainAPIKey(&m), nil
+}
+
+func (s *apiKeyRepository) ListByUserID(userID int64) ([]*realworld.APIKey, error) {
+	var ms []APIKey
+	if err := s.db.Where(&APIKey{UserID: userID}).Order("id").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	kk := make([]*realworld.APIKey, 0, len(ms))
+	for i := range ms {
+		kk = append(kk, domainAPIKey(&ms[i]))
+	}
+	return kk, nil
+}
+
+func (s *apiKeyRepository) Delete(userID, id int64) error {
+	// Unscoped, since a soft deleted key would still hold its unique hash.
+	res := s.db.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&APIKey{})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return realworld.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (s *apiKeyRepository) Touch(id int64, at time.Time) error {
+	return s.db.Model(&APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+func domainAPIKey(m *APIKey) *realworld.APIKey {
+	k := &realworld.APIKey{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Name:      m.Name,
+		Prefix:    m.Prefix,
+		Hash:      m.Hash,
+		CreatedAt: m.CreatedAt,
+	}
+	if m.Scopes != "" {
+		k.Scopes = strings.Split(m.Scopes, ",")
+	}
+	if m.LastUsedAt != nil {
+		k.LastUsedAt = *m.LastUsedAt
+	}
+	return k
+}
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"github.com/jinzhu/gorm"
 	realworld "github.com/xesina/gokit-realworld"
+	"time"
 )
 
 type Article struct {
@@ -14,17 +15,40 @@ type Article struct {
 	Author      User
 	AuthorID    int64
 	Comments    []Comment
-	Favorites   []User `gorm:"many2many:favorites;"`
-	Tags        []Tag  `gorm:"many2many:article_tags;association_autocreate:false"`
+	Favorites   []User     `gorm:"many2many:favorites;"`
+	Bookmarks   []User     `gorm:"many2many:bookmarks;"`
+	Reactions   []Reaction `gorm:"foreignkey:ArticleID"`
+	Tags        []Tag      `gorm:"many2many:article_tags;association_autocreate:false"`
 }
 
 type Comment struct {
 	Model
 	Article   Article
 	ArticleID int64
+	ParentID  int64 `gorm:"index"`
 	User      User
 	UserID    int64
 	Body      string
+	Deleted   bool
+	Edited    bool
+	Reactions []Reaction `gorm:"foreignkey:CommentID"`
+}
+
+type CommentEdit struct {
+	ID        int64 `gorm:"primary_key"`
+	CommentID int64 `gorm:"index;not null"`
+	Body      string
+	CreatedAt time.Time
+}
+
+// Reaction belongs to either an article or a comment; the other ID is left zero.
+type Reaction struct {
+	ID        int64  `gorm:"primary_key"`
+	Kind      string `gorm:"not null;unique_index:idx_reaction"`
+	UserID    int64  `gorm:"not null;unique_index:idx_reaction"`
+	ArticleID int64  `gorm:"unique_index:idx_reaction"`
+	CommentID int64  `gorm:"unique_index:idx_reaction"`
+	CreatedAt time.Time
 }
 
 type Tag struct {
@@ -40,7 +64,7 @@ type articleRepository struct {
 func (s articleRepository) Get(slug string) (*realworld.Article, error) {
 	var m Article
 
-	err := s.db.Where(&Article{Slug: slug}).Preload("Favorites").Preload("Tags").Preload("Author").Find(&m).Error
+	err := s.db.Where(&Article{Slug: slug}).Preload("Favorites").Preload("Bookmarks").Preload("Reactions").Preload("Tags").Preload("Author").Find(&m).Error
 	if err != nil {
 		if gorm.IsRecordNotFoundError(err) {
 			return nil, realworld.ErrArticleNotFound
@@ -56,6 +80,8 @@ func (s articleRepository) List(offset, limit int) ([]*realworld.Article, int, e
 	var articles []Article
 
 	err := s.db.Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Offset(offset).
@@ -88,6 +114,8 @@ func (s articleRepository) ListByTag(tag string, offset, limit int) ([]*realworl
 
 	err = s.db.Model(&t).
 		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Offset(offset).
@@ -111,6 +139,8 @@ func (s articleRepository) ListByAuthorID(id int64, offset, limit int) ([]*realw
 
 	err := s.db.Where(Article{AuthorID: id}).
 		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Offset(offset).
@@ -128,11 +158,19 @@ func (s articleRepository) ListByAuthorID(id int64, offset, limit int) ([]*realw
 	return s.domainArticles(articles), len(articles), nil
 }
 
+func (s articleRepository) CountByAuthorID(id int64) (int, error) {
+	var count int
+	err := s.db.Model(&Article{}).Where("author_id = ?", id).Count(&count).Error
+	return count, err
+}
+
 func (s articleRepository) ListByFavoriterID(id int64, offset, limit int) ([]*realworld.Article, int, error) {
 	var articles []Article
 
 	err := s.db.Model(&User{Model: Model{ID: id}}).
 		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Offset(offset).
@@ -151,6 +189,33 @@ func (s articleRepository) ListByFavoriterID(id int64, offset, limit int) ([]*re
 	return s.domainArticles(articles), len(articles), nil
 }
 
+func (s articleRepository) ListByBookmarkerID(id int64, offset, limit int) ([]*realworld.Article, int, error) {
+	var articles []Article
+
+	err := s.db.Model(&User{Model: Model{ID: id}}).
+		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
+		Preload("Tags").
+		Preload("Author").
+		Offset(offset).
+		Limit(limit).
+		Order("created_at desc, id desc").
+		Association("Bookmarks").
+		Find(&articles).Error
+
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return []*realworld.Article{}, 0, nil
+		}
+		return nil, 0, err
+	}
+
+	count := s.db.Model(&User{Model: Model{ID: id}}).Association("Bookmarks").Count()
+
+	return s.domainArticles(articles), count, nil
+}
+
 func (s articleRepository) Feed(req realworld.FeedRequest) ([]*realworld.Article, int, error) {
 	var (
 		u        User
@@ -191,6 +256,8 @@ func (s articleRepository) Feed(req realworld.FeedRequest) ([]*realworld.Article
 
 	err = s.db.Where("author_id in (?)", ids).
 		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Offset(req.Offset).
@@ -247,6 +314,8 @@ func (s articleRepository) Create(a realworld.Article) (*realworld.Article, erro
 
 	err = tx.Where(m.ID).
 		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Find(m).Error
@@ -306,6 +375,8 @@ func (s articleRepository) Update(slug string, a realworld.Article) (*realworld.
 
 	err = tx.Where(m.ID).
 		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Find(m).Error
@@ -342,6 +413,8 @@ func (s articleRepository) AddFavorite(a realworld.Article, u realworld.User) (*
 
 	err = s.db.Where(m.ID).
 		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Find(&m).Error
@@ -362,13 +435,190 @@ func (s articleRepository) RemoveFavorite(a realworld.Article, u realworld.User)
 
 	err = s.db.Where(m.ID).
 		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
+		Preload("Tags").
+		Preload("Author").
+		Find(&m).Error
+
+	return s.domainArticle(&m), nil
+}
+
+func (s articleRepository) AddBookmark(a realworld.Article, u realworld.User) (*realworld.Article, error) {
+	var m Article
+	err := s.db.Where("slug = ?", a.Slug).First(&m).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.db.Model(&m).Association("Bookmarks").Append(userModel(&u)).Error; err != nil {
+		return nil, err
+	}
+
+	err = s.db.Where(m.ID).
+		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
 		Preload("Tags").
 		Preload("Author").
 		Find(&m).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s.domainArticle(&m), nil
+}
+
+func (s articleRepository) RemoveBookmark(a realworld.Article, u realworld.User) (*realworld.Article, error) {
+	var m Article
+	err := s.db.Where("slug = ?", a.Slug).First(&m).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.db.Model(&m).Association("Bookmarks").Delete(userModel(&u)).Error; err != nil {
+		return nil, err
+	}
+
+	err = s.db.Where(m.ID).
+		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
+		Preload("Tags").
+		Preload("Author").
+		Find(&m).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s.domainArticle(&m), nil
+}
+
+func (s articleRepository) AddReaction(r realworld.Reaction, a realworld.Article) (*realworld.Article, error) {
+	var m Article
+	err := s.db.Where("slug = ?", a.Slug).First(&m).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	rm := Reaction{Kind: r.Kind, UserID: r.UserID, ArticleID: m.ID}
+	if err := s.db.Where(rm).FirstOrCreate(&rm).Error; err != nil {
+		return nil, err
+	}
+
+	return s.getByID(m.ID)
+}
+
+func (s articleRepository) RemoveReaction(r realworld.Reaction, a realworld.Article) (*realworld.Article, error) {
+	var m Article
+	err := s.db.Where("slug = ?", a.Slug).First(&m).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	err = s.db.Where("kind = ? AND user_id = ? AND article_id = ?", r.Kind, r.UserID, m.ID).
+		Delete(Reaction{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getByID(m.ID)
+}
+
+func (s articleRepository) AddCommentReaction(r realworld.Reaction, c realworld.Comment) (*realworld.Comment, error) {
+	cm, err := s.getComment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	rm := Reaction{Kind: r.Kind, UserID: r.UserID, CommentID: cm.ID}
+	if err := s.db.Where(rm).FirstOrCreate(&rm).Error; err != nil {
+		return nil, err
+	}
+
+	cm, err = s.getComment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.domainComment(cm), nil
+}
+
+func (s articleRepository) RemoveCommentReaction(r realworld.Reaction, c realworld.Comment) (*realworld.Comment, error) {
+	cm, err := s.getComment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Where("kind = ? AND user_id = ? AND comment_id = ?", r.Kind, r.UserID, cm.ID).
+		Delete(Reaction{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err = s.getComment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.domainComment(cm), nil
+}
+
+func (s articleRepository) getByID(id int64) (*realworld.Article, error) {
+	var m Article
+
+	err := s.db.Where(id).
+		Preload("Favorites").
+		Preload("Bookmarks").
+		Preload("Reactions").
+		Preload("Tags").
+		Preload("Author").
+		First(&m).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrArticleNotFound
+		}
+		return nil, err
+	}
 
 	return s.domainArticle(&m), nil
 }
 
+// getComment loads a comment making sure it belongs to the article with the comment's slug.
+func (s articleRepository) getComment(c realworld.Comment) (*Comment, error) {
+	var article Article
+	err := s.db.Where("slug = ?", c.Article.Slug).First(&article).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	var cm Comment
+	err = s.db.Where("id = ? AND article_id = ?", c.ID, article.ID).Preload("Reactions").First(&cm).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	return &cm, nil
+}
+
 func (s articleRepository) AddComment(c realworld.Comment) (*realworld.Comment, error) {
 	var article Article
 
@@ -398,14 +648,153 @@ func (s articleRepository) AddComment(c realworld.Comment) (*realworld.Comment,
 	return s.domainComment(cm), nil
 }
 
+func (s articleRepository) Comment(c realworld.Comment) (*realworld.Comment, error) {
+	cm, err := s.getComment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.domainComment(cm), nil
+}
+
+func (s articleRepository) UpdateComment(c realworld.Comment) (*realworld.Comment, error) {
+	cm, err := s.getComment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Model(cm).Updates(map[string]interface{}{
+		"body":    c.Body,
+		"deleted": c.Deleted,
+		"edited":  c.Edited,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err = s.getComment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.domainComment(cm), nil
+}
+
+func (s articleRepository) AddCommentEdit(e realworld.CommentEdit) (*realworld.CommentEdit, error) {
+	m := CommentEdit{CommentID: e.CommentID, Body: e.Body}
+	if err := s.db.Create(&m).Error; err != nil {
+		return nil, err
+	}
+
+	return s.domainCommentEdit(&m), nil
+}
+
+func (s articleRepository) CommentEdits(c realworld.Comment) ([]*realworld.CommentEdit, error) {
+	var edits []CommentEdit
+	if err := s.db.Where(&CommentEdit{CommentID: c.ID}).Order("id").Find(&edits).Error; err != nil {
+		return nil, err
+	}
+
+	ee := make([]*realworld.CommentEdit, 0)
+	for _, e := range edits {
+		ee = append(ee, s.domainCommentEdit(&e))
+	}
+
+	return ee, nil
+}
+
+// DeleteComment is for good, so the body doesn't linger in a soft deleted row.
 func (s articleRepository) DeleteComment(c realworld.Comment) error {
 	cm := s.commentModel(&c)
-	return s.db.Delete(cm).Error
+	return s.db.Unscoped().Delete(cm).Error
+}
+
+func (s articleRepository) DeleteCommentEdits(c realworld.Comment) error {
+	return s.db.Where("comment_id = ?", c.ID).Delete(&CommentEdit{}).Error
+}
+
+func (s articleRepository) CommentsByUserID(id int64) ([]*realworld.Comment, error) {
+	var cc []Comment
+	err := s.db.Joins("JOIN articles ON articles.id = comments.article_id AND articles.deleted_at IS NULL").
+		Where("comments.user_id = ?", id).
+		Preload("Article").
+		Preload("Reactions").
+		Order("comments.created_at asc, comments.id asc").
+		Find(&cc).Error
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*realworld.Comment, 0, len(cc))
+	for i := range cc {
+		c := s.domainComment(&cc[i])
+		c.Article = realworld.Article{ID: cc[i].Article.ID, Slug: cc[i].Article.Slug}
+		comments = append(comments, c)
+	}
+
+	return comments, nil
+}
+
+func (s articleRepository) DeleteByAuthorID(id int64) error {
+	var articleIDs, commentIDs []int64
+	if err := s.db.Unscoped().Model(&Article{}).Where("author_id = ?", id).Pluck("id", &articleIDs).Error; err != nil {
+		return err
+	}
+	if len(articleIDs) == 0 {
+		return nil
+	}
+	if err := s.db.Unscoped().Model(&Comment{}).Where("article_id IN (?)", articleIDs).Pluck("id", &commentIDs).Error; err != nil {
+		return err
+	}
+
+	return transaction(s.db,
+		func(tx *gorm.DB) error {
+			return tx.Where("comment_id IN (?)", commentIDs).Delete(&CommentEdit{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Where("comment_id IN (?) OR article_id IN (?)", commentIDs, articleIDs).Delete(&Reaction{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Unscoped().Where("id IN (?)", commentIDs).Delete(&Comment{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM favorites WHERE article_id IN (?)", articleIDs).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM bookmarks WHERE article_id IN (?)", articleIDs).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM article_tags WHERE article_id IN (?)", articleIDs).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Unscoped().Where("id IN (?)", articleIDs).Delete(&Article{}).Error
+		},
+	)
+}
+
+func (s articleRepository) ForgetUser(id int64) error {
+	return transaction(s.db,
+		func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM favorites WHERE user_id = ?", id).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM bookmarks WHERE user_id = ?", id).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Where("user_id = ?", id).Delete(&Reaction{}).Error
+		},
+	)
 }
 
 func (s articleRepository) Comments(a realworld.Article) ([]*realworld.Comment, error) {
 	var m Article
-	err := s.db.Where(&Article{Slug: a.Slug}).Preload("Comments").Preload("Comments.User").First(&m).Error
+	err := s.db.Where(&Article{Slug: a.Slug}).
+		Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("comments.created_at asc, comments.id asc")
+		}).
+		Preload("Comments.User").
+		Preload("Comments.Reactions").
+		First(&m).Error
 
 	if err != nil {
 		if gorm.IsRecordNotFoundError(err) {
@@ -453,6 +842,8 @@ func (s *articleRepository) domainArticle(m *Article) *realworld.Article {
 		Body:        m.Body,
 		Author:      realworld.User{ID: m.AuthorID},
 		Favorites:   s.favoriteMap(m.Favorites),
+		Bookmarks:   s.bookmarkMap(m.Bookmarks),
+		Reactions:   s.reactionMap(m.Reactions),
 		Tags:        s.tagMap(m.Tags),
 		CreatedAt:   m.CreatedAt,
 		UpdatedAt:   m.UpdatedAt,
@@ -477,6 +868,22 @@ func (s *articleRepository) favoriteMap(ff []User) realworld.Favorites {
 	return fm
 }
 
+func (s *articleRepository) bookmarkMap(bb []User) realworld.Bookmarks {
+	bm := make(realworld.Bookmarks)
+	for _, b := range bb {
+		bm[b.ID] = struct{}{}
+	}
+	return bm
+}
+
+func (s *articleRepository) reactionMap(rr []Reaction) realworld.Reactions {
+	rm := make(realworld.Reactions)
+	for _, r := range rr {
+		rm.Add(r.Kind, r.UserID)
+	}
+	return rm
+}
+
 func (s *articleRepository) tagMap(tt []Tag) realworld.Tags {
 	tm := make(realworld.Tags)
 	for _, t := range tt {
@@ -504,6 +911,7 @@ func (s *articleRepository) commentModel(c *realworld.Comment) *Comment {
 	return &Comment{
 		Model:     Model{ID: c.ID},
 		ArticleID: c.ArticleID,
+		ParentID:  c.ParentID,
 		UserID:    c.UserID,
 		Body:      c.Body,
 	}
@@ -513,13 +921,26 @@ func (s *articleRepository) domainComment(c *Comment) *realworld.Comment {
 	return &realworld.Comment{
 		ID:        c.ID,
 		ArticleID: c.ArticleID,
+		ParentID:  c.ParentID,
 		UserID:    c.UserID,
 		Body:      c.Body,
+		Deleted:   c.Deleted,
+		Edited:    c.Edited,
+		Reactions: s.reactionMap(c.Reactions),
 		CreatedAt: c.CreatedAt,
 		UpdatedAt: c.UpdatedAt,
 	}
 }
 
+func (s *articleRepository) domainCommentEdit(e *CommentEdit) *realworld.CommentEdit {
+	return &realworld.CommentEdit{
+		ID:        e.ID,
+		CommentID: e.CommentID,
+		Body:      e.Body,
+		EditedAt:  e.CreatedAt,
+	}
+}
+
 func (s *articleRepository) domainTags(tt []Tag) []*realworld.Tag {
 	tags := make([]*realworld.Tag, 0)
 
@@ -539,8 +960,12 @@ func (s *articleRepository) domainComments(cc []Comment) []*realworld.Comment {
 		comments = append(comments, &realworld.Comment{
 			ID:        c.ID,
 			ArticleID: c.ArticleID,
+			ParentID:  c.ParentID,
 			UserID:    c.UserID,
 			Body:      c.Body,
+			Deleted:   c.Deleted,
+			Edited:    c.Edited,
+			Reactions: s.reactionMap(c.Reactions),
 			CreatedAt: c.CreatedAt,
 			UpdatedAt: c.UpdatedAt,
 		})
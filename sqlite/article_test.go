@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"testing"
+)
+
+func TestArticleRepository_Bookmarks(t *testing.T) {
+	s := newTestStorage(t)
+	users := s.NewUserRepository()
+	repo := s.NewArticleRepository()
+
+	alice, err := users.Create(realworld.User{Username: "alice", Email: "alice@example.com", Password: "x"})
+	assert.NoError(t, err)
+	bob, err := users.Create(realworld.User{Username: "bob", Email: "bob@example.com", Password: "x"})
+	assert.NoError(t, err)
+
+	var slugs []string
+	for i := 1; i <= 3; i++ {
+		slug := fmt.Sprintf("article-%d", i)
+		_, err := repo.Create(realworld.Article{Slug: slug, Title: slug, Body: "body", Author: *alice})
+		assert.NoError(t, err)
+		slugs = append(slugs, slug)
+	}
+
+	for _, slug := range slugs {
+		_, err := repo.AddBookmark(realworld.Article{Slug: slug}, *alice)
+		assert.NoError(t, err)
+	}
+	// bookmarking twice changes nothing
+	a, err := repo.AddBookmark(realworld.Article{Slug: slugs[0]}, *alice)
+	assert.NoError(t, err)
+	assert.True(t, a.Bookmarked(alice.ID))
+	assert.False(t, a.Bookmarked(bob.ID))
+
+	aa, count, err := repo.ListByBookmarkerID(alice.ID, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	if assert.Len(t, aa, 2) {
+		assert.Equal(t, "article-3", aa[0].Slug)
+		assert.Equal(t, "article-2", aa[1].Slug)
+	}
+	aa, count, err = repo.ListByBookmarkerID(alice.ID, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	if assert.Len(t, aa, 1) {
+		assert.Equal(t, "article-1", aa[0].Slug)
+	}
+
+	// bookmarks are private to whoever made them
+	aa, count, err = repo.ListByBookmarkerID(bob.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Empty(t, aa)
+
+	for i := 0; i < 2; i++ {
+		a, err := repo.RemoveBookmark(realworld.Article{Slug: slugs[1]}, *alice)
+		assert.NoError(t, err)
+		assert.False(t, a.Bookmarked(alice.ID))
+	}
+	_, count, err = repo.ListByBookmarkerID(alice.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = repo.AddBookmark(realworld.Article{Slug: "missing"}, *alice)
+	assert.Equal(t, realworld.ErrArticleNotFound, err)
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type Attachment struct {
+	Model
+	ArticleID   int64  `gorm:"index;not null"`
+	UserID      int64  `gorm:"index;not null"`
+	Key         string `gorm:"unique_index;not null"`
+	ContentType string `gorm:"not null"`
+	Size        int64  `gorm:"not null"`
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func (s *attachmentRepository) Create(a realworld.Attachment, quota int64) (*realworld.Attachment, error) {
+	// A single statement, so the sum it checks can't change before the row
+	// is inserted.
+	now := time.Now()
+	res := s.db.Exec(`INSERT INTO attachments (created_at, updated_at, article_id, user_id, "key", content_type, size)
+		SELECT ?, ?, ?, ?, ?, ?, ?
+		WHERE (SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = ? AND deleted_at IS NULL) + ? <= ?`,
+		now, now, a.ArticleID, a.UserID, a.Key, a.ContentType, a.Size,
+		a.UserID, a.Size, quota,
+	)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, realworld.ErrQuotaExceeded
+	}
+
+	var m Attachment
+	if err := s.db.Where(&Attachment{Key: a.Key}).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return domainAttachment(&m), nil
+}
+
+func (s *attachmentRepository) Get(articleID, id int64) (*realworld.Attachment, error) {
+	var m Attachment
+	if err := s.db.Where("id = ? AND article_id = ?", id, articleID).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return domainAttachment(&m), nil
+}
+
+func (s *attachmentRepository) ListByArticleID(id int64) ([]*realworld.Attachment, error) {
+	return s.list(&Attachment{ArticleID: id})
+}
+
+func (s *attachmentRepository) ListByUserID(id int64) ([]*realworld.Attachment, error) {
+	return s.list(&Attachment{UserID: id})
+}
+
+func (s *attachmentRepository) list(where *Attachment) ([]*realworld.Attachment, error) {
+	var ms []Attachment
+	if err := s.db.Where(where).Order("id").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	aa := make([]*realworld.Attachment, 0, len(ms))
+	for i := range ms {
+		aa = append(aa, domainAttachment(&ms[i]))
+	}
+	return aa, nil
+}
+
+func (s *attachmentRepository) Delete(id int64) error {
+	// Unscoped, since the file of a soft deleted attachment would be gone.
+	res := s.db.Unscoped().Where("id = ?", id).Delete(&Attachment{})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return realworld.ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+func domainAttachment(m *Attachment) *realworld.Attachment {
+	return &realworld.Attachment{
+		ID:          m.ID,
+		ArticleID:   m.ArticleID,
+		UserID:      m.UserID,
+		Key:         m.Key,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		CreatedAt:   m.CreatedAt,
+	}
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"sync"
+	"testing"
+)
+
+func TestAttachmentRepository_CreateQuota(t *testing.T) {
+	repo := newTestStorage(t).NewAttachmentRepository()
+
+	// Uploads racing for what's left of the quota can't all get in.
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		created  int
+		exceeded int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := repo.Create(realworld.Attachment{
+				ArticleID:   1,
+				UserID:      1,
+				Key:         fmt.Sprintf("attachments/1/%d.png", i),
+				ContentType: "image/png",
+				Size:        100,
+			}, 450)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				created++
+			case realworld.ErrQuotaExceeded:
+				exceeded++
+			default:
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 4, created)
+	assert.Equal(t, 6, exceeded)
+
+	// Other users have quotas of their own.
+	a, err := repo.Create(realworld.Attachment{ArticleID: 2, UserID: 2, Key: "attachments/2/a.png", ContentType: "image/png", Size: 450}, 450)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), a.UserID)
+	assert.Equal(t, int64(450), a.Size)
+
+	aa, err := repo.ListByUserID(1)
+	assert.NoError(t, err)
+	assert.Len(t, aa, 4)
+}
@@ -34,8 +34,23 @@ func (s *Storage) Migrate() {
 		&Follow{},
 		&Article{},
 		&Comment{},
+		&CommentEdit{},
+		&Reaction{},
 		&Tag{},
+		&RefreshToken{},
+		&Revocation{},
+		&PasswordReset{},
+		&EmailVerification{},
+		&TwoFactor{},
+		&LoginChallenge{},
+		&APIKey{},
+		&Identity{},
+		&OAuthState{},
+		&Restriction{},
+		&Attachment{},
+		&UsernameChange{},
 	)
+	migrateSearch(s.DB)
 }
 
 func (s *Storage) NewUserRepository() realworld.UserRepo {
@@ -49,3 +64,93 @@ func (s *Storage) NewArticleRepository() realworld.ArticleRepo {
 		db: s.DB,
 	}
 }
+
+func (s *Storage) NewRefreshTokenRepository() realworld.RefreshTokenRepo {
+	return &refreshTokenRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewRevocationRepository() realworld.RevocationRepo {
+	return &revocationRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewPasswordResetRepository() realworld.PasswordResetRepo {
+	return &passwordResetRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewEmailVerificationRepository() realworld.EmailVerificationRepo {
+	return &emailVerificationRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewTwoFactorRepository() realworld.TwoFactorRepo {
+	return &twoFactorRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewLoginChallengeRepository() realworld.LoginChallengeRepo {
+	return &loginChallengeRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewAPIKeyRepository() realworld.APIKeyRepo {
+	return &apiKeyRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewIdentityRepository() realworld.IdentityRepo {
+	return &identityRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewOAuthStateRepository() realworld.OAuthStateRepo {
+	return &oauthStateRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewRestrictionRepository() realworld.RestrictionRepo {
+	return &restrictionRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewAttachmentRepository() realworld.AttachmentRepo {
+	return &attachmentRepository{
+		db: s.DB,
+	}
+}
+
+func (s *Storage) NewUsernameHistoryRepository() realworld.UsernameHistoryRepo {
+	return &usernameHistoryRepository{
+		db: s.DB,
+	}
+}
+
+// transaction runs the steps in a single transaction, which is rolled back
+// as soon as one of them fails.
+func transaction(db *gorm.DB, steps ...func(tx *gorm.DB) error) error {
+	tx := db.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		if err := step(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type Identity struct {
+	Model
+	UserID   int64  `gorm:"unique_index:idx_identity_user_provider;not null"`
+	Provider string `gorm:"unique_index:idx_identity_user_provider;unique_index:idx_identity_subject;not null"`
+	Subject  string `gorm:"unique_index:idx_identity_subject;not null"`
+	Email    string
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func (s *identityRepository) Create(i realworld.Identity) (*realworld.Identity, error) {
+	var count int
+	err := s.db.Model(&Identity{}).
+		Where("provider = ? AND (subject = ? OR user_id = ?)", i.Provider, i.Subject, i.UserID).
+		Count(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, realworld.ErrIdentityLinked
+	}
+
+	m := &Identity{
+		UserID:   i.UserID,
+		Provider: i.Provider,
+		Subject:  i.Subject,
+		Email:    i.Email,
+	}
+	if err := s.db.Create(m).Error; err != nil {
+		return nil, err
+	}
+	return domainIdentity(m), nil
+}
+
+func (s *identityRepository) Get(provider, subject string) (*realworld.Identity, error) {
+	var m Identity
+	if err := s.db.Where(&Identity{Provider: provider, Subject: subject}).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrIdentityNotFound
+		}
+		return nil, err
+	}
+	return domainIdentity(&m), nil
+}
+
+func (s *identityRepository) ListByUserID(userID int64) ([]*realworld.Identity, error) {
+	var ms []Identity
+	if err := s.db.Where(&Identity{UserID: userID}).Order("id").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	ii := make([]*realworld.Identity, 0, len(ms))
+	for i := range ms {
+		ii = append(ii, domainIdentity(&ms[i]))
+	}
+	return ii, nil
+}
+
+func (s *identityRepository) Delete(userID int64, provider string) error {
+	// Unscoped, since a soft deleted identity would still hold its unique indexes.
+	res := s.db.Unscoped().Where("user_id = ? AND provider = ?", userID, provider).Delete(&Identity{})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return realworld.ErrIdentityNotFound
+	}
+
+	return nil
+}
+
+func domainIdentity(m *Identity) *realworld.Identity {
+	return &realworld.Identity{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Provider:  m.Provider,
+		Subject:   m.Subject,
+		Email:     m.Email,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+type OAuthState struct {
+	Hash        string `gorm:"primary_key"`
+	Provider    string `gorm:"not null"`
+	Verifier    string `gorm:"not null"`
+	Nonce       string `gorm:"not null"`
+	BindingHash string
+	LinkUserID  int64
+	ExpiresAt   time.Time `gorm:"index;not null"`
+}
+
+type oauthStateRepository struct {
+	db *gorm.DB
+}
+
+func (s *oauthStateRepository) Create(st realworld.OAuthState) error {
+	// States left behind by logins nobody finished aren't needed anymore.
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&OAuthState{}).Error; err != nil {
+		return err
+	}
+
+	return s.db.Create(&OAuthState{
+		Hash:        st.Hash,
+		Provider:    st.Provider,
+		Verifier:    st.Verifier,
+		Nonce:       st.Nonce,
+		BindingHash: st.BindingHash,
+		LinkUserID:  st.LinkUserID,
+		ExpiresAt:   st.ExpiresAt,
+	}).Error
+}
+
+func (s *oauthStateRepository) Take(hash string) (*realworld.OAuthState, error) {
+	var m OAuthState
+	if err := s.db.Where(&OAuthState{Hash: hash}).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrInvalidOAuthState
+		}
+		return nil, err
+	}
+
+	// Whoever deletes the row gets to use it.
+	res := s.db.Where(&OAuthState{Hash: hash}).Delete(&OAuthState{})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, realworld.ErrInvalidOAuthState
+	}
+
+	return &realworld.OAuthState{
+		Hash:        m.Hash,
+		Provider:    m.Provider,
+		Verifier:    m.Verifier,
+		Nonce:       m.Nonce,
+		BindingHash: m.BindingHash,
+		LinkUserID:  m.LinkUserID,
+		ExpiresAt:   m.ExpiresAt,
+	}, nil
+}
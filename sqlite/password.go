@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type PasswordReset struct {
+	Model
+	UserID    int64  `gorm:"index;not null"`
+	Hash      string `gorm:"unique_index;not null"`
+	Used      bool
+	ExpiresAt time.Time
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func (s *passwordResetRepository) Create(r realworld.PasswordReset) (*realworld.PasswordReset, error) {
+	m := &PasswordReset{
+		UserID:    r.UserID,
+		Hash:      r.Hash,
+		ExpiresAt: r.ExpiresAt,
+	}
+	if err := s.db.Create(m).Error; err != nil {
+		return nil, err
+	}
+	return domainPasswordReset(m), nil
+}
+
+func (s *passwordResetRepository) GetByHash(hash string) (*realworld.PasswordReset, error) {
+	var m PasswordReset
+	if err := s.db.Where(&PasswordReset{Hash: hash}).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrInvalidResetToken
+		}
+		return nil, err
+	}
+	return domainPasswordReset(&m), nil
+}
+
+func (s *passwordResetRepository) Use(r realworld.PasswordReset) error {
+	// Checked and set in one statement so a token can't be used twice concurrently.
+	res := s.db.Model(&PasswordReset{}).
+		Where("id = ? AND used = ?", r.ID, false).
+		Update("used", true)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return realworld.ErrInvalidResetToken
+	}
+
+	return nil
+}
+
+func domainPasswordReset(m *PasswordReset) *realworld.PasswordReset {
+	return &realworld.PasswordReset{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Hash:      m.Hash,
+		Used:      m.Used,
+		ExpiresAt: m.ExpiresAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
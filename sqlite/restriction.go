@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type Restriction struct {
+	UserID    int64  `gorm:"primary_key;auto_increment:false"`
+	TargetID  int64  `gorm:"primary_key;auto_increment:false;index"`
+	Kind      string `gorm:"primary_key"`
+	CreatedAt time.Time
+}
+
+type restrictionRepository struct {
+	db *gorm.DB
+}
+
+func (s *restrictionRepository) Add(r realworld.Restriction) error {
+	m := Restriction{UserID: r.UserID, TargetID: r.TargetID, Kind: r.Kind}
+	return s.db.Where(&m).FirstOrCreate(&m).Error
+}
+
+func (s *restrictionRepository) Remove(r realworld.Restriction) error {
+	return s.db.
+		Where("user_id = ? AND target_id = ? AND kind = ?", r.UserID, r.TargetID, r.Kind).
+		Delete(&Restriction{}).Error
+}
+
+func (s *restrictionRepository) Exists(r realworld.Restriction) (bool, error) {
+	var count int
+	err := s.db.Model(&Restriction{}).
+		Where("user_id = ? AND target_id = ? AND kind = ?", r.UserID, r.TargetID, r.Kind).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *restrictionRepository) TargetIDs(userID int64, kind string) ([]int64, error) {
+	var ids []int64
+	err := s.db.Model(&Restriction{}).
+		Where("user_id = ? AND kind = ?", userID, kind).
+		Order("target_id").
+		Pluck("target_id", &ids).Error
+	return ids, err
+}
+
+func (s *restrictionRepository) UserIDs(targetID int64, kind string) ([]int64, error) {
+	var ids []int64
+	err := s.db.Model(&Restriction{}).
+		Where("target_id = ? AND kind = ?", targetID, kind).
+		Order("user_id").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type Revocation struct {
+	ID        string    `gorm:"primary_key"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+type revocationRepository struct {
+	db *gorm.DB
+}
+
+func (s *revocationRepository) Add(r realworld.Revocation) error {
+	// Revoking the same ID twice keeps the later expiry.
+	return s.db.Save(&Revocation{ID: r.ID, ExpiresAt: r.ExpiresAt}).Error
+}
+
+func (s *revocationRepository) Revoked(ids ...string) (bool, error) {
+	if len(ids) == 0 {
+		return false, nil
+	}
+
+	var count int
+	err := s.db.Model(&Revocation{}).
+		Where("id IN (?) AND expires_at > ?", ids, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *revocationRepository) Active() ([]*realworld.Revocation, error) {
+	var ms []Revocation
+	if err := s.db.Where("expires_at > ?", time.Now()).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	rs := make([]*realworld.Revocation, 0, len(ms))
+	for _, m := range ms {
+		rs = append(rs, &realworld.Revocation{ID: m.ID, ExpiresAt: m.ExpiresAt})
+	}
+	return rs, nil
+}
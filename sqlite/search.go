@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"strings"
+)
+
+// likeEscaper escapes what LIKE would otherwise take for wildcards.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// migrateSearch adds the index username prefixes are looked up with. LIKE
+// ignores case, so only an index that does too can be used for it.
+func migrateSearch(db *gorm.DB) {
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_users_username_nocase ON users (username COLLATE NOCASE)")
+}
+
+func (s *userRepository) ListByUsernamePrefix(prefix string, limit int) ([]*realworld.User, error) {
+	var mm []User
+	err := s.db.
+		Where(`username LIKE ? ESCAPE '\' AND banned = ?`, likeEscaper.Replace(prefix)+"%", false).
+		Preload("Followers").
+		Order("username COLLATE NOCASE").
+		Limit(limit).
+		Find(&mm).Error
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*realworld.User, 0, len(mm))
+	for i := range mm {
+		users = append(users, s.domainUser(&mm[i]))
+	}
+
+	return users, nil
+}
+
+func (s *userRepository) Search(r realworld.UserSearchRequest) ([]*realworld.User, int, error) {
+	query := likeEscaper.Replace(r.Query)
+
+	q := s.db.Model(&User{}).
+		Where(`users.banned = ? AND (users.username LIKE ? ESCAPE '\' OR users.bio LIKE ? ESCAPE '\')`,
+			false, "%"+query+"%", "%"+query+"%")
+
+	var total int
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Followed users first, then those whose username starts with the query,
+	// then those whose username contains it.
+	var mm []User
+	err := q.Select("users.*").
+		Joins("LEFT JOIN follows ON follows.following_id = users.id AND follows.follower_id = ?", r.ViewerID).
+		Preload("Followers").
+		Order("follows.follower_id IS NULL").
+		Order(gorm.Expr(`CASE WHEN users.username LIKE ? ESCAPE '\' THEN 0 WHEN users.username LIKE ? ESCAPE '\' THEN 1 ELSE 2 END`,
+			query+"%", "%"+query+"%")).
+		Order("users.username COLLATE NOCASE").
+		Order("users.id").
+		Offset(r.Offset).
+		Limit(r.Limit).
+		Find(&mm).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users := make([]*realworld.User, 0, len(mm))
+	for i := range mm {
+		users = append(users, s.domainUser(&mm[i]))
+	}
+
+	return users, total, nil
+}
@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestStorage returns migrated storage in a temporary database that is
+// removed once the test is done.
+func newTestStorage(t *testing.T) *Storage {
+	dir, err := ioutil.TempDir("", "sqlite")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	s, err := NewStorage(filepath.Join(dir, "test.db"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { s.DB.Close() })
+	s.DB.LogMode(false)
+	s.Migrate()
+	return s
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type RefreshToken struct {
+	Model
+	UserID    int64  `gorm:"index;not null"`
+	Family    string `gorm:"index;not null"`
+	Hash      string `gorm:"unique_index;not null"`
+	Used      bool
+	Revoked   bool
+	ExpiresAt time.Time
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func (s *refreshTokenRepository) Create(t realworld.RefreshToken) (*realworld.RefreshToken, error) {
+	m := refreshTokenModel(&t)
+	if err := s.db.Create(m).Error; err != nil {
+		return nil, err
+	}
+	return domainRefreshToken(m), nil
+}
+
+func (s *refreshTokenRepository) GetByHash(hash string) (*realworld.RefreshToken, error) {
+	var m RefreshToken
+	if err := s.db.Where(&RefreshToken{Hash: hash}).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return domainRefreshToken(&m), nil
+}
+
+func (s *refreshTokenRepository) Use(t realworld.RefreshToken) error {
+	// The used flag is checked and set in one statement so two concurrent
+	// refreshes with the same token can't both succeed.
+	res := s.db.Model(&RefreshToken{}).
+		Where("id = ? AND used = ?", t.ID, false).
+		Update("used", true)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return realworld.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (s *refreshTokenRepository) ListByUserID(id int64) ([]*realworld.RefreshToken, error) {
+	var ms []RefreshToken
+	if err := s.db.Where(&RefreshToken{UserID: id}).Order("id").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*realworld.RefreshToken, 0, len(ms))
+	for i := range ms {
+		tokens = append(tokens, domainRefreshToken(&ms[i]))
+	}
+	return tokens, nil
+}
+
+func (s *refreshTokenRepository) RevokeFamily(family string) error {
+	return s.db.Model(&RefreshToken{}).
+		Where("family = ?", family).
+		Update("revoked", true).Error
+}
+
+func refreshTokenModel(t *realworld.RefreshToken) *RefreshToken {
+	return &RefreshToken{
+		Model: Model{
+			ID:        t.ID,
+			CreatedAt: t.CreatedAt,
+		},
+		UserID:    t.UserID,
+		Family:    t.Family,
+		Hash:      t.Hash,
+		Used:      t.Used,
+		Revoked:   t.Revoked,
+		ExpiresAt: t.ExpiresAt,
+	}
+}
+
+func domainRefreshToken(m *RefreshToken) *realworld.RefreshToken {
+	return &realworld.RefreshToken{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Family:    m.Family,
+		Hash:      m.Hash,
+		Used:      m.Used,
+		Revoked:   m.Revoked,
+		ExpiresAt: m.ExpiresAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
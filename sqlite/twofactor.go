@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"strings"
+	"time"
+)
+
+type TwoFactor struct {
+	UserID  int64  `gorm:"primary_key;auto_increment:false"`
+	Secret  string `gorm:"not null"`
+	Enabled bool
+	// RecoveryCodes is the comma separated list of recovery code hashes.
+	RecoveryCodes string
+	LastStep      int64
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func (s *twoFactorRepository) Get(userID int64) (*realworld.TwoFactor, error) {
+	var m TwoFactor
+	if err := s.db.Where("user_id = ?", userID).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrTwoFactorNotFound
+		}
+		return nil, err
+	}
+
+	tf := &realworld.TwoFactor{
+		UserID:   m.UserID,
+		Secret:   m.Secret,
+		Enabled:  m.Enabled,
+		LastStep: m.LastStep,
+	}
+	if m.RecoveryCodes != "" {
+		tf.RecoveryCodes = strings.Split(m.RecoveryCodes, ",")
+	}
+
+	return tf, nil
+}
+
+func (s *twoFactorRepository) Save(tf realworld.TwoFactor) error {
+	return s.db.Save(&TwoFactor{
+		UserID:        tf.UserID,
+		Secret:        tf.Secret,
+		Enabled:       tf.Enabled,
+		RecoveryCodes: strings.Join(tf.RecoveryCodes, ","),
+		LastStep:      tf.LastStep,
+	}).Error
+}
+
+func (s *twoFactorRepository) Delete(userID int64) error {
+	return s.db.Where("user_id = ?", userID).Delete(&TwoFactor{}).Error
+}
+
+type LoginChallenge struct {
+	Model
+	UserID    int64  `gorm:"index;not null"`
+	Hash      string `gorm:"unique_index;not null"`
+	Used      bool
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+type loginChallengeRepository struct {
+	db *gorm.DB
+}
+
+func (s *loginChallengeRepository) Create(c realworld.LoginChallenge) (*realworld.LoginChallenge, error) {
+	m := &LoginChallenge{
+		UserID:    c.UserID,
+		Hash:      c.Hash,
+		ExpiresAt: c.ExpiresAt,
+	}
+	if err := s.db.Create(m).Error; err != nil {
+		return nil, err
+	}
+	return domainLoginChallenge(m), nil
+}
+
+func (s *loginChallengeRepository) GetByHash(hash string) (*realworld.LoginChallenge, error) {
+	var m LoginChallenge
+	if err := s.db.Where(&LoginChallenge{Hash: hash}).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrInvalidLoginChallenge
+		}
+		return nil, err
+	}
+	return domainLoginChallenge(&m), nil
+}
+
+func (s *loginChallengeRepository) Use(c realworld.LoginChallenge) error {
+	res := s.db.Model(&LoginChallenge{}).
+		Where("id = ? AND used = ?", c.ID, false).
+		Update("used", true)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return realworld.ErrInvalidLoginChallenge
+	}
+
+	return nil
+}
+
+func (s *loginChallengeRepository) Fail(c realworld.LoginChallenge) error {
+	return s.db.Model(&LoginChallenge{}).
+		Where("id = ?", c.ID).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+func domainLoginChallenge(m *LoginChallenge) *realworld.LoginChallenge {
+	return &realworld.LoginChallenge{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Hash:      m.Hash,
+		Used:      m.Used,
+		Attempts:  m.Attempts,
+		ExpiresAt: m.ExpiresAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
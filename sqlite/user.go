@@ -2,21 +2,24 @@ package sqlite
 
 import (
 	"database/sql"
-	"errors"
 	"github.com/jinzhu/gorm"
 	realworld "github.com/xesina/gokit-realworld"
 )
 
 type User struct {
 	Model
-	Username   string `gorm:"unique_index;not null"`
-	Email      string `gorm:"unique_index;not null"`
-	Password   string `gorm:"not null"`
-	Bio        sql.NullString
-	Image      sql.NullString
-	Followers  []Follow  `gorm:"foreignkey:FollowingID"`
-	Followings []Follow  `gorm:"foreignkey:FollowerID"`
-	Favorites  []Article `gorm:"many2many:favorites;"`
+	Username      string `gorm:"unique_index;not null"`
+	Email         string `gorm:"unique_index;not null"`
+	Password      string `gorm:"not null"`
+	Bio           sql.NullString
+	Image         sql.NullString
+	Followers     []Follow  `gorm:"foreignkey:FollowingID"`
+	Followings    []Follow  `gorm:"foreignkey:FollowerID"`
+	Favorites     []Article `gorm:"many2many:favorites;"`
+	Bookmarks     []Article `gorm:"many2many:bookmarks;"`
+	EmailVerified bool
+	Role          string `gorm:"not null;default:'user'"`
+	Banned        bool
 }
 
 type Follow struct {
@@ -31,33 +34,65 @@ type userRepository struct {
 }
 
 func (s *userRepository) Create(u realworld.User) (*realworld.User, error) {
-	m, err := s.GetByUsername(u.Username)
-	if err != nil && !errors.Is(err, realworld.ErrUserNotFound) {
+	if err := s.checkTaken(u.Username, u.Email, 0); err != nil {
 		return nil, err
 	}
 
-	if m != nil {
-		return nil, realworld.ErrUserAlreadyExists
-	}
-
 	user := userModel(&u)
-	err = s.db.Create(user).Error
+	err := s.db.Create(user).Error
 	return s.domainUser(user), err
 }
 
 func (s *userRepository) Update(u realworld.User) (*realworld.User, error) {
-	old, err := s.GetByUsername(u.Username)
+	old, err := s.getByID(u.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.checkTaken(u.Username, u.Email, u.ID); err != nil {
+		return nil, err
+	}
+
 	if u.Password == "" {
 		u.Password = old.Password
 	}
+	u.EmailVerified = u.EmailVerified || old.EmailVerified
+	u.Role = old.Role
+	u.Banned = old.Banned
 
+	// Every column is named, since Update on a model leaves out the ones
+	// being cleared.
 	model := userModel(&u)
-	err = s.db.Model(model).Update(model).Error
-	return &u, err
+	err = s.db.Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"username":       model.Username,
+		"email":          model.Email,
+		"password":       model.Password,
+		"bio":            model.Bio,
+		"image":          model.Image,
+		"email_verified": model.EmailVerified,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(u.ID)
+}
+
+// checkTaken returns ErrUserAlreadyExists when a user other than the one
+// with the given ID has the username or email.
+func (s *userRepository) checkTaken(username, email string, id int64) error {
+	var count int
+	err := s.db.Model(&User{}).
+		Where("(username = ? OR email = ?) AND id <> ?", username, email, id).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return realworld.ErrUserAlreadyExists
+	}
+	return nil
 }
 
 func (s *userRepository) Get(e string) (*realworld.User, error) {
@@ -72,15 +107,15 @@ func (s *userRepository) Get(e string) (*realworld.User, error) {
 }
 
 func (s *userRepository) GetByID(id int64) (*realworld.User, error) {
-	m, err := s.getByID(id)
-	if err != nil {
+	var m User
+	if err := s.db.Preload("Followers").Preload("Followings").First(&m, id).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
 			return nil, realworld.ErrUserNotFound
 		}
 
 		return nil, err
 	}
-	return s.domainUser(m), nil
+	return s.domainUser(&m), nil
 }
 
 func (s *userRepository) getByID(id int64) (*User, error) {
@@ -189,9 +224,13 @@ func userModel(u *realworld.User) *User {
 			String: u.Image.Value,
 			Valid:  u.Image.Valid,
 		},
-		Followers:  nil,
-		Followings: nil,
-		Favorites:  nil,
+		Followers:     nil,
+		Followings:    nil,
+		Favorites:     nil,
+		Bookmarks:     nil,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		Banned:        u.Banned,
 	}
 }
 
@@ -209,8 +248,11 @@ func (s *userRepository) domainUser(u *User) *realworld.User {
 			Value: u.Image.String,
 			Valid: u.Image.Valid,
 		},
-		Followers:  s.followersMap(u.Followers),
-		Followings: s.followingMap(u.Followings),
+		Followers:     s.followersMap(u.Followers),
+		Followings:    s.followingMap(u.Followings),
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		Banned:        u.Banned,
 	}
 }
 
@@ -229,3 +271,106 @@ func (s *userRepository) followingMap(ff []Follow) realworld.Follows {
 	}
 	return fm
 }
+
+func (s *userRepository) ListFollowers(id int64, offset, limit int) ([]*realworld.User, int, error) {
+	return s.listFollows("follows.follower_id = users.id", "follows.following_id = ?", id, offset, limit)
+}
+
+func (s *userRepository) ListFollowing(id int64, offset, limit int) ([]*realworld.User, int, error) {
+	return s.listFollows("follows.following_id = users.id", "follows.follower_id = ?", id, offset, limit)
+}
+
+// listFollows pages through the users joined to follows on join, among the
+// follows of the user with the given ID picked by where.
+func (s *userRepository) listFollows(join, where string, id int64, offset, limit int) ([]*realworld.User, int, error) {
+	q := s.db.Model(&User{}).
+		Joins("JOIN follows ON "+join).
+		Where(where, id)
+
+	var total int
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var mm []User
+	err := q.Preload("Followers").
+		Order("users.username").
+		Offset(offset).
+		Limit(limit).
+		Find(&mm).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users := make([]*realworld.User, 0, len(mm))
+	for i := range mm {
+		users = append(users, s.domainUser(&mm[i]))
+	}
+
+	return users, total, nil
+}
+
+func (s *userRepository) SetRole(id int64, role string) error {
+	return s.set(id, "role", role)
+}
+
+func (s *userRepository) SetBanned(id int64, banned bool) error {
+	return s.set(id, "banned", banned)
+}
+
+func (s *userRepository) SetImage(id int64, image realworld.Image) error {
+	return s.set(id, "image", sql.NullString{String: image.Value, Valid: image.Valid})
+}
+
+func (s *userRepository) set(id int64, column string, value interface{}) error {
+	res := s.db.Model(&User{}).Where("id = ?", id).Update(column, value)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return realworld.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (s *userRepository) Anonymize(id int64, username, email string) error {
+	if _, err := s.getByID(id); err != nil {
+		return err
+	}
+
+	return transaction(s.db,
+		func(tx *gorm.DB) error {
+			return tx.Where("follower_id = ? OR following_id = ?", id, id).Delete(&Follow{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+				"username":       username,
+				"email":          email,
+				"password":       "",
+				"bio":            sql.NullString{},
+				"image":          sql.NullString{},
+				"email_verified": false,
+				"role":           realworld.RoleUser,
+				"banned":         true,
+			}).Error
+		},
+	)
+}
+
+// Delete is for good, so the username and email are free to be taken again.
+func (s *userRepository) Delete(id int64) error {
+	if _, err := s.getByID(id); err != nil {
+		return err
+	}
+
+	return transaction(s.db,
+		func(tx *gorm.DB) error {
+			return tx.Where("follower_id = ? OR following_id = ?", id, id).Delete(&Follow{}).Error
+		},
+		func(tx *gorm.DB) error {
+			return tx.Unscoped().Where("id = ?", id).Delete(&User{}).Error
+		},
+	)
+}
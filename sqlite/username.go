@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+)
+
+type UsernameChange struct {
+	Model
+	UserID   int64  `gorm:"index;not null"`
+	Username string `gorm:"index;not null"`
+}
+
+type usernameHistoryRepository struct {
+	db *gorm.DB
+}
+
+func (s *usernameHistoryRepository) Add(c realworld.UsernameChange) error {
+	return s.db.Create(&UsernameChange{UserID: c.UserID, Username: c.Username}).Error
+}
+
+func (s *usernameHistoryRepository) Latest(username string) (*realworld.UsernameChange, error) {
+	var m UsernameChange
+	if err := s.db.Where(&UsernameChange{Username: username}).Order("id desc").First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &realworld.UsernameChange{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Username:  m.Username,
+		CreatedAt: m.CreatedAt,
+	}, nil
+}
+
+func (s *usernameHistoryRepository) DeleteByUserID(id int64) error {
+	return s.db.Unscoped().Where("user_id = ?", id).Delete(&UsernameChange{}).Error
+}
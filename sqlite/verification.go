@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"github.com/jinzhu/gorm"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type EmailVerification struct {
+	Model
+	UserID    int64  `gorm:"index;not null"`
+	Email     string `gorm:"not null"`
+	Hash      string `gorm:"unique_index;not null"`
+	Change    bool
+	Used      bool
+	ExpiresAt time.Time
+}
+
+type emailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func (s *emailVerificationRepository) Create(v realworld.EmailVerification) (*realworld.EmailVerification, error) {
+	m := &EmailVerification{
+		UserID:    v.UserID,
+		Email:     v.Email,
+		Hash:      v.Hash,
+		Change:    v.Change,
+		ExpiresAt: v.ExpiresAt,
+	}
+	if err := s.db.Create(m).Error; err != nil {
+		return nil, err
+	}
+	return domainEmailVerification(m), nil
+}
+
+func (s *emailVerificationRepository) GetByHash(hash string) (*realworld.EmailVerification, error) {
+	var m EmailVerification
+	if err := s.db.Where(&EmailVerification{Hash: hash}).First(&m).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, realworld.ErrInvalidVerificationToken
+		}
+		return nil, err
+	}
+	return domainEmailVerification(&m), nil
+}
+
+func (s *emailVerificationRepository) Use(v realworld.EmailVerification) error {
+	res := s.db.Model(&EmailVerification{}).
+		Where("id = ? AND used = ?", v.ID, false).
+		Update("used", true)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return realworld.ErrInvalidVerificationToken
+	}
+
+	return nil
+}
+
+func domainEmailVerification(m *EmailVerification) *realworld.EmailVerification {
+	return &realworld.EmailVerification{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Email:     m.Email,
+		Hash:      m.Hash,
+		Change:    m.Change,
+		Used:      m.Used,
+		ExpiresAt: m.ExpiresAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
@@ -0,0 +1,48 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = Error{EUnauthorized, errors.New("invalid refresh token")}
+	ErrRefreshTokenReused  = Error{EUnauthorized, errors.New("refresh token has already been used")}
+)
+
+// RefreshToken is a long-lived, single-use token exchanged for a new access
+// token. Tokens rotated from the same login share a Family, so presenting an
+// already used token revokes every token issued for that login. Only a hash
+// of the token is stored.
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	Family    string
+	Hash      string
+	Used      bool
+	Revoked   bool
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (t RefreshToken) Expired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+type RefreshTokenService interface {
+	// Issue starts a new token family for the user and returns the plain token.
+	Issue(u User) (*RefreshToken, string, error)
+	// Refresh exchanges a plain token for a new one in the same family.
+	Refresh(token string) (*RefreshToken, string, error)
+	// Revoke invalidates every token in the family of the given plain token.
+	Revoke(token string) error
+}
+
+type RefreshTokenRepo interface {
+	Create(t RefreshToken) (*RefreshToken, error)
+	GetByHash(hash string) (*RefreshToken, error)
+	// Use marks the token as used and returns ErrRefreshTokenReused if it already was.
+	Use(t RefreshToken) error
+	ListByUserID(id int64) ([]*RefreshToken, error)
+	RevokeFamily(family string) error
+}
@@ -0,0 +1,211 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	realworld "github.com/xesina/gokit-realworld"
+	"sort"
+	"time"
+)
+
+const defaultTTL = time.Hour * 24 * 30
+
+type Service struct {
+	Repo        realworld.RefreshTokenRepo
+	Revocations realworld.RevocationRepo
+	// TTL is how long a refresh token stays valid. defaultTTL is used when it's zero.
+	TTL time.Duration
+}
+
+func (s Service) Issue(u realworld.User) (*realworld.RefreshToken, string, error) {
+	family, err := random(16)
+	if err != nil {
+		return nil, "", realworld.InternalError(err)
+	}
+	return s.create(u.ID, family)
+}
+
+func (s Service) Refresh(plain string) (*realworld.RefreshToken, string, error) {
+	t, err := s.Repo.GetByHash(Hash(plain))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if t.Revoked || t.Expired() {
+		return nil, "", realworld.ErrInvalidRefreshToken
+	}
+
+	if err := s.Repo.Use(*t); err != nil {
+		// A used token showing up again means it has leaked, so we
+		// invalidate every token issued from the same login.
+		if errors.Is(err, realworld.ErrRefreshTokenReused) {
+			if err := s.revokeFamily(t.Family); err != nil {
+				return nil, "", err
+			}
+		}
+		return nil, "", err
+	}
+
+	return s.create(t.UserID, t.Family)
+}
+
+func (s Service) Revoke(plain string) error {
+	t, err := s.Repo.GetByHash(Hash(plain))
+	if err != nil {
+		return err
+	}
+	return s.revokeFamily(t.Family)
+}
+
+func (s Service) Sessions(u realworld.User) ([]*realworld.Session, error) {
+	tokens, err := s.Repo.ListByUserID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*realworld.Session, 0)
+	for _, fam := range families(tokens) {
+		last := fam[len(fam)-1]
+		if last.Revoked || last.Expired() {
+			continue
+		}
+		sessions = append(sessions, &realworld.Session{
+			ID:         last.Family,
+			UserID:     u.ID,
+			CreatedAt:  fam[0].CreatedAt,
+			LastUsedAt: last.CreatedAt,
+			ExpiresAt:  last.ExpiresAt,
+		})
+	}
+
+	return sessions, nil
+}
+
+func (s Service) RevokeSession(u realworld.User, id string) error {
+	sessions, err := s.Sessions(u)
+	if err != nil {
+		return err
+	}
+
+	for _, ss := range sessions {
+		if ss.ID == id {
+			return s.revokeFamily(id)
+		}
+	}
+
+	return realworld.ErrSessionNotFound
+}
+
+func (s Service) RevokeSessions(u realworld.User) error {
+	sessions, err := s.Sessions(u)
+	if err != nil {
+		return err
+	}
+
+	for _, ss := range sessions {
+		if err := s.revokeFamily(ss.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s Service) Revoked(ids ...string) (bool, error) {
+	if s.Revocations == nil {
+		return false, nil
+	}
+	return s.Revocations.Revoked(ids...)
+}
+
+// revokeFamily invalidates the refresh tokens of a session as well as the
+// access tokens already handed out for it.
+func (s Service) revokeFamily(family string) error {
+	if err := s.Repo.RevokeFamily(family); err != nil {
+		return err
+	}
+
+	if s.Revocations == nil {
+		return nil
+	}
+
+	// Access tokens are shorter lived than refresh tokens, so none of the
+	// session's tokens can outlive this entry.
+	return s.Revocations.Add(realworld.Revocation{
+		ID:        family,
+		ExpiresAt: time.Now().Add(s.ttl()),
+	})
+}
+
+func (s Service) create(userID int64, family string) (*realworld.RefreshToken, string, error) {
+	plain, err := random(32)
+	if err != nil {
+		return nil, "", realworld.InternalError(err)
+	}
+
+	t, err := s.Repo.Create(realworld.RefreshToken{
+		UserID:    userID,
+		Family:    family,
+		Hash:      Hash(plain),
+		ExpiresAt: time.Now().Add(s.ttl()),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return t, plain, nil
+}
+
+func (s Service) ttl() time.Duration {
+	if s.TTL == 0 {
+		return defaultTTL
+	}
+	return s.TTL
+}
+
+// families groups tokens by family, keeping the order they were issued in.
+func families(tokens []*realworld.RefreshToken) [][]*realworld.RefreshToken {
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].ID < tokens[j].ID
+	})
+
+	index := map[string]int{}
+	var fams [][]*realworld.RefreshToken
+	for _, t := range tokens {
+		i, ok := index[t.Family]
+		if !ok {
+			i = len(fams)
+			index[t.Family] = i
+			fams = append(fams, nil)
+		}
+		fams[i] = append(fams[i], t)
+	}
+	return fams
+}
+
+// Generate returns a new random token together with its hash, for one-time
+// tokens handed out outside of this service.
+func Generate() (plain, hash string, err error) {
+	plain, err = random(32)
+	if err != nil {
+		return "", "", err
+	}
+	return plain, Hash(plain), nil
+}
+
+// Hash returns the form in which a plain token is stored.
+func Hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,58 @@
+package token
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"testing"
+)
+
+func TestService_RefreshReuseRevokesFamily(t *testing.T) {
+	s := Service{Repo: inmem.NewMemRefreshTokenRepo()}
+
+	_, first, err := s.Issue(realworld.User{ID: 1})
+	assert.NoError(t, err)
+
+	rotated, second, err := s.Refresh(first)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rotated.UserID)
+
+	_, _, err = s.Refresh(first)
+	assert.Equal(t, realworld.ErrRefreshTokenReused, err)
+
+	_, _, err = s.Refresh(second)
+	assert.Equal(t, realworld.ErrInvalidRefreshToken, err)
+}
+
+func TestService_RevokeSessions(t *testing.T) {
+	s := Service{
+		Repo:        inmem.NewMemRefreshTokenRepo(),
+		Revocations: inmem.NewMemRevocationRepo(),
+	}
+	u := realworld.User{ID: 1}
+
+	first, _, err := s.Issue(u)
+	assert.NoError(t, err)
+	_, second, err := s.Issue(u)
+	assert.NoError(t, err)
+
+	sessions, err := s.Sessions(u)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	assert.Equal(t, realworld.ErrSessionNotFound, s.RevokeSession(realworld.User{ID: 2}, first.Family))
+	assert.NoError(t, s.RevokeSession(u, first.Family))
+
+	revoked, err := s.Revoked("jti", first.Family)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	assert.NoError(t, s.RevokeSessions(u))
+
+	sessions, err = s.Sessions(u)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	_, _, err = s.Refresh(second)
+	assert.Equal(t, realworld.ErrInvalidRefreshToken, err)
+}
@@ -0,0 +1,64 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidTwoFactorCode    = Error{EUnauthorized, errors.New("invalid two-factor code")}
+	ErrInvalidLoginChallenge   = Error{EUnauthorized, errors.New("invalid or expired login challenge")}
+	ErrTwoFactorAlreadyEnabled = Error{EConflict, errors.New("two-factor authentication is already enabled")}
+	ErrTwoFactorNotEnrolled    = Error{EConflict, errors.New("two-factor authentication is not enrolled")}
+	ErrTwoFactorNotFound       = Error{ENotFound, errors.New("two-factor authentication is not set up")}
+)
+
+// TwoFactor is a user's TOTP setup. It isn't Enabled until the user proves
+// their authenticator works by confirming a code.
+type TwoFactor struct {
+	UserID  int64
+	Secret  string
+	Enabled bool
+	// RecoveryCodes holds the hashes of the unused recovery codes.
+	RecoveryCodes []string
+	// LastStep is the last TOTP time step accepted, so a code can't be replayed.
+	LastStep int64
+}
+
+// TwoFactorEnrollment is what a user needs to add the account to an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type TwoFactorRepo interface {
+	// Get returns ErrTwoFactorNotFound for users that never enrolled.
+	Get(userID int64) (*TwoFactor, error)
+	Save(tf TwoFactor) error
+	Delete(userID int64) error
+}
+
+// LoginChallenge is handed out after the password step of a login for users
+// with two-factor authentication, and exchanged for tokens with a code.
+type LoginChallenge struct {
+	ID        int64
+	UserID    int64
+	Hash      string
+	Used      bool
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (c LoginChallenge) Expired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+type LoginChallengeRepo interface {
+	Create(c LoginChallenge) (*LoginChallenge, error)
+	GetByHash(hash string) (*LoginChallenge, error)
+	// Use marks the challenge as used. It returns ErrInvalidLoginChallenge if it already was.
+	Use(c LoginChallenge) error
+	// Fail counts a wrong code against the challenge.
+	Fail(c LoginChallenge) error
+}
@@ -12,8 +12,31 @@ var (
 	ErrUserNotFound           = Error{ENotFound, errors.New("user not found")}
 	ErrUserAlreadyExists      = Error{EConflict, errors.New("user already exists")}
 	ErrIncorrectPasswordError = Error{EIncorrectPassword, errors.New("incorrect password")}
+	ErrNotAdmin               = Error{EForbidden, errors.New("only admins can do this")}
+	ErrNotModerator           = Error{EForbidden, errors.New("only moderators can do this")}
+	ErrInvalidRole            = Error{EConflict, errors.New("unknown role")}
+	ErrSelfModeration         = Error{EForbidden, errors.New("admins can't change their own role or ban themselves")}
+	ErrUserBanned             = Error{EForbidden, errors.New("user is banned")}
 )
 
+// Roles a user can have, each allowed everything the ones before it are.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
 type Bio struct {
 	Value string
 	Valid bool
@@ -92,6 +115,20 @@ type User struct {
 	Image      Image
 	Followers  Follows
 	Followings Follows
+	// EmailVerified is false until the user follows the link sent to Email.
+	EmailVerified bool
+	Role          string
+	// Banned users can't log in.
+	Banned bool
+}
+
+// HasRole reports whether u has role or one above it.
+func (u *User) HasRole(role string) bool {
+	return roleRanks[u.Role] >= roleRanks[role]
+}
+
+func (u *User) IsAdmin() bool {
+	return u.HasRole(RoleAdmin)
 }
 
 func (u *User) HashPassword(plain string) (string, error) {
@@ -119,18 +156,106 @@ func (u *User) IsFollower(follower *User) bool {
 	return false
 }
 
+// ProfileStats counts what shows on a user's profile.
+type ProfileStats struct {
+	Followers int
+	Following int
+	Articles  int
+}
+
+// FollowListRequest selects a page of the users following, or followed by,
+// the user with Username.
+type FollowListRequest struct {
+	Username string
+	Offset   int
+	Limit    int
+}
+
+// UserSearchRequest selects a page of the users whose username or bio
+// contains Query, ignoring case. Users followed by the viewer with ViewerID
+// come first, then those whose username starts with Query.
+type UserSearchRequest struct {
+	Query    string
+	ViewerID int64
+	Offset   int
+	Limit    int
+}
+
+// UserPatch holds the changes to make to a user, leaving the fields that
+// are nil as they are.
+type UserPatch struct {
+	Username *string
+	Email    *string
+	Password *string
+	Bio      *Bio
+	Image    *Image
+}
+
 type UserService interface {
 	Register(user User) (*User, error)
-	Login(user User) (*User, error)
+	// Login checks the user's credentials. Failed attempts count against both
+	// the account and clientIP, either of which can end up locked out.
+	Login(user User, clientIP string) (*User, error)
+	// UnlockLogin lifts the login lockout of the user with the given
+	// username. Only admins can do this.
+	UnlockLogin(admin User, username string) error
+	// SetRole, Ban and Unban can only be done by admins, and not to themselves.
+	SetRole(admin User, username, role string) (*User, error)
+	// Ban also ends every session of the user.
+	Ban(admin User, username string) error
+	Unban(admin User, username string) error
 	Get(user User) (*User, error)
+	// Update replaces what users can change about themselves, and Patch
+	// changes only what p sets. Both return ErrUserAlreadyExists when the
+	// username or email belongs to someone else, and ErrUsernameReserved
+	// when someone else gave up the username recently. When emails are verified,
+	// a new email takes effect only once it's verified, until which the old
+	// one stays.
 	Update(user User) (*User, error)
+	Patch(user User, p UserPatch) (*User, error)
+	// GetProfile also finds users by a username they gave up, as long as
+	// nobody else took it, returning them with the one they have now.
 	GetProfile(user User) (*User, error)
 	Follow(req FollowRequest) (*User, error)
 	Unfollow(req FollowRequest) (*User, error)
+	ProfileStats(user User) (*ProfileStats, error)
+	// Followers and Following return a page of the users following, or
+	// followed by, the user along with how many there are in all.
+	Followers(r FollowListRequest) ([]*User, int, error)
+	Following(r FollowListRequest) ([]*User, int, error)
+	// Search returns a page of the users matching r along with how many there are in all.
+	Search(r UserSearchRequest) ([]*User, int, error)
+	// Autocomplete returns up to limit users whose username starts with
+	// prefix, ignoring case, ordered by username.
+	Autocomplete(prefix string, limit int) ([]*User, error)
+	// Restrict blocks or mutes a user and returns them. Blocking also ends
+	// the follows between the two users. Unrestrict lifts the restriction.
+	Restrict(r RestrictionRequest) (*User, error)
+	Unrestrict(r RestrictionRequest) (*User, error)
+	// RestrictionsOn reports whether the user with viewerID blocks and mutes u.
+	RestrictionsOn(u User, viewerID int64) (blocking, muting bool, err error)
+	// ForgotPassword mails a reset token to the user with the given email, if there is one.
+	ForgotPassword(email string) error
+	ResetPassword(token, password string) error
+	VerifyEmail(token string) error
+	// ResendVerification mails a new verification token to the user.
+	ResendVerification(user User) error
+	// LoginChallenge returns a challenge for users who have two-factor
+	// authentication enabled, or an empty string when Login is enough.
+	LoginChallenge(user User) (string, error)
+	// LoginTwoFactor finishes a login with the challenge and a code of the
+	// second factor, throttled like Login is for the client IP.
+	LoginTwoFactor(challenge, code, clientIP string) (*User, error)
+	EnrollTwoFactor(user User) (*TwoFactorEnrollment, error)
+	// ConfirmTwoFactor enables two-factor authentication and returns the recovery codes.
+	ConfirmTwoFactor(user User, code string) ([]string, error)
+	DisableTwoFactor(user User, code string) error
 }
 
 type UserRepo interface {
 	// TODO: should this return user? What if we assume this should only be a **write** command
+	// Create and Update return ErrUserAlreadyExists when another user has
+	// the username or email. Update finds the user by ID.
 	Create(u User) (*User, error)
 	Update(u User) (*User, error)
 	Get(e string) (*User, error)
@@ -138,4 +263,22 @@ type UserRepo interface {
 	GetByUsername(u string) (*User, error)
 	AddFollower(follower, followee int64) (*User, error)
 	RemoveFollower(follower, followee int64) (*User, error)
+	// ListFollowers and ListFollowing return a page of the users following,
+	// or followed by, the user with the given ID, ordered by username, along
+	// with how many there are in all.
+	ListFollowers(id int64, offset, limit int) ([]*User, int, error)
+	ListFollowing(id int64, offset, limit int) ([]*User, int, error)
+	// Search and ListByUsernamePrefix leave banned users out.
+	Search(r UserSearchRequest) ([]*User, int, error)
+	ListByUsernamePrefix(prefix string, limit int) ([]*User, error)
+	// SetRole and SetBanned change what Update leaves as it is.
+	SetRole(id int64, role string) error
+	SetBanned(id int64, banned bool) error
+	// SetImage replaces the image of the user, removing it when it isn't Valid.
+	SetImage(id int64, image Image) error
+	// Anonymize replaces the username and email of the user, bans them and
+	// removes their password, profile and follows.
+	Anonymize(id int64, username, email string) error
+	// Delete removes the user and their follows for good.
+	Delete(id int64) error
 }
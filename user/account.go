@@ -0,0 +1,44 @@
+package user
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	realworld "github.com/xesina/gokit-realworld"
+)
+
+type DeleteAccountRequest struct {
+	UserID   int64
+	Password string
+}
+
+func DeleteAccountEndpoint(a realworld.AccountService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(DeleteAccountRequest)
+		if err := a.DeleteAccount(realworld.User{ID: req.UserID}, req.Password); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type ExportAccountRequest struct {
+	UserID int64
+}
+
+type ExportAccountResponse struct {
+	Export *realworld.AccountExport
+	Err    error
+}
+
+func (r ExportAccountResponse) Failed() error { return r.Err }
+
+func ExportAccountEndpoint(a realworld.AccountService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ExportAccountRequest)
+		e, err := a.ExportAccount(realworld.User{ID: req.UserID})
+		if err != nil {
+			return nil, err
+		}
+		return ExportAccountResponse{Export: e}, nil
+	}
+}
@@ -0,0 +1,86 @@
+package user
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+)
+
+func (s Service) UnlockLogin(admin realworld.User, username string) error {
+	u, err := s.moderate(admin, username)
+	if err != nil {
+		return err
+	}
+
+	if s.AccountLockout != nil {
+		s.AccountLockout.Reset(lockoutKey(u.Email))
+	}
+
+	return nil
+}
+
+func (s Service) SetRole(admin realworld.User, username, role string) (*realworld.User, error) {
+	if !realworld.ValidRole(role) {
+		return nil, realworld.ErrInvalidRole
+	}
+
+	u, err := s.moderate(admin, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.ID == admin.ID {
+		return nil, realworld.ErrSelfModeration
+	}
+
+	if err := s.UserRepo.SetRole(u.ID, role); err != nil {
+		return nil, err
+	}
+	u.Role = role
+
+	return u, nil
+}
+
+func (s Service) Ban(admin realworld.User, username string) error {
+	u, err := s.moderate(admin, username)
+	if err != nil {
+		return err
+	}
+
+	if u.ID == admin.ID {
+		return realworld.ErrSelfModeration
+	}
+
+	if err := s.UserRepo.SetBanned(u.ID, true); err != nil {
+		return err
+	}
+
+	if s.Sessions != nil {
+		return s.Sessions.RevokeSessions(*u)
+	}
+
+	return nil
+}
+
+func (s Service) Unban(admin realworld.User, username string) error {
+	u, err := s.moderate(admin, username)
+	if err != nil {
+		return err
+	}
+
+	return s.UserRepo.SetBanned(u.ID, false)
+}
+
+// moderate returns the user with the given username once it has checked
+// that admin really is one. The role in the token admin came with may be
+// out of date, so it's looked up again.
+func (s Service) moderate(admin realworld.User, username string) (*realworld.User, error) {
+	found, err := s.UserRepo.GetByID(admin.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found.IsAdmin() {
+		return nil, realworld.ErrNotAdmin
+	}
+
+	return s.UserRepo.GetByUsername(username)
+}
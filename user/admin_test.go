@@ -0,0 +1,55 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"github.com/xesina/gokit-realworld/token"
+	"testing"
+)
+
+func TestService_Moderation(t *testing.T) {
+	tokens := token.Service{Repo: inmem.NewMemRefreshTokenRepo(), Revocations: inmem.NewMemRevocationRepo()}
+	s := Service{UserRepo: inmem.NewMemUserSaver(), Sessions: tokens}
+
+	register := func(username string) *realworld.User {
+		u, err := s.Register(realworld.User{Username: username, Email: username + "@example.com", Password: "secret"})
+		assert.NoError(t, err)
+		assert.Equal(t, realworld.RoleUser, u.Role)
+		return u
+	}
+	admin, alice := register("admin"), register("alice")
+	assert.NoError(t, s.UserRepo.SetRole(admin.ID, realworld.RoleAdmin))
+
+	_, err := s.SetRole(*alice, "admin", realworld.RoleUser)
+	assert.Equal(t, realworld.ErrNotAdmin, err)
+	_, err = s.SetRole(*admin, "alice", "root")
+	assert.Equal(t, realworld.ErrInvalidRole, err)
+	_, err = s.SetRole(*admin, "admin", realworld.RoleUser)
+	assert.Equal(t, realworld.ErrSelfModeration, err)
+
+	u, err := s.SetRole(*admin, "alice", realworld.RoleModerator)
+	assert.NoError(t, err)
+	assert.True(t, u.HasRole(realworld.RoleModerator))
+	assert.False(t, u.HasRole(realworld.RoleAdmin))
+
+	// A profile update leaves the role alone.
+	u, err = s.Update(realworld.User{ID: alice.ID, Username: "alice", Email: "alice@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, realworld.RoleModerator, u.Role)
+
+	_, refresh, err := tokens.Issue(*alice)
+	assert.NoError(t, err)
+
+	assert.Equal(t, realworld.ErrNotAdmin, s.Ban(*alice, "admin"))
+	assert.NoError(t, s.Ban(*admin, "alice"))
+
+	_, err = s.Login(realworld.User{Email: "alice@example.com", Password: "secret"}, "")
+	assert.Equal(t, realworld.ErrUserBanned, err)
+	_, _, err = tokens.Refresh(refresh)
+	assert.Error(t, err)
+
+	assert.NoError(t, s.Unban(*admin, "alice"))
+	_, err = s.Login(realworld.User{Email: "alice@example.com", Password: "secret"}, "")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,95 @@
+package user
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type CreateAPIKeyRequest struct {
+	UserID int64
+	Name   string
+	Scopes []string
+}
+
+type APIKey struct {
+	ID         int64
+	Name       string
+	Prefix     string
+	Scopes     []string
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+func newAPIKey(k *realworld.APIKey) APIKey {
+	return APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+type APIKeyResponse struct {
+	APIKey APIKey
+	// Key is the plain key, only available when it's created.
+	Key string
+	Err error
+}
+
+func (r APIKeyResponse) Failed() error { return r.Err }
+
+func CreateAPIKeyEndpoint(s realworld.APIKeyService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(CreateAPIKeyRequest)
+		k, plain, err := s.CreateAPIKey(realworld.User{ID: req.UserID}, req.Name, req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		return APIKeyResponse{APIKey: newAPIKey(k), Key: plain}, nil
+	}
+}
+
+type APIKeysRequest struct {
+	UserID int64
+}
+
+type APIKeysResponse struct {
+	APIKeys []APIKey
+	Err     error
+}
+
+func (r APIKeysResponse) Failed() error { return r.Err }
+
+func APIKeysEndpoint(s realworld.APIKeyService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(APIKeysRequest)
+		kk, err := s.APIKeys(realworld.User{ID: req.UserID})
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]APIKey, 0, len(kk))
+		for _, k := range kk {
+			keys = append(keys, newAPIKey(k))
+		}
+		return APIKeysResponse{APIKeys: keys}, nil
+	}
+}
+
+type RevokeAPIKeyRequest struct {
+	UserID int64
+	ID     int64
+}
+
+func RevokeAPIKeyEndpoint(s realworld.APIKeyService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RevokeAPIKeyRequest)
+		if err := s.RevokeAPIKey(realworld.User{ID: req.UserID}, req.ID); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
@@ -0,0 +1,48 @@
+package user
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	realworld "github.com/xesina/gokit-realworld"
+	"io"
+)
+
+type SetAvatarRequest struct {
+	UserID int64
+	Image  io.Reader
+}
+
+type AvatarResponse struct {
+	Image realworld.Image
+	// Sizes has the URL of each size the avatar is stored at.
+	Sizes map[int]string
+	Err   error
+}
+
+func (r AvatarResponse) Failed() error { return r.Err }
+
+func SetAvatarEndpoint(a realworld.AvatarService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SetAvatarRequest)
+		u, sizes, err := a.SetAvatar(realworld.User{ID: req.UserID}, req.Image)
+		if err != nil {
+			return nil, err
+		}
+		return AvatarResponse{Image: u.Image, Sizes: sizes}, nil
+	}
+}
+
+type RemoveAvatarRequest struct {
+	UserID int64
+}
+
+func RemoveAvatarEndpoint(a realworld.AvatarService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RemoveAvatarRequest)
+		u, err := a.RemoveAvatar(realworld.User{ID: req.UserID})
+		if err != nil {
+			return nil, err
+		}
+		return AvatarResponse{Image: u.Image, Sizes: map[int]string{}}, nil
+	}
+}
@@ -21,22 +21,32 @@ func (r RegisterRequest) toUser() realworld.User {
 }
 
 type Response struct {
-	ID       int64
-	Username string
-	Email    string
-	Bio      realworld.Bio
-	Image    realworld.Image
-	Err      error
+	ID            int64
+	Username      string
+	Email         string
+	Bio           realworld.Bio
+	Image         realworld.Image
+	EmailVerified bool
+	Role          string
+	RefreshToken  string
+	SessionID     string
+	// Challenge is set instead of a session when the login needs a second factor.
+	Challenge string
+	// PendingEmail is the new email of an update, until it's verified.
+	PendingEmail string
+	Err          error
 }
 
 func NewResponse(u *realworld.User, err error) Response {
 	return Response{
-		ID:       u.ID,
-		Username: u.Username,
-		Email:    u.Email,
-		Bio:      u.Bio,
-		Image:    u.Image,
-		Err:      err,
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		Bio:           u.Bio,
+		Image:         u.Image,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		Err:           err,
 	}
 }
 
@@ -44,20 +54,28 @@ func (r Response) error() error { return r.Err }
 
 func (r Response) Failed() error { return r.Err }
 
-func RegisterEndpoint(s realworld.UserService) endpoint.Endpoint {
+func RegisterEndpoint(s realworld.UserService, t realworld.RefreshTokenService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(RegisterRequest)
 		u, err := s.Register(req.toUser())
 		if err != nil {
 			return nil, err
 		}
-		return NewResponse(u, err), nil
+		rt, refresh, err := t.Issue(*u)
+		if err != nil {
+			return nil, err
+		}
+		resp := NewResponse(u, err)
+		resp.RefreshToken = refresh
+		resp.SessionID = rt.Family
+		return resp, nil
 	}
 }
 
 type LoginRequest struct {
 	Email    string
 	Password string
+	ClientIP string
 }
 
 func (r LoginRequest) toUser() realworld.User {
@@ -67,14 +85,259 @@ func (r LoginRequest) toUser() realworld.User {
 	}
 }
 
-func LoginEndpoint(s realworld.UserService) endpoint.Endpoint {
+func LoginEndpoint(s realworld.UserService, t realworld.RefreshTokenService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(LoginRequest)
-		u, err := s.Login(req.toUser())
+		u, err := s.Login(req.toUser(), req.ClientIP)
 		if err != nil {
 			return nil, err
 		}
-		return NewResponse(u, err), nil
+		challenge, err := s.LoginChallenge(*u)
+		if err != nil {
+			return nil, err
+		}
+		if challenge != "" {
+			return Response{Challenge: challenge}, nil
+		}
+		rt, refresh, err := t.Issue(*u)
+		if err != nil {
+			return nil, err
+		}
+		resp := NewResponse(u, err)
+		resp.RefreshToken = refresh
+		resp.SessionID = rt.Family
+		return resp, nil
+	}
+}
+
+type RefreshRequest struct {
+	RefreshToken string
+}
+
+func RefreshEndpoint(s realworld.UserService, t realworld.RefreshTokenService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RefreshRequest)
+		rt, refresh, err := t.Refresh(req.RefreshToken)
+		if err != nil {
+			return nil, err
+		}
+		u, err := s.Get(realworld.User{ID: rt.UserID})
+		if err != nil {
+			return nil, err
+		}
+		resp := NewResponse(u, err)
+		resp.RefreshToken = refresh
+		resp.SessionID = rt.Family
+		return resp, nil
+	}
+}
+
+type EmptyResponse struct {
+	Err error
+}
+
+func (r EmptyResponse) Failed() error { return r.Err }
+
+func LogoutEndpoint(t realworld.RefreshTokenService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RefreshRequest)
+		if err := t.Revoke(req.RefreshToken); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type ForgotPasswordRequest struct {
+	Email string
+}
+
+func ForgotPasswordEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ForgotPasswordRequest)
+		if err := s.ForgotPassword(req.Email); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type ResetPasswordRequest struct {
+	Token    string
+	Password string
+}
+
+func ResetPasswordEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ResetPasswordRequest)
+		if err := s.ResetPassword(req.Token, req.Password); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type VerifyEmailRequest struct {
+	Token string
+}
+
+func VerifyEmailEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(VerifyEmailRequest)
+		if err := s.VerifyEmail(req.Token); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type ResendVerificationRequest struct {
+	UserID int64
+}
+
+func ResendVerificationEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ResendVerificationRequest)
+		if err := s.ResendVerification(realworld.User{ID: req.UserID}); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type LoginTwoFactorRequest struct {
+	Challenge string
+	Code      string
+	ClientIP  string
+}
+
+func LoginTwoFactorEndpoint(s realworld.UserService, t realworld.RefreshTokenService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(LoginTwoFactorRequest)
+		u, err := s.LoginTwoFactor(req.Challenge, req.Code, req.ClientIP)
+		if err != nil {
+			return nil, err
+		}
+		rt, refresh, err := t.Issue(*u)
+		if err != nil {
+			return nil, err
+		}
+		resp := NewResponse(u, err)
+		resp.RefreshToken = refresh
+		resp.SessionID = rt.Family
+		return resp, nil
+	}
+}
+
+type EnrollTwoFactorRequest struct {
+	UserID int64
+}
+
+type EnrollTwoFactorResponse struct {
+	Secret string
+	URI    string
+	Err    error
+}
+
+func (r EnrollTwoFactorResponse) Failed() error { return r.Err }
+
+func EnrollTwoFactorEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(EnrollTwoFactorRequest)
+		e, err := s.EnrollTwoFactor(realworld.User{ID: req.UserID})
+		if err != nil {
+			return nil, err
+		}
+		return EnrollTwoFactorResponse{Secret: e.Secret, URI: e.URI}, nil
+	}
+}
+
+type TwoFactorCodeRequest struct {
+	UserID int64
+	Code   string
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string
+	Err           error
+}
+
+func (r RecoveryCodesResponse) Failed() error { return r.Err }
+
+func ConfirmTwoFactorEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(TwoFactorCodeRequest)
+		codes, err := s.ConfirmTwoFactor(realworld.User{ID: req.UserID}, req.Code)
+		if err != nil {
+			return nil, err
+		}
+		return RecoveryCodesResponse{RecoveryCodes: codes}, nil
+	}
+}
+
+func DisableTwoFactorEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(TwoFactorCodeRequest)
+		if err := s.DisableTwoFactor(realworld.User{ID: req.UserID}, req.Code); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type UnlockLoginRequest struct {
+	UserID   int64
+	Username string
+}
+
+func UnlockLoginEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(UnlockLoginRequest)
+		if err := s.UnlockLogin(realworld.User{ID: req.UserID}, req.Username); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type SetRoleRequest struct {
+	UserID   int64
+	Username string
+	Role     string
+}
+
+func SetRoleEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SetRoleRequest)
+		if _, err := s.SetRole(realworld.User{ID: req.UserID}, req.Username, req.Role); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+type BanRequest struct {
+	UserID   int64
+	Username string
+}
+
+func BanEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(BanRequest)
+		if err := s.Ban(realworld.User{ID: req.UserID}, req.Username); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
+
+func UnbanEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(BanRequest)
+		if err := s.Unban(realworld.User{ID: req.UserID}, req.Username); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
 	}
 }
 
@@ -119,18 +382,59 @@ func (r UpdateRequest) toUser() realworld.User {
 	}
 }
 
-func UpdateEndpoint(s realworld.UserService) endpoint.Endpoint {
+func UpdateEndpoint(s realworld.UserService, t realworld.RefreshTokenService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(UpdateRequest)
 		u, err := s.Update(req.toUser())
 		if err != nil {
 			return nil, err
 		}
-		return NewResponse(u, err), nil
+		return updateResponse(t, u, req.Email, req.Password != "")
 	}
 
 }
 
+type PatchRequest struct {
+	ID    int64
+	Patch realworld.UserPatch
+}
+
+func PatchEndpoint(s realworld.UserService, t realworld.RefreshTokenService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(PatchRequest)
+		u, err := s.Patch(realworld.User{ID: req.ID}, req.Patch)
+		if err != nil {
+			return nil, err
+		}
+		var email string
+		if req.Patch.Email != nil {
+			email = *req.Patch.Email
+		}
+		return updateResponse(t, u, email, req.Patch.Password != nil)
+	}
+}
+
+// updateResponse is the response to an update that asked for the email
+// and changed the password if it says so.
+func updateResponse(t realworld.RefreshTokenService, u *realworld.User, email string, password bool) (Response, error) {
+	resp := NewResponse(u, nil)
+	// An email that didn't change right away waits to be verified.
+	if email != "" && email != u.Email {
+		resp.PendingEmail = email
+	}
+	// Changing the password ends every session including the current
+	// one, so the caller gets a fresh one to carry on with.
+	if password {
+		rt, refresh, err := t.Issue(*u)
+		if err != nil {
+			return Response{}, err
+		}
+		resp.RefreshToken = refresh
+		resp.SessionID = rt.Family
+	}
+	return resp, nil
+}
+
 type ProfileRequest struct {
 	Username string
 	ViewerID int64
@@ -148,7 +452,14 @@ type ProfileResponse struct {
 	Bio       realworld.Bio
 	Image     realworld.Image
 	Following bool
-	Err       error
+	// Blocking and Muting tell whether the viewer blocks or mutes the user.
+	// They're left out of listings, like Stats.
+	Blocking bool
+	Muting   bool
+	Stats    *realworld.ProfileStats
+	// Renamed is set when the profile was found by a username the user gave up.
+	Renamed bool
+	Err     error
 }
 
 func NewProfileResponse(u *realworld.User, viewerID int64, err error) ProfileResponse {
@@ -162,6 +473,23 @@ func NewProfileResponse(u *realworld.User, viewerID int64, err error) ProfileRes
 	}
 }
 
+// profileWithStats looks up the stats of the profile in resp, and what the
+// viewer restricts about it.
+func profileWithStats(s realworld.UserService, resp ProfileResponse, viewerID int64) (ProfileResponse, error) {
+	stats, err := s.ProfileStats(realworld.User{ID: resp.ID})
+	if err != nil {
+		return ProfileResponse{}, err
+	}
+	resp.Stats = stats
+
+	resp.Blocking, resp.Muting, err = s.RestrictionsOn(realworld.User{ID: resp.ID}, viewerID)
+	if err != nil {
+		return ProfileResponse{}, err
+	}
+
+	return resp, nil
+}
+
 func (r ProfileResponse) error() error { return r.Err }
 
 func (r ProfileResponse) Failed() error { return r.Err }
@@ -173,7 +501,9 @@ func GetProfileEndpoint(s realworld.UserService) endpoint.Endpoint {
 		if err != nil {
 			return nil, err
 		}
-		return NewProfileResponse(u, req.ViewerID, err), nil
+		resp := NewProfileResponse(u, req.ViewerID, err)
+		resp.Renamed = u.Username != req.Username
+		return profileWithStats(s, resp, req.ViewerID)
 	}
 }
 
@@ -187,7 +517,7 @@ func FollowEndpoint(s realworld.UserService) endpoint.Endpoint {
 		if err != nil {
 			return nil, err
 		}
-		return NewProfileResponse(u, req.ViewerID, err), nil
+		return profileWithStats(s, NewProfileResponse(u, req.ViewerID, err), req.ViewerID)
 	}
 }
 
@@ -201,6 +531,119 @@ func UnfollowEndpoint(s realworld.UserService) endpoint.Endpoint {
 		if err != nil {
 			return nil, err
 		}
-		return NewProfileResponse(u, req.ViewerID, err), nil
+		return profileWithStats(s, NewProfileResponse(u, req.ViewerID, err), req.ViewerID)
+	}
+}
+
+// RestrictEndpoint puts the restriction of the given kind on the user in the
+// request on behalf of the viewer.
+func RestrictEndpoint(s realworld.UserService, kind string) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ProfileRequest)
+		u, err := s.Restrict(realworld.RestrictionRequest{
+			Username: req.Username,
+			UserID:   req.ViewerID,
+			Kind:     kind,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return profileWithStats(s, NewProfileResponse(u, req.ViewerID, err), req.ViewerID)
+	}
+}
+
+func UnrestrictEndpoint(s realworld.UserService, kind string) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ProfileRequest)
+		u, err := s.Unrestrict(realworld.RestrictionRequest{
+			Username: req.Username,
+			UserID:   req.ViewerID,
+			Kind:     kind,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return profileWithStats(s, NewProfileResponse(u, req.ViewerID, err), req.ViewerID)
+	}
+}
+
+type FollowListRequest struct {
+	Username string
+	ViewerID int64
+	Offset   int
+	Limit    int
+}
+
+func (r FollowListRequest) serviceRequest() realworld.FollowListRequest {
+	return realworld.FollowListRequest{
+		Username: r.Username,
+		Offset:   r.Offset,
+		Limit:    r.Limit,
+	}
+}
+
+type ProfilesResponse struct {
+	Profiles []ProfileResponse
+	Count    int
+	Err      error
+}
+
+func (r ProfilesResponse) Failed() error { return r.Err }
+
+func NewProfilesResponse(uu []*realworld.User, count int, viewerID int64) ProfilesResponse {
+	resp := ProfilesResponse{Profiles: make([]ProfileResponse, 0, len(uu)), Count: count}
+	for _, u := range uu {
+		resp.Profiles = append(resp.Profiles, NewProfileResponse(u, viewerID, nil))
+	}
+	return resp
+}
+
+func FollowersEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(FollowListRequest)
+		uu, count, err := s.Followers(req.serviceRequest())
+		if err != nil {
+			return nil, err
+		}
+		return NewProfilesResponse(uu, count, req.ViewerID), nil
+	}
+}
+
+func FollowingEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(FollowListRequest)
+		uu, count, err := s.Following(req.serviceRequest())
+		if err != nil {
+			return nil, err
+		}
+		return NewProfilesResponse(uu, count, req.ViewerID), nil
+	}
+}
+
+func SearchEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(realworld.UserSearchRequest)
+		uu, count, err := s.Search(req)
+		if err != nil {
+			return nil, err
+		}
+		return NewProfilesResponse(uu, count, req.ViewerID), nil
+	}
+}
+
+type AutocompleteRequest struct {
+	Prefix   string
+	ViewerID int64
+	Limit    int
+}
+
+func AutocompleteEndpoint(s realworld.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(AutocompleteRequest)
+		uu, err := s.Autocomplete(req.Prefix, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+		return NewProfilesResponse(uu, len(uu), req.ViewerID), nil
 	}
 }
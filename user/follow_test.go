@@ -0,0 +1,54 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"testing"
+)
+
+func TestService_Follows(t *testing.T) {
+	articles := inmem.NewMemArticleRepo()
+	s := Service{UserRepo: inmem.NewMemUserSaver(), Articles: articles}
+
+	register := func(username string) *realworld.User {
+		u, err := s.Register(realworld.User{Username: username, Email: username + "@example.com", Password: "secret"})
+		assert.NoError(t, err)
+		return u
+	}
+	alice := register("alice")
+	for _, name := range []string{"dave", "bobby", "carol"} {
+		u := register(name)
+		_, err := s.Follow(realworld.FollowRequest{Followee: "alice", Follower: u.ID})
+		assert.NoError(t, err)
+	}
+	_, err := s.Follow(realworld.FollowRequest{Followee: "carol", Follower: alice.ID})
+	assert.NoError(t, err)
+	_, err = articles.Create(realworld.Article{Slug: "hello", Author: *alice})
+	assert.NoError(t, err)
+
+	uu, total, err := s.Followers(realworld.FollowListRequest{Username: "alice", Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, uu, 1)
+	assert.Equal(t, "carol", uu[0].Username)
+
+	uu, total, err = s.Followers(realworld.FollowListRequest{Username: "alice", Offset: 2, Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, uu, 1)
+	assert.Equal(t, "dave", uu[0].Username)
+
+	uu, total, err = s.Following(realworld.FollowListRequest{Username: "alice", Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "carol", uu[0].Username)
+	assert.True(t, uu[0].IsFollower(alice))
+
+	_, _, err = s.Following(realworld.FollowListRequest{Username: "nobody", Limit: 20})
+	assert.Equal(t, realworld.ErrUserNotFound, err)
+
+	stats, err := s.ProfileStats(*alice)
+	assert.NoError(t, err)
+	assert.Equal(t, realworld.ProfileStats{Followers: 3, Following: 1, Articles: 1}, *stats)
+}
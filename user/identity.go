@@ -0,0 +1,149 @@
+package user
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type ProvidersResponse struct {
+	Providers []string
+	Err       error
+}
+
+func (r ProvidersResponse) Failed() error { return r.Err }
+
+func ProvidersEndpoint(i realworld.IdentityService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		return ProvidersResponse{Providers: i.ProviderNames()}, nil
+	}
+}
+
+type AuthorizationURLRequest struct {
+	Provider string
+	// UserID is set when linking an identity to the user.
+	UserID int64
+}
+
+type AuthorizationURLResponse struct {
+	URL string
+	// Binding is for the browser to keep until the callback.
+	Binding string
+	Err     error
+}
+
+func (r AuthorizationURLResponse) Failed() error { return r.Err }
+
+func AuthorizationURLEndpoint(i realworld.IdentityService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(AuthorizationURLRequest)
+		u, binding, err := i.AuthorizationURL(req.Provider, realworld.User{ID: req.UserID})
+		if err != nil {
+			return nil, err
+		}
+		return AuthorizationURLResponse{URL: u, Binding: binding}, nil
+	}
+}
+
+type OAuthCallbackRequest struct {
+	Provider string
+	State    string
+	Code     string
+	Binding  string
+}
+
+func (req OAuthCallbackRequest) toCallback() realworld.OAuthCallback {
+	return realworld.OAuthCallback{
+		Provider: req.Provider,
+		State:    req.State,
+		Code:     req.Code,
+		Binding:  req.Binding,
+	}
+}
+
+// OAuthCallbackEndpoint logs the user in like LoginEndpoint does, second
+// factor included, unless an identity was linked, in which case the user's
+// identities are returned.
+func OAuthCallbackEndpoint(i realworld.IdentityService, s realworld.UserService, t realworld.RefreshTokenService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(OAuthCallbackRequest)
+		u, linked, err := i.Callback(req.toCallback())
+		if err != nil {
+			return nil, err
+		}
+		if linked {
+			return identitiesResponse(i, *u)
+		}
+		challenge, err := s.LoginChallenge(*u)
+		if err != nil {
+			return nil, err
+		}
+		if challenge != "" {
+			return Response{Challenge: challenge}, nil
+		}
+		rt, refresh, err := t.Issue(*u)
+		if err != nil {
+			return nil, err
+		}
+		resp := NewResponse(u, err)
+		resp.RefreshToken = refresh
+		resp.SessionID = rt.Family
+		return resp, nil
+	}
+}
+
+type Identity struct {
+	Provider  string
+	Email     string
+	CreatedAt time.Time
+}
+
+type IdentitiesRequest struct {
+	UserID int64
+}
+
+type IdentitiesResponse struct {
+	Identities []Identity
+	Err        error
+}
+
+func (r IdentitiesResponse) Failed() error { return r.Err }
+
+func IdentitiesEndpoint(i realworld.IdentityService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(IdentitiesRequest)
+		return identitiesResponse(i, realworld.User{ID: req.UserID})
+	}
+}
+
+func identitiesResponse(i realworld.IdentityService, u realworld.User) (IdentitiesResponse, error) {
+	ii, err := i.Identities(u)
+	if err != nil {
+		return IdentitiesResponse{}, err
+	}
+	identities := make([]Identity, 0, len(ii))
+	for _, id := range ii {
+		identities = append(identities, Identity{
+			Provider:  id.Provider,
+			Email:     id.Email,
+			CreatedAt: id.CreatedAt,
+		})
+	}
+	return IdentitiesResponse{Identities: identities}, nil
+}
+
+type UnlinkIdentityRequest struct {
+	UserID   int64
+	Provider string
+}
+
+func UnlinkIdentityEndpoint(i realworld.IdentityService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(UnlinkIdentityRequest)
+		if err := i.Unlink(realworld.User{ID: req.UserID}, req.Provider); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
@@ -0,0 +1,41 @@
+package user
+
+import (
+	"strings"
+	"time"
+)
+
+// lockedOut reports whether logins for the account or from the client IP
+// are locked out.
+func (s Service) lockedOut(account, clientIP string) bool {
+	now := time.Now()
+	if s.AccountLockout != nil && now.Before(s.AccountLockout.LockedUntil(account)) {
+		return true
+	}
+	if s.IPLockout != nil && clientIP != "" && now.Before(s.IPLockout.LockedUntil(clientIP)) {
+		return true
+	}
+	return false
+}
+
+func (s Service) loginFailed(account, clientIP string) {
+	if s.AccountLockout != nil {
+		s.AccountLockout.Fail(account)
+	}
+	if s.IPLockout != nil && clientIP != "" {
+		s.IPLockout.Fail(clientIP)
+	}
+}
+
+// loginSucceeded clears the failures of the account. Those of the IP are
+// kept, so logging in to an account of one's own doesn't make up for
+// guessing at others.
+func (s Service) loginSucceeded(account string) {
+	if s.AccountLockout != nil {
+		s.AccountLockout.Reset(account)
+	}
+}
+
+func lockoutKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,51 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"testing"
+	"time"
+)
+
+func TestService_LoginLockout(t *testing.T) {
+	s := Service{
+		UserRepo:       inmem.NewMemUserSaver(),
+		AccountLockout: inmem.NewLockout(3, time.Minute, time.Hour),
+		IPLockout:      inmem.NewLockout(5, time.Minute, time.Hour),
+	}
+
+	alice, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	_, err = s.Register(realworld.User{Username: "bobby", Email: "bobby@example.com", Password: "secret"})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = s.Login(realworld.User{Email: "alice@example.com", Password: "wrong"}, "10.0.0.1")
+		assert.Equal(t, realworld.ErrIncorrectPasswordError, err)
+	}
+
+	// Even the right password is refused, from any IP.
+	_, err = s.Login(realworld.User{Email: "ALICE@example.com", Password: "secret"}, "10.0.0.2")
+	assert.Equal(t, realworld.ErrLoginLockedOut, err)
+
+	// Unknown emails count against the IP like wrong passwords.
+	for i := 0; i < 2; i++ {
+		_, err = s.Login(realworld.User{Email: "nobody@example.com", Password: "wrong"}, "10.0.0.1")
+		assert.Equal(t, realworld.ErrUserNotFound, err)
+	}
+	_, err = s.Login(realworld.User{Email: "bobby@example.com", Password: "secret"}, "10.0.0.1")
+	assert.Equal(t, realworld.ErrLoginLockedOut, err)
+	_, err = s.Login(realworld.User{Email: "bobby@example.com", Password: "secret"}, "10.0.0.2")
+	assert.NoError(t, err)
+
+	assert.Equal(t, realworld.ErrNotAdmin, s.UnlockLogin(*alice, "alice"))
+
+	admin, err := s.Register(realworld.User{Username: "admin", Email: "admin@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.UserRepo.SetRole(admin.ID, realworld.RoleAdmin))
+
+	assert.NoError(t, s.UnlockLogin(*admin, "alice"))
+	_, err = s.Login(realworld.User{Email: "alice@example.com", Password: "secret"}, "10.0.0.2")
+	assert.NoError(t, err)
+}
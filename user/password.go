@@ -0,0 +1,108 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/token"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultResetTTL = time.Hour
+
+func (s Service) ForgotPassword(email string) error {
+	// The limit applies whether or not the email belongs to a user, so it
+	// doesn't reveal which ones do.
+	if !s.allowMail("reset", email) {
+		return realworld.ErrTooManyResets
+	}
+
+	u, err := s.UserRepo.Get(email)
+	if err != nil {
+		if errors.Is(err, realworld.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	plain, hash, err := token.Generate()
+	if err != nil {
+		return realworld.InternalError(err)
+	}
+
+	_, err = s.Resets.Create(realworld.PasswordReset{
+		UserID:    u.ID,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(s.resetTTL()),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.Mailer.Send(s.resetMessage(u, plain)); err != nil {
+		return realworld.InternalError(err)
+	}
+
+	return nil
+}
+
+func (s Service) ResetPassword(plain, password string) error {
+	r, err := s.Resets.GetByHash(token.Hash(plain))
+	if err != nil {
+		return err
+	}
+
+	if r.Used || r.Expired() {
+		return realworld.ErrInvalidResetToken
+	}
+
+	if err := s.Resets.Use(*r); err != nil {
+		return err
+	}
+
+	u, err := s.UserRepo.GetByID(r.UserID)
+	if err != nil {
+		return err
+	}
+
+	u.Password = password
+	_, err = s.Update(*u)
+	return err
+}
+
+func (s Service) resetMessage(u *realworld.User, plain string) realworld.Message {
+	return realworld.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the following to choose a new password. It expires in %s.\n\n%s\n\n"+
+				"If you didn't ask for this, you can ignore this email.\n",
+			u.Username, s.resetTTL(), link(s.ResetURL, plain),
+		),
+	}
+}
+
+// allowMail reports whether another email of the given kind may be sent to address.
+func (s Service) allowMail(kind, address string) bool {
+	if s.MailLimiter == nil {
+		return true
+	}
+	return s.MailLimiter.Allow(kind + ":" + strings.ToLower(strings.TrimSpace(address)))
+}
+
+// link returns the URL a one-time token is sent as, or the bare token without a base URL.
+func link(base, plain string) string {
+	if base == "" {
+		return plain
+	}
+	return base + "?token=" + url.QueryEscape(plain)
+}
+
+func (s Service) resetTTL() time.Duration {
+	if s.ResetTTL == 0 {
+		return defaultResetTTL
+	}
+	return s.ResetTTL
+}
@@ -0,0 +1,51 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type recordingMailer struct {
+	sent []realworld.Message
+}
+
+func (m *recordingMailer) Send(msg realworld.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestService_ResetPassword(t *testing.T) {
+	mailer := &recordingMailer{}
+	s := Service{
+		UserRepo:    inmem.NewMemUserSaver(),
+		Resets:      inmem.NewMemPasswordResetRepo(),
+		Mailer:      mailer,
+		MailLimiter: inmem.NewRateLimiter(1, time.Hour),
+		ResetURL:    "https://example.com/reset",
+	}
+
+	_, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.ForgotPassword("nobody@example.com"))
+	assert.Empty(t, mailer.sent)
+
+	assert.NoError(t, s.ForgotPassword("alice@example.com"))
+	assert.Len(t, mailer.sent, 1)
+	assert.Equal(t, "alice@example.com", mailer.sent[0].To)
+
+	token := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(mailer.sent[0].Body)[1]
+
+	assert.Equal(t, realworld.ErrInvalidResetToken, s.ResetPassword("bogus", "newsecret"))
+	assert.NoError(t, s.ResetPassword(token, "newsecret"))
+	assert.Equal(t, realworld.ErrInvalidResetToken, s.ResetPassword(token, "another"))
+
+	_, err = s.Login(realworld.User{Email: "alice@example.com", Password: "newsecret"}, "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, realworld.ErrTooManyResets, s.ForgotPassword("ALICE@example.com"))
+}
@@ -0,0 +1,94 @@
+package user
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+)
+
+func (s Service) Restrict(r realworld.RestrictionRequest) (*realworld.User, error) {
+	target, restriction, err := s.restriction(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Restrictions.Add(restriction); err != nil {
+		return nil, err
+	}
+
+	if r.Kind == realworld.RestrictionBlock {
+		if _, err := s.UserRepo.RemoveFollower(r.UserID, target.ID); err != nil {
+			return nil, err
+		}
+		if _, err := s.UserRepo.RemoveFollower(target.ID, r.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.UserRepo.GetByID(target.ID)
+}
+
+func (s Service) Unrestrict(r realworld.RestrictionRequest) (*realworld.User, error) {
+	target, restriction, err := s.restriction(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Restrictions.Remove(restriction); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// restriction looks up the user r is about and the restriction it describes.
+func (s Service) restriction(r realworld.RestrictionRequest) (*realworld.User, realworld.Restriction, error) {
+	if !realworld.ValidRestriction(r.Kind) {
+		return nil, realworld.Restriction{}, realworld.ErrInvalidRestriction
+	}
+
+	target, err := s.UserRepo.GetByUsername(r.Username)
+	if err != nil {
+		return nil, realworld.Restriction{}, err
+	}
+
+	if target.ID == r.UserID {
+		return nil, realworld.Restriction{}, realworld.ErrSelfRestriction
+	}
+
+	return target, realworld.Restriction{UserID: r.UserID, TargetID: target.ID, Kind: r.Kind}, nil
+}
+
+func (s Service) RestrictionsOn(u realworld.User, viewerID int64) (blocking, muting bool, err error) {
+	if s.Restrictions == nil || viewerID == 0 {
+		return false, false, nil
+	}
+
+	r := realworld.Restriction{UserID: viewerID, TargetID: u.ID, Kind: realworld.RestrictionBlock}
+	if blocking, err = s.Restrictions.Exists(r); err != nil {
+		return false, false, err
+	}
+
+	r.Kind = realworld.RestrictionMute
+	if muting, err = s.Restrictions.Exists(r); err != nil {
+		return false, false, err
+	}
+
+	return blocking, muting, nil
+}
+
+// blocked reports whether either of the users with the given IDs blocks the other.
+func (s Service) blocked(a, b int64) (bool, error) {
+	if s.Restrictions == nil {
+		return false, nil
+	}
+
+	for _, r := range []realworld.Restriction{
+		{UserID: a, TargetID: b, Kind: realworld.RestrictionBlock},
+		{UserID: b, TargetID: a, Kind: realworld.RestrictionBlock},
+	} {
+		if ok, err := s.Restrictions.Exists(r); err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"testing"
+)
+
+func TestService_Restrict(t *testing.T) {
+	s := Service{UserRepo: inmem.NewMemUserSaver(), Restrictions: inmem.NewMemRestrictionRepo()}
+
+	register := func(username string) *realworld.User {
+		u, err := s.Register(realworld.User{Username: username, Email: username + "@example.com", Password: "secret"})
+		assert.NoError(t, err)
+		return u
+	}
+	alice, bob := register("alice"), register("bobby")
+
+	_, err := s.Follow(realworld.FollowRequest{Followee: "alice", Follower: bob.ID})
+	assert.NoError(t, err)
+	_, err = s.Follow(realworld.FollowRequest{Followee: "bobby", Follower: alice.ID})
+	assert.NoError(t, err)
+
+	block := realworld.RestrictionRequest{Username: "bobby", UserID: alice.ID, Kind: realworld.RestrictionBlock}
+	u, err := s.Restrict(block)
+	assert.NoError(t, err)
+	assert.Empty(t, u.Followers)
+	assert.Empty(t, u.Followings)
+
+	blocking, muting, err := s.RestrictionsOn(*bob, alice.ID)
+	assert.NoError(t, err)
+	assert.True(t, blocking)
+	assert.False(t, muting)
+
+	// neither can follow the other
+	_, err = s.Follow(realworld.FollowRequest{Followee: "alice", Follower: bob.ID})
+	assert.Equal(t, realworld.ErrBlocked, err)
+	_, err = s.Follow(realworld.FollowRequest{Followee: "bobby", Follower: alice.ID})
+	assert.Equal(t, realworld.ErrBlocked, err)
+
+	_, err = s.Unrestrict(block)
+	assert.NoError(t, err)
+	_, err = s.Follow(realworld.FollowRequest{Followee: "bobby", Follower: alice.ID})
+	assert.NoError(t, err)
+
+	// muting leaves follows alone
+	u, err = s.Restrict(realworld.RestrictionRequest{Username: "bobby", UserID: alice.ID, Kind: realworld.RestrictionMute})
+	assert.NoError(t, err)
+	assert.True(t, u.IsFollower(alice))
+
+	_, err = s.Restrict(realworld.RestrictionRequest{Username: "alice", UserID: alice.ID, Kind: realworld.RestrictionMute})
+	assert.Equal(t, realworld.ErrSelfRestriction, err)
+	_, err = s.Restrict(realworld.RestrictionRequest{Username: "bobby", UserID: alice.ID, Kind: "ignore"})
+	assert.Equal(t, realworld.ErrInvalidRestriction, err)
+	_, err = s.Restrict(realworld.RestrictionRequest{Username: "nobody", UserID: alice.ID, Kind: realworld.RestrictionBlock})
+	assert.Equal(t, realworld.ErrUserNotFound, err)
+}
@@ -0,0 +1,69 @@
+package user
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	realworld "github.com/xesina/gokit-realworld"
+	"time"
+)
+
+type SessionsRequest struct {
+	UserID int64
+	// Current is the session the request was made from.
+	Current string
+}
+
+type Session struct {
+	ID         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	Current    bool
+}
+
+type SessionsResponse struct {
+	Sessions []Session
+	Err      error
+}
+
+func (r SessionsResponse) Failed() error { return r.Err }
+
+func NewSessionsResponse(ss []*realworld.Session, current string, err error) SessionsResponse {
+	sessions := make([]Session, 0, len(ss))
+	for _, s := range ss {
+		sessions = append(sessions, Session{
+			ID:         s.ID,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == current,
+		})
+	}
+	return SessionsResponse{Sessions: sessions, Err: err}
+}
+
+func SessionsEndpoint(t realworld.SessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SessionsRequest)
+		ss, err := t.Sessions(realworld.User{ID: req.UserID})
+		if err != nil {
+			return nil, err
+		}
+		return NewSessionsResponse(ss, req.Current, err), nil
+	}
+}
+
+type RevokeSessionRequest struct {
+	UserID int64
+	ID     string
+}
+
+func RevokeSessionEndpoint(t realworld.SessionService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RevokeSessionRequest)
+		if err := t.RevokeSession(realworld.User{ID: req.UserID}, req.ID); err != nil {
+			return nil, err
+		}
+		return EmptyResponse{}, nil
+	}
+}
@@ -0,0 +1,262 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/token"
+	"github.com/xesina/gokit-realworld/totp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTOTPIssuer = "Conduit"
+	// totpSkew is how many time steps either way a code is accepted for, to
+	// allow for clock drift.
+	totpSkew             = 1
+	challengeTTL         = time.Minute * 5
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+func (s Service) LoginChallenge(u realworld.User) (string, error) {
+	enabled, err := s.twoFactorEnabled(u.ID)
+	if err != nil || !enabled {
+		return "", err
+	}
+
+	plain, hash, err := token.Generate()
+	if err != nil {
+		return "", realworld.InternalError(err)
+	}
+
+	_, err = s.Challenges.Create(realworld.LoginChallenge{
+		UserID:    u.ID,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(challengeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return plain, nil
+}
+
+// twoFactorEnabled reports whether logging in as the user with the given ID
+// takes a second factor.
+func (s Service) twoFactorEnabled(id int64) (bool, error) {
+	if s.TwoFactor == nil {
+		return false, nil
+	}
+
+	tf, err := s.TwoFactor.Get(id)
+	if err != nil {
+		if errors.Is(err, realworld.ErrTwoFactorNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return tf.Enabled, nil
+}
+
+// LoginTwoFactor finishes a login with the second factor. Wrong codes count
+// against the same lockouts as wrong passwords do, so asking for new
+// challenges doesn't allow for more guesses.
+func (s Service) LoginTwoFactor(challenge, code, clientIP string) (*realworld.User, error) {
+	c, err := s.Challenges.GetByHash(token.Hash(challenge))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Used || c.Expired() || c.Attempts >= maxChallengeAttempts {
+		return nil, realworld.ErrInvalidLoginChallenge
+	}
+
+	u, err := s.UserRepo.GetByID(c.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := lockoutKey(u.Email)
+	if s.lockedOut(account, clientIP) {
+		return nil, realworld.ErrLoginLockedOut
+	}
+
+	// The user may have been banned since the password was checked.
+	if u.Banned {
+		return nil, realworld.ErrUserBanned
+	}
+
+	tf, err := s.TwoFactor.Get(c.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCode(tf, code); err != nil {
+		if errors.Is(err, realworld.ErrInvalidTwoFactorCode) {
+			s.loginFailed(account, clientIP)
+			if err := s.Challenges.Fail(*c); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.Challenges.Use(*c); err != nil {
+		return nil, err
+	}
+
+	s.loginSucceeded(account)
+
+	return u, nil
+}
+
+func (s Service) EnrollTwoFactor(u realworld.User) (*realworld.TwoFactorEnrollment, error) {
+	found, err := s.UserRepo.GetByID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tf, err := s.TwoFactor.Get(u.ID)
+	if err != nil && !errors.Is(err, realworld.ErrTwoFactorNotFound) {
+		return nil, err
+	}
+	if tf != nil && tf.Enabled {
+		return nil, realworld.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, realworld.InternalError(err)
+	}
+
+	// Enrolling again replaces a secret that was never confirmed.
+	if err := s.TwoFactor.Save(realworld.TwoFactor{UserID: u.ID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	return &realworld.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.totpIssuer(), found.Email, secret),
+	}, nil
+}
+
+func (s Service) ConfirmTwoFactor(u realworld.User, code string) ([]string, error) {
+	tf, err := s.TwoFactor.Get(u.ID)
+	if err != nil {
+		if errors.Is(err, realworld.ErrTwoFactorNotFound) {
+			return nil, realworld.ErrTwoFactorNotEnrolled
+		}
+		return nil, err
+	}
+
+	if tf.Enabled {
+		return nil, realworld.ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, realworld.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := recoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, realworld.InternalError(err)
+	}
+
+	tf.Enabled = true
+	tf.LastStep = step
+	tf.RecoveryCodes = hashes
+	if err := s.TwoFactor.Save(*tf); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor takes a code like logging in does, and wrong ones count
+// against the account's lockout the same way, so a stolen session can't be
+// used to guess codes until two-factor authentication can be turned off.
+func (s Service) DisableTwoFactor(u realworld.User, code string) error {
+	found, err := s.UserRepo.GetByID(u.ID)
+	if err != nil {
+		return err
+	}
+
+	tf, err := s.TwoFactor.Get(u.ID)
+	if err != nil {
+		if errors.Is(err, realworld.ErrTwoFactorNotFound) {
+			return realworld.ErrTwoFactorNotEnrolled
+		}
+		return err
+	}
+
+	if !tf.Enabled {
+		return realworld.ErrTwoFactorNotEnrolled
+	}
+
+	account := lockoutKey(found.Email)
+	if s.lockedOut(account, "") {
+		return realworld.ErrLoginLockedOut
+	}
+
+	if err := s.checkCode(tf, code); err != nil {
+		if errors.Is(err, realworld.ErrInvalidTwoFactorCode) {
+			s.loginFailed(account, "")
+		}
+		return err
+	}
+
+	return s.TwoFactor.Delete(u.ID)
+}
+
+// checkCode accepts a current TOTP code or an unused recovery code, and
+// saves tf so that neither can be used again.
+func (s Service) checkCode(tf *realworld.TwoFactor, code string) error {
+	code = strings.TrimSpace(code)
+
+	if step, ok := totp.Validate(tf.Secret, code, time.Now(), totpSkew); ok && step > tf.LastStep {
+		tf.LastStep = step
+		return s.TwoFactor.Save(*tf)
+	}
+
+	hash := token.Hash(normalizeRecoveryCode(code))
+	for i, h := range tf.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			tf.RecoveryCodes = append(tf.RecoveryCodes[:i:i], tf.RecoveryCodes[i+1:]...)
+			return s.TwoFactor.Save(*tf)
+		}
+	}
+
+	return realworld.ErrInvalidTwoFactorCode
+}
+
+func (s Service) totpIssuer() string {
+	if s.TOTPIssuer == "" {
+		return defaultTOTPIssuer
+	}
+	return s.TOTPIssuer
+}
+
+// recoveryCodes returns n codes formatted like "abcd-efgh" along with their hashes.
+func recoveryCodes(n int) (codes, hashes []string, err error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(enc.EncodeToString(b))
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, token.Hash(code))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
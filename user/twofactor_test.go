@@ -0,0 +1,187 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"github.com/xesina/gokit-realworld/totp"
+	"testing"
+	"time"
+)
+
+func TestService_TwoFactor(t *testing.T) {
+	s := Service{
+		UserRepo:   inmem.NewMemUserSaver(),
+		TwoFactor:  inmem.NewMemTwoFactorRepo(),
+		Challenges: inmem.NewMemLoginChallengeRepo(),
+	}
+
+	u, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+
+	challenge, err := s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	assert.Empty(t, challenge)
+
+	e, err := s.EnrollTwoFactor(*u)
+	assert.NoError(t, err)
+	assert.Contains(t, e.URI, "otpauth://totp/")
+
+	// Enrollment alone doesn't change how the user logs in.
+	challenge, err = s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	assert.Empty(t, challenge)
+
+	_, err = s.ConfirmTwoFactor(*u, "000000")
+	assert.Equal(t, realworld.ErrInvalidTwoFactorCode, err)
+
+	code, err := totp.Code(e.Secret, totp.Step(time.Now()))
+	assert.NoError(t, err)
+	codes, err := s.ConfirmTwoFactor(*u, code)
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	challenge, err = s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, challenge)
+
+	// The code used to confirm can't be replayed.
+	_, err = s.LoginTwoFactor(challenge, code, "")
+	assert.Equal(t, realworld.ErrInvalidTwoFactorCode, err)
+
+	found, err := s.LoginTwoFactor(challenge, codes[0], "")
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, found.ID)
+
+	_, err = s.LoginTwoFactor(challenge, codes[1], "")
+	assert.Equal(t, realworld.ErrInvalidLoginChallenge, err)
+
+	challenge, err = s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	_, err = s.LoginTwoFactor(challenge, codes[0], "")
+	assert.Equal(t, realworld.ErrInvalidTwoFactorCode, err)
+
+	assert.NoError(t, s.DisableTwoFactor(*u, codes[1]))
+	challenge, err = s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	assert.Empty(t, challenge)
+}
+
+func TestService_LoginTwoFactorAttempts(t *testing.T) {
+	s := Service{
+		UserRepo:   inmem.NewMemUserSaver(),
+		TwoFactor:  inmem.NewMemTwoFactorRepo(),
+		Challenges: inmem.NewMemLoginChallengeRepo(),
+	}
+
+	u, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	e, err := s.EnrollTwoFactor(*u)
+	assert.NoError(t, err)
+	code, err := totp.Code(e.Secret, totp.Step(time.Now()))
+	assert.NoError(t, err)
+	codes, err := s.ConfirmTwoFactor(*u, code)
+	assert.NoError(t, err)
+
+	challenge, err := s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	for i := 0; i < maxChallengeAttempts; i++ {
+		_, err = s.LoginTwoFactor(challenge, "wrong", "")
+		assert.Equal(t, realworld.ErrInvalidTwoFactorCode, err)
+	}
+
+	_, err = s.LoginTwoFactor(challenge, codes[0], "")
+	assert.Equal(t, realworld.ErrInvalidLoginChallenge, err)
+}
+
+func TestService_LoginTwoFactorLockout(t *testing.T) {
+	s := Service{
+		UserRepo:       inmem.NewMemUserSaver(),
+		TwoFactor:      inmem.NewMemTwoFactorRepo(),
+		Challenges:     inmem.NewMemLoginChallengeRepo(),
+		AccountLockout: inmem.NewLockout(3, time.Minute, time.Hour),
+	}
+
+	u, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	e, err := s.EnrollTwoFactor(*u)
+	assert.NoError(t, err)
+	code, err := totp.Code(e.Secret, totp.Step(time.Now()))
+	assert.NoError(t, err)
+	codes, err := s.ConfirmTwoFactor(*u, code)
+	assert.NoError(t, err)
+
+	// A fresh challenge for every guess, after the right password each time.
+	for i := 0; i < 3; i++ {
+		found, err := s.Login(realworld.User{Email: "alice@example.com", Password: "secret"}, "10.0.0.1")
+		assert.NoError(t, err)
+		challenge, err := s.LoginChallenge(*found)
+		assert.NoError(t, err)
+		_, err = s.LoginTwoFactor(challenge, "wrong", "10.0.0.1")
+		assert.Equal(t, realworld.ErrInvalidTwoFactorCode, err)
+	}
+
+	_, err = s.Login(realworld.User{Email: "alice@example.com", Password: "secret"}, "10.0.0.1")
+	assert.Equal(t, realworld.ErrLoginLockedOut, err)
+
+	// Even a challenge handed out before the lockout takes effect is refused.
+	challenge, err := s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	_, err = s.LoginTwoFactor(challenge, codes[0], "10.0.0.2")
+	assert.Equal(t, realworld.ErrLoginLockedOut, err)
+}
+
+func TestService_LoginTwoFactorBanned(t *testing.T) {
+	s := Service{
+		UserRepo:   inmem.NewMemUserSaver(),
+		TwoFactor:  inmem.NewMemTwoFactorRepo(),
+		Challenges: inmem.NewMemLoginChallengeRepo(),
+	}
+
+	u, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	e, err := s.EnrollTwoFactor(*u)
+	assert.NoError(t, err)
+	code, err := totp.Code(e.Secret, totp.Step(time.Now()))
+	assert.NoError(t, err)
+	codes, err := s.ConfirmTwoFactor(*u, code)
+	assert.NoError(t, err)
+
+	challenge, err := s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	assert.NoError(t, s.UserRepo.SetBanned(u.ID, true))
+
+	_, err = s.LoginTwoFactor(challenge, codes[0], "")
+	assert.Equal(t, realworld.ErrUserBanned, err)
+}
+
+func TestService_DisableTwoFactorLockout(t *testing.T) {
+	s := Service{
+		UserRepo:       inmem.NewMemUserSaver(),
+		TwoFactor:      inmem.NewMemTwoFactorRepo(),
+		Challenges:     inmem.NewMemLoginChallengeRepo(),
+		AccountLockout: inmem.NewLockout(3, time.Minute, time.Hour),
+	}
+
+	u, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	e, err := s.EnrollTwoFactor(*u)
+	assert.NoError(t, err)
+	code, err := totp.Code(e.Secret, totp.Step(time.Now()))
+	assert.NoError(t, err)
+	codes, err := s.ConfirmTwoFactor(*u, code)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, realworld.ErrInvalidTwoFactorCode, s.DisableTwoFactor(*u, "wrong"))
+	}
+
+	// Even a right code is refused now, and logging in is locked out too.
+	assert.Equal(t, realworld.ErrLoginLockedOut, s.DisableTwoFactor(*u, codes[0]))
+	_, err = s.Login(realworld.User{Email: "alice@example.com", Password: "secret"}, "")
+	assert.Equal(t, realworld.ErrLoginLockedOut, err)
+
+	challenge, err := s.LoginChallenge(*u)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, challenge)
+}
@@ -2,31 +2,117 @@ package user
 
 import (
 	realworld "github.com/xesina/gokit-realworld"
+	"time"
 )
 
 type Service struct {
 	UserRepo realworld.UserRepo
+	// Sessions, when set, has every session of a user revoked once their password changes.
+	Sessions realworld.SessionService
+
+	Resets        realworld.PasswordResetRepo
+	Verifications realworld.EmailVerificationRepo
+	Mailer        realworld.Mailer
+	// MailLimiter limits how often each kind of email can be requested for an address.
+	MailLimiter realworld.RateLimiter
+	// ResetTTL is how long a reset token stays valid. defaultResetTTL is used when it's zero.
+	ResetTTL time.Duration
+	// ResetURL, when set, is the page reset tokens are linked to as its "token" query parameter.
+	ResetURL string
+	// VerifyTTL is how long an email verification token stays valid. defaultVerifyTTL is used when it's zero.
+	VerifyTTL time.Duration
+	// VerifyURL is like ResetURL for email verification tokens.
+	VerifyURL string
+
+	TwoFactor  realworld.TwoFactorRepo
+	Challenges realworld.LoginChallengeRepo
+	// TOTPIssuer names the service in authenticator apps. defaultTOTPIssuer is used when it's empty.
+	TOTPIssuer string
+
+	// AccountLockout and IPLockout, when set, throttle failed logins per
+	// email address and per client IP respectively.
+	AccountLockout realworld.Lockout
+	IPLockout      realworld.Lockout
+
+	// Articles, when set, has the articles of users counted on their profiles.
+	Articles realworld.ArticleRepo
+
+	// Restrictions holds who blocks and mutes whom. Follows are only checked
+	// against blocks when it is set.
+	Restrictions realworld.RestrictionRepo
+
+	// Usernames, when set, keeps the usernames users give up, which then
+	// lead to their profile and can't be taken by anyone else until
+	// UsernameReservation has passed. DefaultUsernameReservation is used
+	// when it's zero.
+	Usernames           realworld.UsernameHistoryRepo
+	UsernameReservation time.Duration
 }
 
 func (s Service) Register(u realworld.User) (*realworld.User, error) {
+	if err := s.checkUsername(0, u.Username); err != nil {
+		return nil, err
+	}
+
 	hashed, err := u.HashPassword(u.Password)
 	if err != nil {
 		return nil, realworld.InternalError(err)
 	}
 	u.Password = hashed
-	return s.UserRepo.Create(u)
+
+	// Without verifications configured there's nothing to wait for.
+	u.EmailVerified = s.Verifications == nil
+	u.Role = realworld.RoleUser
+
+	created, err := s.UserRepo.Create(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if !created.EmailVerified {
+		// A failed email doesn't undo the registration; the user can ask
+		// for another one.
+		_ = s.sendVerification(created)
+	}
+
+	return created, nil
 }
 
-func (s Service) Login(u realworld.User) (*realworld.User, error) {
+func (s Service) Login(u realworld.User, clientIP string) (*realworld.User, error) {
+	account := lockoutKey(u.Email)
+	if s.lockedOut(account, clientIP) {
+		return nil, realworld.ErrLoginLockedOut
+	}
+
 	found, err := s.UserRepo.Get(u.Email)
 	if err != nil {
+		// Guesses at unknown emails count too, so it makes no difference
+		// to a lockout whether the account exists.
+		if realworld.ErrorCode(err) == realworld.ENotFound {
+			s.loginFailed(account, clientIP)
+		}
 		return nil, err
 	}
 
 	if !found.CheckPassword(u.Password) {
+		s.loginFailed(account, clientIP)
 		return nil, realworld.ErrIncorrectPasswordError
 	}
 
+	if found.Banned {
+		return nil, realworld.ErrUserBanned
+	}
+
+	// With a second factor to go the failures are kept until it's passed,
+	// or a correct password would clear those of wrong codes.
+	enabled, err := s.twoFactorEnabled(found.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		s.loginSucceeded(account)
+	}
+
 	return found, nil
 }
 
@@ -34,22 +120,96 @@ func (s Service) Get(u realworld.User) (*realworld.User, error) {
 	return s.UserRepo.GetByID(u.ID)
 }
 
+// Update leaves the password as it is when u has none.
 func (s Service) Update(u realworld.User) (*realworld.User, error) {
-	// TODO: check: this is a full update. should I consider patching instead?
-	// TODO: check: where should I check if this user exists at all? in store or service impl?
+	p := realworld.UserPatch{
+		Username: &u.Username,
+		Email:    &u.Email,
+		Bio:      &u.Bio,
+		Image:    &u.Image,
+	}
 	if u.Password != "" {
-		hashed, err := u.HashPassword(u.Password)
+		p.Password = &u.Password
+	}
+	return s.Patch(u, p)
+}
+
+func (s Service) Patch(u realworld.User, p realworld.UserPatch) (*realworld.User, error) {
+	found, err := s.UserRepo.GetByID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	old := found.Username
+	if p.Username != nil && *p.Username != old {
+		if err := s.checkUsername(found.ID, *p.Username); err != nil {
+			return nil, err
+		}
+		found.Username = *p.Username
+	}
+	if p.Bio != nil {
+		found.Bio = *p.Bio
+	}
+	if p.Image != nil {
+		found.Image = *p.Image
+	}
+	if p.Password != nil {
+		hashed, err := found.HashPassword(*p.Password)
 		if err != nil {
 			return nil, realworld.InternalError(err)
 		}
-		u.Password = hashed
+		found.Password = hashed
+	}
+
+	// Without verifications configured a new email takes effect right away,
+	// like it does on registration.
+	var email string
+	if p.Email != nil && *p.Email != found.Email {
+		if err := s.checkEmail(found.ID, *p.Email); err != nil {
+			return nil, err
+		}
+		switch {
+		case s.Verifications == nil:
+			found.Email = *p.Email
+		case !s.allowMail("verify", *p.Email):
+			return nil, realworld.ErrTooManyVerifications
+		default:
+			email = *p.Email
+		}
 	}
 
-	return s.UserRepo.Update(u)
+	updated, err := s.UserRepo.Update(*found)
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.Username != old && s.Usernames != nil {
+		if err := s.Usernames.Add(realworld.UsernameChange{UserID: updated.ID, Username: old}); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Password != nil && s.Sessions != nil {
+		if err := s.Sessions.RevokeSessions(*updated); err != nil {
+			return nil, err
+		}
+	}
+
+	if email != "" {
+		// As on registration, a failed email doesn't undo the rest; the
+		// user can ask for the change again.
+		_ = s.sendEmailChange(updated, email)
+	}
+
+	return updated, nil
 }
 
 func (s Service) GetProfile(user realworld.User) (*realworld.User, error) {
-	return s.UserRepo.GetByUsername(user.Username)
+	found, err := s.UserRepo.GetByUsername(user.Username)
+	if realworld.ErrorCode(err) == realworld.ENotFound {
+		return s.renamed(user.Username)
+	}
+	return found, err
 }
 
 func (s Service) Follow(req realworld.FollowRequest) (*realworld.User, error) {
@@ -58,6 +218,14 @@ func (s Service) Follow(req realworld.FollowRequest) (*realworld.User, error) {
 		return nil, err
 	}
 
+	blocked, err := s.blocked(req.Follower, followee.ID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, realworld.ErrBlocked
+	}
+
 	return s.UserRepo.AddFollower(req.Follower, followee.ID)
 }
 
@@ -69,3 +237,49 @@ func (s Service) Unfollow(req realworld.FollowRequest) (*realworld.User, error)
 
 	return s.UserRepo.RemoveFollower(req.Follower, followee.ID)
 }
+
+func (s Service) ProfileStats(u realworld.User) (*realworld.ProfileStats, error) {
+	found, err := s.UserRepo.GetByID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := realworld.ProfileStats{
+		Followers: len(found.Followers),
+		Following: len(found.Followings),
+	}
+
+	if s.Articles != nil {
+		if stats.Articles, err = s.Articles.CountByAuthorID(found.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &stats, nil
+}
+
+func (s Service) Followers(r realworld.FollowListRequest) ([]*realworld.User, int, error) {
+	u, err := s.UserRepo.GetByUsername(r.Username)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.UserRepo.ListFollowers(u.ID, r.Offset, r.Limit)
+}
+
+func (s Service) Following(r realworld.FollowListRequest) ([]*realworld.User, int, error) {
+	u, err := s.UserRepo.GetByUsername(r.Username)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.UserRepo.ListFollowing(u.ID, r.Offset, r.Limit)
+}
+
+func (s Service) Search(r realworld.UserSearchRequest) ([]*realworld.User, int, error) {
+	return s.UserRepo.Search(r)
+}
+
+func (s Service) Autocomplete(prefix string, limit int) ([]*realworld.User, error) {
+	return s.UserRepo.ListByUsernamePrefix(prefix, limit)
+}
@@ -0,0 +1,71 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"regexp"
+	"testing"
+)
+
+func TestService_Patch(t *testing.T) {
+	mailer := &recordingMailer{}
+	s := Service{
+		UserRepo:      inmem.NewMemUserSaver(),
+		Verifications: inmem.NewMemEmailVerificationRepo(),
+		Mailer:        mailer,
+	}
+
+	u, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret", Bio: realworld.Bio{Value: "hi", Valid: true}})
+	assert.NoError(t, err)
+	_, err = s.Register(realworld.User{Username: "bob", Email: "bob@example.com", Password: "secret"})
+	assert.NoError(t, err)
+
+	str := func(s string) *string { return &s }
+
+	// only what's set changes
+	found, err := s.Patch(*u, realworld.UserPatch{Username: str("alicia")})
+	assert.NoError(t, err)
+	assert.Equal(t, "alicia", found.Username)
+	assert.Equal(t, "alice@example.com", found.Email)
+	assert.Equal(t, "hi", found.Bio.Value)
+
+	found, err = s.Patch(*u, realworld.UserPatch{Bio: &realworld.Bio{}})
+	assert.NoError(t, err)
+	assert.False(t, found.Bio.Valid)
+	assert.Equal(t, "alicia", found.Username)
+
+	_, err = s.Patch(*u, realworld.UserPatch{Username: str("bob")})
+	assert.Equal(t, realworld.ErrUserAlreadyExists, err)
+	_, err = s.Patch(*u, realworld.UserPatch{Email: str("bob@example.com")})
+	assert.Equal(t, realworld.ErrUserAlreadyExists, err)
+
+	// a new email waits to be verified
+	mailer.sent = nil
+	found, err = s.Patch(*u, realworld.UserPatch{Email: str("alicia@example.com")})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", found.Email)
+	if assert.Len(t, mailer.sent, 1) {
+		assert.Equal(t, "alicia@example.com", mailer.sent[0].To)
+	}
+	token := regexp.MustCompile(`\n\n(\S+)\n$`).FindStringSubmatch(mailer.sent[0].Body)[1]
+
+	assert.NoError(t, s.VerifyEmail(token))
+	found, err = s.Get(*u)
+	assert.NoError(t, err)
+	assert.Equal(t, "alicia@example.com", found.Email)
+	assert.True(t, found.EmailVerified)
+
+	// the old address is free again
+	_, err = s.Register(realworld.User{Username: "carol", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+
+	// the address can be taken before the change is verified
+	mailer.sent = nil
+	_, err = s.Patch(*u, realworld.UserPatch{Email: str("dave@example.com")})
+	assert.NoError(t, err)
+	token = regexp.MustCompile(`\n\n(\S+)\n$`).FindStringSubmatch(mailer.sent[0].Body)[1]
+	_, err = s.Register(realworld.User{Username: "dave", Email: "dave@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, realworld.ErrUserAlreadyExists, s.VerifyEmail(token))
+}
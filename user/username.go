@@ -0,0 +1,25 @@
+package user
+
+import (
+	realworld "github.com/xesina/gokit-realworld"
+)
+
+// checkUsername returns ErrUsernameReserved when someone other than the user
+// with the given ID gave up the username too recently for it to be taken.
+func (s Service) checkUsername(id int64, username string) error {
+	return realworld.CheckUsername(s.Usernames, s.UsernameReservation, id, username)
+}
+
+// renamed returns the user who last gave up the username, which still leads
+// to them as long as nobody else has it.
+func (s Service) renamed(username string) (*realworld.User, error) {
+	if s.Usernames == nil {
+		return nil, realworld.ErrUserNotFound
+	}
+
+	c, err := s.Usernames.Latest(username)
+	if err != nil {
+		return nil, err
+	}
+	return s.UserRepo.GetByID(c.UserID)
+}
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"testing"
+	"time"
+)
+
+func TestService_UsernameHistory(t *testing.T) {
+	s := Service{
+		UserRepo:  inmem.NewMemUserSaver(),
+		Usernames: inmem.NewMemUsernameHistoryRepo(),
+	}
+
+	alice, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	bob, err := s.Register(realworld.User{Username: "bob", Email: "bob@example.com", Password: "secret"})
+	assert.NoError(t, err)
+
+	rename := func(u *realworld.User, username string) error {
+		_, err := s.Patch(*u, realworld.UserPatch{Username: &username})
+		return err
+	}
+
+	assert.NoError(t, rename(alice, "alicia"))
+	assert.NoError(t, rename(alice, "ali"))
+
+	// every username alice gave up leads to her
+	for _, username := range []string{"alice", "alicia", "ali"} {
+		found, err := s.GetProfile(realworld.User{Username: username})
+		assert.NoError(t, err)
+		assert.Equal(t, alice.ID, found.ID, username)
+		assert.Equal(t, "ali", found.Username, username)
+	}
+	_, err = s.GetProfile(realworld.User{Username: "carol"})
+	assert.Equal(t, realworld.ErrUserNotFound, err)
+
+	// nobody else can take them for a while, but alice can take them back
+	assert.Equal(t, realworld.ErrUsernameReserved, rename(bob, "alice"))
+	_, err = s.Register(realworld.User{Username: "alicia", Email: "carol@example.com", Password: "secret"})
+	assert.Equal(t, realworld.ErrUsernameReserved, err)
+	assert.NoError(t, rename(alice, "alicia"))
+
+	// once the reservation ends, whoever takes the username gets its profile
+	s.UsernameReservation = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, rename(bob, "alice"))
+	found, err := s.GetProfile(realworld.User{Username: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, bob.ID, found.ID)
+}
@@ -0,0 +1,126 @@
+package user
+
+import (
+	"fmt"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/token"
+	"time"
+)
+
+const defaultVerifyTTL = time.Hour * 48
+
+func (s Service) VerifyEmail(plain string) error {
+	v, err := s.Verifications.GetByHash(token.Hash(plain))
+	if err != nil {
+		return err
+	}
+
+	if v.Used || v.Expired() {
+		return realworld.ErrInvalidVerificationToken
+	}
+
+	u, err := s.UserRepo.GetByID(v.UserID)
+	if err != nil {
+		return err
+	}
+
+	// The token only vouches for the address it was sent to, which replaces
+	// the user's own when the token was sent to confirm a change.
+	if v.Change {
+		if err := s.checkEmail(u.ID, v.Email); err != nil {
+			return err
+		}
+		u.Email = v.Email
+	} else if u.Email != v.Email {
+		return realworld.ErrInvalidVerificationToken
+	}
+
+	if err := s.Verifications.Use(*v); err != nil {
+		return err
+	}
+
+	u.EmailVerified = true
+	_, err = s.UserRepo.Update(*u)
+	return err
+}
+
+func (s Service) ResendVerification(u realworld.User) error {
+	found, err := s.UserRepo.GetByID(u.ID)
+	if err != nil {
+		return err
+	}
+
+	if found.EmailVerified {
+		return realworld.ErrEmailAlreadyVerified
+	}
+
+	if !s.allowMail("verify", found.Email) {
+		return realworld.ErrTooManyVerifications
+	}
+
+	return s.sendVerification(found)
+}
+
+// checkEmail returns ErrUserAlreadyExists when a user other than the one
+// with the given ID has the email.
+func (s Service) checkEmail(id int64, email string) error {
+	other, err := s.UserRepo.Get(email)
+	if err != nil && realworld.ErrorCode(err) != realworld.ENotFound {
+		return err
+	}
+	if other != nil && other.ID != id {
+		return realworld.ErrUserAlreadyExists
+	}
+	return nil
+}
+
+func (s Service) sendVerification(u *realworld.User) error {
+	return s.mailVerification(u, u.Email, false, "Verify your email",
+		"Use the following to verify your email address.")
+}
+
+// sendEmailChange mails a token to email, which becomes the user's once
+// they verify it. Until then their email stays as it is.
+func (s Service) sendEmailChange(u *realworld.User, email string) error {
+	return s.mailVerification(u, email, true, "Confirm your new email",
+		"Use the following to confirm this is your new email address.")
+}
+
+func (s Service) mailVerification(u *realworld.User, email string, change bool, subject, intro string) error {
+	plain, hash, err := token.Generate()
+	if err != nil {
+		return realworld.InternalError(err)
+	}
+
+	_, err = s.Verifications.Create(realworld.EmailVerification{
+		UserID:    u.ID,
+		Email:     email,
+		Hash:      hash,
+		Change:    change,
+		ExpiresAt: time.Now().Add(s.verifyTTL()),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.Mailer.Send(realworld.Message{
+		To:      email,
+		Subject: subject,
+		Body: fmt.Sprintf(
+			"Hi %s,\n\n%s It expires in %s.\n\n%s\n",
+			u.Username, intro, s.verifyTTL(), link(s.VerifyURL, plain),
+		),
+	})
+	if err != nil {
+		return realworld.InternalError(err)
+	}
+
+	return nil
+}
+
+func (s Service) verifyTTL() time.Duration {
+	if s.VerifyTTL == 0 {
+		return defaultVerifyTTL
+	}
+	return s.VerifyTTL
+}
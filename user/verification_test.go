@@ -0,0 +1,36 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	realworld "github.com/xesina/gokit-realworld"
+	"github.com/xesina/gokit-realworld/inmem"
+	"regexp"
+	"testing"
+)
+
+func TestService_VerifyEmail(t *testing.T) {
+	mailer := &recordingMailer{}
+	s := Service{
+		UserRepo:      inmem.NewMemUserSaver(),
+		Verifications: inmem.NewMemEmailVerificationRepo(),
+		Mailer:        mailer,
+		VerifyURL:     "https://example.com/verify",
+	}
+
+	u, err := s.Register(realworld.User{Username: "alice", Email: "alice@example.com", Password: "secret"})
+	assert.NoError(t, err)
+	assert.False(t, u.EmailVerified)
+	assert.Len(t, mailer.sent, 1)
+
+	token := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(mailer.sent[0].Body)[1]
+
+	assert.Equal(t, realworld.ErrInvalidVerificationToken, s.VerifyEmail("bogus"))
+	assert.NoError(t, s.VerifyEmail(token))
+	assert.Equal(t, realworld.ErrInvalidVerificationToken, s.VerifyEmail(token))
+
+	u, err = s.Get(*u)
+	assert.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+
+	assert.Equal(t, realworld.ErrEmailAlreadyVerified, s.ResendVerification(*u))
+}
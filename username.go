@@ -0,0 +1,54 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUsernameReserved = Error{EConflict, errors.New("username was recently given up by another user")}
+
+// DefaultUsernameReservation is how long a username that was given up is
+// kept from others when no reservation is configured.
+const DefaultUsernameReservation = time.Hour * 24 * 90
+
+// UsernameChange records a username a user gave up for another.
+type UsernameChange struct {
+	ID        int64
+	UserID    int64
+	Username  string
+	CreatedAt time.Time
+}
+
+type UsernameHistoryRepo interface {
+	Add(c UsernameChange) error
+	// Latest returns the last time the username was given up, or
+	// ErrUserNotFound if it never was.
+	Latest(username string) (*UsernameChange, error)
+	DeleteByUserID(id int64) error
+}
+
+// CheckUsername returns ErrUsernameReserved when someone other than the user
+// with the given ID gave up the username less than reservation ago, or
+// DefaultUsernameReservation when it's zero. Nothing is reserved without a
+// history to go by.
+func CheckUsername(history UsernameHistoryRepo, reservation time.Duration, id int64, username string) error {
+	if history == nil {
+		return nil
+	}
+	if reservation == 0 {
+		reservation = DefaultUsernameReservation
+	}
+
+	c, err := history.Latest(username)
+	if err != nil {
+		if ErrorCode(err) == ENotFound {
+			return nil
+		}
+		return err
+	}
+
+	if c.UserID != id && time.Since(c.CreatedAt) < reservation {
+		return ErrUsernameReserved
+	}
+	return nil
+}
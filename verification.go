@@ -0,0 +1,39 @@
+package gokit_realworld
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidVerificationToken = Error{EInvalidToken, errors.New("invalid or expired verification token")}
+	ErrEmailAlreadyVerified     = Error{EConflict, errors.New("email is already verified")}
+	ErrEmailNotVerified         = Error{EForbidden, errors.New("email is not verified")}
+	ErrTooManyVerifications     = Error{ERateLimit, errors.New("too many verification emails requested")}
+)
+
+// EmailVerification is a single-use token proving the user can read mail
+// sent to Email. Only the hash of the token is stored.
+type EmailVerification struct {
+	ID     int64
+	UserID int64
+	Email  string
+	Hash   string
+	// Change is set when Email is a new address for the user, which
+	// replaces theirs once verified.
+	Change    bool
+	Used      bool
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (v EmailVerification) Expired() bool {
+	return time.Now().After(v.ExpiresAt)
+}
+
+type EmailVerificationRepo interface {
+	Create(v EmailVerification) (*EmailVerification, error)
+	GetByHash(hash string) (*EmailVerification, error)
+	// Use marks the verification as used. It returns ErrInvalidVerificationToken if it already was.
+	Use(v EmailVerification) error
+}